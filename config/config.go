@@ -0,0 +1,217 @@
+// Package config is a loader of go-github-sample settings
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/sataga/go-github-sample/domain/calendar"
+	"github.com/sataga/go-github-sample/domain/period"
+	dus "github.com/sataga/go-github-sample/domain/usersupport"
+	"github.com/sataga/go-github-sample/infra/publish"
+	"github.com/sataga/go-github-sample/infra/webhook"
+	"gopkg.in/yaml.v2"
+)
+
+// Config is a settings of go-github-sample
+type Config struct {
+	Target   TargetConfig     `yaml:"target"`
+	Taxonomy *dus.Taxonomy    `yaml:"taxonomy"`
+	Scoring  *dus.Scoring     `yaml:"scoring"`
+	Nudge    *dus.NudgeConfig `yaml:"nudge"`
+	Slack    SlackConfig      `yaml:"slack"`
+	Store    StoreConfig      `yaml:"store"`
+	Team     TeamConfig       `yaml:"team"`
+	Calendar CalendarConfig   `yaml:"calendar"`
+	// Period is week start and fiscal start month of report spans
+	Period  period.Config `yaml:"period"`
+	Webhook WebhookConfig `yaml:"webhook"`
+	// Publish is a repository which -publish option commits reports to
+	Publish publish.Config `yaml:"publish"`
+}
+
+// WebhookConfig is a settings of webhook receiver
+type WebhookConfig struct {
+	// Secret verifies X-Hub-Signature-256 of deliveries
+	Secret string `yaml:"secret"`
+	// Rules post messages to slack when deliveries match
+	Rules []webhook.Rule `yaml:"rules"`
+}
+
+// CalendarConfig is a settings of business calendar
+type CalendarConfig struct {
+	// BusinessHours measures durations, scores and days of daily report in business hours instead of wall-clock hours
+	BusinessHours   bool `yaml:"business_hours"`
+	calendar.Config `yaml:",inline"`
+}
+
+// TeamConfig is a settings of the support team
+type TeamConfig struct {
+	// Members are logins whose comment is regarded as a response to support issues
+	// a comment by anyone other than the author is regarded as a response when empty
+	Members []string `yaml:"members"`
+	// MaxOpenIssues flags members who have more open issues than it in assignee-report, 0 disables it
+	MaxOpenIssues int `yaml:"max_open_issues"`
+}
+
+// StoreConfig is a settings of local issue store
+// issues are read from GitHub API on every run when Dir is empty
+type StoreConfig struct {
+	Dir string `yaml:"dir"`
+}
+
+// SlackConfig is a settings of slack notification
+// chat.postMessage of Web API is used when Token is set, otherwise incoming webhook is used
+type SlackConfig struct {
+	WebhookURL string `yaml:"webhook_url"`
+	Token      string `yaml:"token"`
+	Channel    string `yaml:"channel"`
+	Username   string `yaml:"username"`
+}
+
+// TargetConfig is a settings of support issues to aggregate
+type TargetConfig struct {
+	// Owner and Repo is a single repository, used when Repositories and Orgs are empty
+	Owner string `yaml:"owner"`
+	Repo  string `yaml:"repo"`
+	// Repositories are full names (owner/name) of repositories to aggregate
+	Repositories []string `yaml:"repositories"`
+	// Orgs are organizations whose every repository is aggregated
+	Orgs         []string `yaml:"orgs"`
+	SupportLabel string   `yaml:"support_label"`
+}
+
+// Repos returns full names of repositories to aggregate
+func (t *TargetConfig) Repos() []string {
+	if len(t.Repositories) == 0 && len(t.Orgs) == 0 {
+		return []string{t.Owner + "/" + t.Repo}
+	}
+	return t.Repositories
+}
+
+// Default returns Config filled with default values
+func Default() *Config {
+	return &Config{
+		Target: TargetConfig{
+			Owner:        "sataga",
+			Repo:         "issue-warehouse",
+			SupportLabel: "PF_Support",
+		},
+		Taxonomy: dus.DefaultTaxonomy(),
+		Scoring:  dus.DefaultScoring(),
+		Nudge:    dus.DefaultNudgeConfig(),
+	}
+}
+
+// UserSupport returns settings of usersupport domain
+func (c *Config) UserSupport() (*dus.Config, error) {
+	p, err := c.Planner()
+	if err != nil {
+		return nil, err
+	}
+	cfg := &dus.Config{
+		Taxonomy:      c.Taxonomy,
+		TeamMembers:   c.Team.Members,
+		Scoring:       c.Scoring,
+		MaxOpenIssues: c.Team.MaxOpenIssues,
+		Nudge:         c.Nudge,
+		Location:      p.Location(),
+	}
+	if c.Calendar.BusinessHours {
+		cal, err := calendar.New(&c.Calendar.Config)
+		if err != nil {
+			return nil, fmt.Errorf("calendar: %s", err)
+		}
+		cfg.Calendar = cal
+	}
+	return cfg, nil
+}
+
+// Planner returns planner of report spans
+// its location is the one of calendar when period location is empty
+func (c *Config) Planner() (*period.Planner, error) {
+	cfg := c.Period
+	if cfg.Location == "" {
+		cfg.Location = c.Calendar.Location
+	}
+	p, err := period.New(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("period: %s", err)
+	}
+	return p, nil
+}
+
+// Load reads YAML config file and overwrites default values
+// it does not validate the config, which callers do after overriding values such as by flags
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if path == "" {
+		return cfg, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %s", err)
+	}
+	if err := yaml.UnmarshalStrict(b, cfg); err != nil {
+		return nil, fmt.Errorf("parse config file %s: %s", path, err)
+	}
+	if cfg.Taxonomy == nil {
+		cfg.Taxonomy = dus.DefaultTaxonomy()
+	}
+	if cfg.Scoring == nil {
+		cfg.Scoring = dus.DefaultScoring()
+	}
+	if cfg.Nudge == nil {
+		cfg.Nudge = dus.DefaultNudgeConfig()
+	}
+	return cfg, nil
+}
+
+// Validate checks required values
+func (c *Config) Validate() error {
+	if len(c.Target.Repositories) == 0 && len(c.Target.Orgs) == 0 && (c.Target.Owner == "" || c.Target.Repo == "") {
+		return fmt.Errorf("need to set target owner and repo, or repositories, or orgs")
+	}
+	for _, repo := range c.Target.Repositories {
+		if parts := strings.Split(repo, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("target repository must be owner/name: %s", repo)
+		}
+	}
+	if c.Target.SupportLabel == "" {
+		return fmt.Errorf("need to set target support_label")
+	}
+	if c.Taxonomy != nil {
+		if err := c.Taxonomy.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Scoring != nil {
+		if err := c.Scoring.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Nudge != nil {
+		if err := c.Nudge.Validate(); err != nil {
+			return err
+		}
+	}
+	for i, r := range c.Webhook.Rules {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("webhook rule %d: %s", i, err)
+		}
+	}
+	if c.Team.MaxOpenIssues < 0 {
+		return fmt.Errorf("team max_open_issues must not be negative")
+	}
+	if err := c.Publish.Validate(); err != nil {
+		return err
+	}
+	if _, err := calendar.New(&c.Calendar.Config); err != nil {
+		return fmt.Errorf("calendar: %s", err)
+	}
+	if _, err := c.Planner(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+// Package calendar is a business calendar to measure durations in working hours
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config is settings of business calendar
+type Config struct {
+	// Location is time zone of working hours, default is Asia/Tokyo
+	Location string `yaml:"location"`
+	// WorkStart and WorkEnd are working hours formatted in 15:04, default is 09:00-18:00
+	WorkStart string `yaml:"work_start"`
+	WorkEnd   string `yaml:"work_end"`
+	// Weekdays are working days of week (mon, tue, ...), default is mon-fri
+	Weekdays []string `yaml:"weekdays"`
+	// JapaneseHolidays includes national holidays of Japan, default is true
+	JapaneseHolidays *bool `yaml:"japanese_holidays"`
+	// Holidays are company holidays formatted in 2006-01-02
+	Holidays []string `yaml:"holidays"`
+}
+
+// Calendar is working hours, weekdays and holidays
+type Calendar struct {
+	loc        *time.Location
+	workStart  time.Duration
+	workEnd    time.Duration
+	weekdays   map[time.Weekday]bool
+	jpHolidays bool
+	holidays   map[string]bool
+	// jpCache caches national holidays per year, reports of spans share it concurrently
+	jpMu    sync.Mutex
+	jpCache map[int]map[string]string
+}
+
+// New creates Calendar, nil config creates default one
+func New(cfg *Config) (*Calendar, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	c := &Calendar{
+		weekdays:   make(map[time.Weekday]bool),
+		jpHolidays: cfg.JapaneseHolidays == nil || *cfg.JapaneseHolidays,
+		holidays:   make(map[string]bool),
+		jpCache:    make(map[int]map[string]string),
+	}
+	var err error
+	name := cfg.Location
+	if name == "" {
+		name = "Asia/Tokyo"
+	}
+	if c.loc, err = time.LoadLocation(name); err != nil {
+		return nil, fmt.Errorf("load location %s: %s", name, err)
+	}
+	if c.workStart, err = parseClock(cfg.WorkStart, "09:00"); err != nil {
+		return nil, err
+	}
+	if c.workEnd, err = parseClock(cfg.WorkEnd, "18:00"); err != nil {
+		return nil, err
+	}
+	if c.workEnd <= c.workStart {
+		return nil, fmt.Errorf("work_end %s must be after work_start %s", cfg.WorkEnd, cfg.WorkStart)
+	}
+	weekdays := cfg.Weekdays
+	if len(weekdays) == 0 {
+		weekdays = []string{"mon", "tue", "wed", "thu", "fri"}
+	}
+	for _, w := range weekdays {
+		wd, err := parseWeekday(w)
+		if err != nil {
+			return nil, err
+		}
+		c.weekdays[wd] = true
+	}
+	for _, h := range cfg.Holidays {
+		if _, err := time.Parse("2006-01-02", h); err != nil {
+			return nil, fmt.Errorf("holiday must be formatted in 2006-01-02: %s", h)
+		}
+		c.holidays[h] = true
+	}
+	return c, nil
+}
+
+// parseWeekday parses a name of weekday abbreviated to 3 letters or more, such as mon, Tues, Wednesday
+func parseWeekday(s string) (time.Weekday, error) {
+	l := strings.ToLower(s)
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if len(l) >= 3 && strings.HasPrefix(strings.ToLower(wd.String()), l) {
+			return wd, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown weekday: %s", s)
+}
+
+// parseClock parses time of day formatted in 15:04
+func parseClock(s, def string) (time.Duration, error) {
+	if s == "" {
+		s = def
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("time of day must be formatted in 15:04: %s", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// WorkingHours returns length of working hours of a business day
+func (c *Calendar) WorkingHours() time.Duration {
+	return c.workEnd - c.workStart
+}
+
+// japaneseHolidays returns national holidays of the year from the cache
+func (c *Calendar) japaneseHolidays(year int) map[string]string {
+	c.jpMu.Lock()
+	defer c.jpMu.Unlock()
+	holidays, ok := c.jpCache[year]
+	if !ok {
+		holidays = JapaneseHolidays(year)
+		c.jpCache[year] = holidays
+	}
+	return holidays
+}
+
+// IsBusinessDay returns whether the day of t is a working day
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	t = t.In(c.loc)
+	if !c.weekdays[t.Weekday()] {
+		return false
+	}
+	key := t.Format("2006-01-02")
+	if c.holidays[key] {
+		return false
+	}
+	if c.jpHolidays {
+		if _, ok := c.japaneseHolidays(t.Year())[key]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// BusinessDuration returns working time between from and to
+func (c *Calendar) BusinessDuration(from, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+	from, to = from.In(c.loc), to.In(c.loc)
+	var total time.Duration
+	for day := midnight(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !c.IsBusinessDay(day) {
+			continue
+		}
+		start, end := day.Add(c.workStart), day.Add(c.workEnd)
+		if from.After(start) {
+			start = from
+		}
+		if to.Before(end) {
+			end = to
+		}
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+	return total
+}
+
+// AddBusinessDays returns the time n business days after t, or before t when n is negative
+// the time of day is kept
+func (c *Calendar) AddBusinessDays(t time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for n > 0 {
+		t = t.AddDate(0, 0, step)
+		if c.IsBusinessDay(t) {
+			n--
+		}
+	}
+	return t
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
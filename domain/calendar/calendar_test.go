@@ -0,0 +1,155 @@
+package calendar
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestJapaneseHolidays(t *testing.T) {
+	tests := []struct {
+		year int
+		want map[string]string
+	}{
+		{
+			year: 2020,
+			want: map[string]string{
+				"2020-01-01": "元日",
+				"2020-01-13": "成人の日",
+				"2020-02-11": "建国記念の日",
+				"2020-02-23": "天皇誕生日",
+				"2020-02-24": "振替休日",
+				"2020-03-20": "春分の日",
+				"2020-04-29": "昭和の日",
+				"2020-05-03": "憲法記念日",
+				"2020-05-04": "みどりの日",
+				"2020-05-05": "こどもの日",
+				"2020-05-06": "振替休日",
+				"2020-07-23": "海の日",
+				"2020-07-24": "スポーツの日",
+				"2020-08-10": "山の日",
+				"2020-09-21": "敬老の日",
+				"2020-09-22": "秋分の日",
+				"2020-11-03": "文化の日",
+				"2020-11-23": "勤労感謝の日",
+			},
+		},
+		{
+			year: 2026,
+			want: map[string]string{
+				"2026-01-01": "元日",
+				"2026-01-12": "成人の日",
+				"2026-02-11": "建国記念の日",
+				"2026-02-23": "天皇誕生日",
+				"2026-03-20": "春分の日",
+				"2026-04-29": "昭和の日",
+				"2026-05-03": "憲法記念日",
+				"2026-05-04": "みどりの日",
+				"2026-05-05": "こどもの日",
+				"2026-05-06": "振替休日",
+				"2026-07-20": "海の日",
+				"2026-08-11": "山の日",
+				"2026-09-21": "敬老の日",
+				"2026-09-22": "国民の休日",
+				"2026-09-23": "秋分の日",
+				"2026-10-12": "スポーツの日",
+				"2026-11-03": "文化の日",
+				"2026-11-23": "勤労感謝の日",
+			},
+		},
+	}
+	for _, tt := range tests {
+		if got := JapaneseHolidays(tt.year); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("JapaneseHolidays(%d) = %v, want %v", tt.year, got, tt.want)
+		}
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	c, err := New(&Config{Holidays: []string{"2020-10-30"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jst, _ := time.LoadLocation("Asia/Tokyo")
+	at := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2020, month, day, hour, min, 0, 0, jst)
+	}
+
+	durations := []struct {
+		name     string
+		from, to time.Time
+		want     time.Duration
+	}{
+		{
+			name: "within a day",
+			from: at(10, 1, 10, 0),
+			to:   at(10, 1, 12, 30),
+			want: 2*time.Hour + 30*time.Minute,
+		},
+		{
+			name: "out of working hours is not counted",
+			from: at(10, 1, 7, 0),
+			to:   at(10, 1, 20, 0),
+			want: 9 * time.Hour,
+		},
+		{
+			name: "opened friday evening and replied monday morning",
+			from: at(10, 2, 17, 0),
+			to:   at(10, 5, 10, 0),
+			want: 2 * time.Hour,
+		},
+		{
+			name: "company holiday and weekend are skipped",
+			from: at(10, 29, 17, 0),
+			to:   at(11, 2, 10, 0),
+			want: 2 * time.Hour,
+		},
+		{
+			name: "national holiday is skipped",
+			from: at(11, 2, 17, 0),
+			to:   at(11, 4, 10, 0),
+			want: 2 * time.Hour,
+		},
+		{
+			name: "reversed",
+			from: at(10, 2, 10, 0),
+			to:   at(10, 1, 10, 0),
+			want: 0,
+		},
+	}
+	for _, tt := range durations {
+		if got := c.BusinessDuration(tt.from, tt.to); got != tt.want {
+			t.Errorf("BusinessDuration() %s = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	if got, want := c.AddBusinessDays(at(11, 4, 10, 0), -2), at(10, 29, 10, 0); !got.Equal(want) {
+		t.Errorf("AddBusinessDays() = %s, want %s", got, want)
+	}
+	if got, want := c.WorkingHours(), 9*time.Hour; got != want {
+		t.Errorf("WorkingHours() = %s, want %s", got, want)
+	}
+
+	if _, err := New(&Config{WorkStart: "18:00", WorkEnd: "09:00"}); err == nil {
+		t.Errorf("New() with reversed working hours error = nil")
+	}
+	for _, w := range []string{"holiday", "monkey", "mo", "月曜日"} {
+		if _, err := New(&Config{Weekdays: []string{w}}); err == nil {
+			t.Errorf("New() with unknown weekday %s error = nil", w)
+		}
+	}
+}
+
+func TestParseWeekday(t *testing.T) {
+	for s, want := range map[string]time.Weekday{
+		"sun":      time.Sunday,
+		"Mon":      time.Monday,
+		"tues":     time.Tuesday,
+		"Saturday": time.Saturday,
+	} {
+		got, err := parseWeekday(s)
+		if err != nil || got != want {
+			t.Errorf("parseWeekday(%s) = %s, %v, want %s", s, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package calendar
+
+import (
+	"time"
+)
+
+// JapaneseHolidays returns national holidays of Japan in the year keyed by date formatted in 2006-01-02
+// rules since 2016 (when Mountain Day was introduced) are implemented, including substitute holidays and citizens' holidays
+func JapaneseHolidays(year int) map[string]string {
+	days := make(map[string]string)
+	add := func(month time.Month, day int, name string) {
+		days[date(year, month, day)] = name
+	}
+
+	add(time.January, 1, "元日")
+	add(time.January, nthWeekday(year, time.January, time.Monday, 2), "成人の日")
+	add(time.February, 11, "建国記念の日")
+	switch {
+	case year >= 2020:
+		add(time.February, 23, "天皇誕生日")
+	case year <= 2018:
+		add(time.December, 23, "天皇誕生日")
+	}
+	add(time.March, vernalEquinox(year), "春分の日")
+	add(time.April, 29, "昭和の日")
+	add(time.May, 3, "憲法記念日")
+	add(time.May, 4, "みどりの日")
+	add(time.May, 5, "こどもの日")
+	// Marine Day, Mountain Day and Sports Day were moved for Tokyo Olympics
+	switch year {
+	case 2020:
+		add(time.July, 23, "海の日")
+		add(time.July, 24, "スポーツの日")
+		add(time.August, 10, "山の日")
+	case 2021:
+		add(time.July, 22, "海の日")
+		add(time.July, 23, "スポーツの日")
+		add(time.August, 8, "山の日")
+	default:
+		add(time.July, nthWeekday(year, time.July, time.Monday, 3), "海の日")
+		add(time.August, 11, "山の日")
+		name := "スポーツの日"
+		if year < 2020 {
+			name = "体育の日"
+		}
+		add(time.October, nthWeekday(year, time.October, time.Monday, 2), name)
+	}
+	add(time.September, nthWeekday(year, time.September, time.Monday, 3), "敬老の日")
+	add(time.September, autumnalEquinox(year), "秋分の日")
+	add(time.November, 3, "文化の日")
+	add(time.November, 23, "勤労感謝の日")
+	if year == 2019 {
+		add(time.April, 30, "国民の休日")
+		add(time.May, 1, "即位の日")
+		add(time.May, 2, "国民の休日")
+		add(time.October, 22, "即位礼正殿の儀")
+	}
+
+	// a day between two holidays is a holiday
+	for d := time.Date(year, time.January, 2, 0, 0, 0, 0, time.UTC); d.Year() == year; d = d.AddDate(0, 0, 1) {
+		key := d.Format("2006-01-02")
+		if _, ok := days[key]; ok || d.Weekday() == time.Sunday {
+			continue
+		}
+		_, before := days[d.AddDate(0, 0, -1).Format("2006-01-02")]
+		_, after := days[d.AddDate(0, 0, 1).Format("2006-01-02")]
+		if before && after {
+			days[key] = "国民の休日"
+		}
+	}
+	// a holiday on Sunday is substituted by the next day which is not a holiday
+	for key := range copyKeys(days) {
+		d, _ := time.Parse("2006-01-02", key)
+		if d.Weekday() != time.Sunday {
+			continue
+		}
+		for sub := d.AddDate(0, 0, 1); ; sub = sub.AddDate(0, 0, 1) {
+			if _, ok := days[sub.Format("2006-01-02")]; !ok {
+				days[sub.Format("2006-01-02")] = "振替休日"
+				break
+			}
+		}
+	}
+	return days
+}
+
+func copyKeys(m map[string]string) map[string]bool {
+	keys := make(map[string]bool, len(m))
+	for k := range m {
+		keys[k] = true
+	}
+	return keys
+}
+
+func date(year int, month time.Month, day int) string {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+}
+
+// nthWeekday returns day of the n-th weekday in the month
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) int {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC).Weekday()
+	return 1 + (int(weekday)-int(first)+7)%7 + (n-1)*7
+}
+
+// vernalEquinox returns day of vernal equinox in March, which is valid from 1980 to 2099
+func vernalEquinox(year int) int {
+	return int(20.8431+0.242194*float64(year-1980)) - (year-1980)/4
+}
+
+// autumnalEquinox returns day of autumnal equinox in September, which is valid from 1980 to 2099
+func autumnalEquinox(year int) int {
+	return int(23.2488+0.242194*float64(year-1980)) - (year-1980)/4
+}
@@ -0,0 +1,201 @@
+// Package period plans spans which reports aggregate, such as weeks, months, quarters and fiscal years
+package period
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kind is a length of spans
+type Kind string
+
+// kinds of spans
+const (
+	Daily      Kind = "daily"
+	Weekly     Kind = "weekly"
+	Monthly    Kind = "monthly"
+	Quarterly  Kind = "quarterly"
+	HalfYearly Kind = "half-yearly"
+	FiscalYear Kind = "fiscal-year"
+	// Custom is an arbitrary range of dates, planned by Planner.CustomSpans
+	Custom Kind = "custom"
+)
+
+// Kinds are all kinds of spans
+var Kinds = []Kind{Daily, Weekly, Monthly, Quarterly, HalfYearly, FiscalYear, Custom}
+
+// ParseKind parses name of kind
+func ParseKind(s string) (Kind, error) {
+	for _, k := range Kinds {
+		if string(k) == s {
+			return k, nil
+		}
+	}
+	return "", fmt.Errorf("unknown kind: %s", s)
+}
+
+// KindNames returns names of all kinds joined by comma, for help of flags
+func KindNames() string {
+	names := make([]string, 0, len(Kinds))
+	for _, k := range Kinds {
+		names = append(names, string(k))
+	}
+	return strings.Join(names, ", ")
+}
+
+// Span is a period which a report aggregates, both ends are inclusive dates
+type Span struct {
+	Since time.Time
+	Until time.Time
+}
+
+// End returns the last instant of the span
+// repositories match times before until, so reports pass it instead of Until to count the last day
+func (s Span) End() time.Time {
+	return s.Until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+}
+
+// String returns the span formatted as "since~until", which reports are keyed by
+func (s Span) String() string {
+	return fmt.Sprintf("%s~%s", s.Since.Format("2006-01-02"), s.Until.Format("2006-01-02"))
+}
+
+// Config is settings of periods
+type Config struct {
+	// Location is time zone which dates begin in, default is Asia/Tokyo
+	Location string `yaml:"location"`
+	// WeekStart is the first day of weekly spans (sun, mon, ...), default is mon
+	WeekStart string `yaml:"week_start"`
+	// FiscalStartMonth is the first month (1-12) of fiscal years, default is 4
+	// quarters and half years are counted from it
+	FiscalStartMonth int `yaml:"fiscal_start_month"`
+}
+
+// Planner plans spans of kinds in its location
+type Planner struct {
+	loc         *time.Location
+	weekStart   time.Weekday
+	fiscalStart time.Month
+}
+
+// New creates Planner, nil config creates default one
+func New(cfg *Config) (*Planner, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	p := &Planner{weekStart: time.Monday, fiscalStart: time.April}
+	name := cfg.Location
+	if name == "" {
+		name = "Asia/Tokyo"
+	}
+	var err error
+	if p.loc, err = time.LoadLocation(name); err != nil {
+		return nil, fmt.Errorf("load location %s: %s", name, err)
+	}
+	if cfg.WeekStart != "" {
+		if p.weekStart, err = parseWeekday(cfg.WeekStart); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.FiscalStartMonth != 0 {
+		if cfg.FiscalStartMonth < 1 || 12 < cfg.FiscalStartMonth {
+			return nil, fmt.Errorf("fiscal_start_month must be 1-12: %d", cfg.FiscalStartMonth)
+		}
+		p.fiscalStart = time.Month(cfg.FiscalStartMonth)
+	}
+	return p, nil
+}
+
+// parseWeekday parses a name of weekday such as sun, Monday
+func parseWeekday(s string) (time.Weekday, error) {
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		name := strings.ToLower(wd.String())
+		if l := strings.ToLower(s); len(l) >= 3 && strings.HasPrefix(name, l) {
+			return wd, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown weekday: %s", s)
+}
+
+// Location returns time zone of the planner
+func (p *Planner) Location() *time.Location {
+	return p.loc
+}
+
+// ParseDate parses a date formatted in 2006-01-02 in the location
+func (p *Planner) ParseDate(s string) (time.Time, error) {
+	t, err := time.ParseInLocation("2006-01-02", s, p.loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("date must be formatted in 2006-01-02: %s", s)
+	}
+	return t, nil
+}
+
+// Span returns the span of kind which contains t
+func (p *Planner) Span(kind Kind, t time.Time) (Span, error) {
+	t = t.In(p.loc)
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, p.loc)
+	switch kind {
+	case Daily:
+		return Span{Since: date, Until: date}, nil
+	case Weekly:
+		since := date.AddDate(0, 0, -((int(date.Weekday()) - int(p.weekStart) + 7) % 7))
+		return Span{Since: since, Until: since.AddDate(0, 0, 6)}, nil
+	case Monthly:
+		return p.monthSpan(date, 1), nil
+	case Quarterly:
+		return p.monthSpan(date, 3), nil
+	case HalfYearly:
+		return p.monthSpan(date, 6), nil
+	case FiscalYear:
+		return p.monthSpan(date, 12), nil
+	case Custom:
+		return Span{}, fmt.Errorf("custom kind needs since and until")
+	}
+	return Span{}, fmt.Errorf("unknown kind: %s", kind)
+}
+
+// monthSpan returns the span of months months which contains date, counted from the fiscal start month
+func (p *Planner) monthSpan(date time.Time, months int) Span {
+	offset := (int(date.Month()) - int(p.fiscalStart) + 12) % 12 % months
+	since := time.Date(date.Year(), date.Month()-time.Month(offset), 1, 0, 0, 0, 0, p.loc)
+	return Span{Since: since, Until: since.AddDate(0, months, -1)}
+}
+
+// Spans returns n spans of kind going back from the one which contains origin, the latest first
+func (p *Planner) Spans(kind Kind, origin time.Time, n int) ([]Span, error) {
+	span, err := p.Span(kind, origin)
+	if err != nil {
+		return nil, err
+	}
+	spans := make([]Span, 0, n)
+	for i := 0; i < n; i++ {
+		spans = append(spans, span)
+		// the previous span contains the day before since
+		if span, err = p.Span(kind, span.Since.AddDate(0, 0, -1)); err != nil {
+			return nil, err
+		}
+	}
+	return spans, nil
+}
+
+// CustomSpans returns n spans as long as since~until going back from it, the latest first
+func (p *Planner) CustomSpans(since, until time.Time, n int) ([]Span, error) {
+	since, until = since.In(p.loc), until.In(p.loc)
+	since = time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, p.loc)
+	until = time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, p.loc)
+	if until.Before(since) {
+		return nil, fmt.Errorf("until %s must not be before since %s", until.Format("2006-01-02"), since.Format("2006-01-02"))
+	}
+	days := 1
+	for d := since; d.Before(until); d = d.AddDate(0, 0, 1) {
+		days++
+	}
+	spans := make([]Span, 0, n)
+	for i := 0; i < n; i++ {
+		spans = append(spans, Span{Since: since, Until: until})
+		since, until = since.AddDate(0, 0, -days), since.AddDate(0, 0, -1)
+	}
+	return spans, nil
+}
@@ -0,0 +1,201 @@
+package period
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlanner_Spans(t *testing.T) {
+	jp, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// origin is Thursday
+	origin := time.Date(2020, 10, 15, 0, 0, 0, 0, jp)
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, jp)
+	}
+	tests := []struct {
+		name    string
+		cfg     *Config
+		kind    Kind
+		want    []Span
+		wantErr bool
+	}{
+		{
+			name: "daily",
+			kind: Daily,
+			want: []Span{
+				{Since: date(2020, 10, 15), Until: date(2020, 10, 15)},
+				{Since: date(2020, 10, 14), Until: date(2020, 10, 14)},
+				{Since: date(2020, 10, 13), Until: date(2020, 10, 13)},
+			},
+		},
+		{
+			name: "weekly from monday",
+			kind: Weekly,
+			want: []Span{
+				{Since: date(2020, 10, 12), Until: date(2020, 10, 18)},
+				{Since: date(2020, 10, 5), Until: date(2020, 10, 11)},
+				{Since: date(2020, 9, 28), Until: date(2020, 10, 4)},
+			},
+		},
+		{
+			name: "weekly from sunday",
+			cfg:  &Config{WeekStart: "sun"},
+			kind: Weekly,
+			want: []Span{
+				{Since: date(2020, 10, 11), Until: date(2020, 10, 17)},
+				{Since: date(2020, 10, 4), Until: date(2020, 10, 10)},
+				{Since: date(2020, 9, 27), Until: date(2020, 10, 3)},
+			},
+		},
+		{
+			name: "monthly",
+			kind: Monthly,
+			want: []Span{
+				{Since: date(2020, 10, 1), Until: date(2020, 10, 31)},
+				{Since: date(2020, 9, 1), Until: date(2020, 9, 30)},
+				{Since: date(2020, 8, 1), Until: date(2020, 8, 31)},
+			},
+		},
+		{
+			name: "quarterly",
+			kind: Quarterly,
+			want: []Span{
+				{Since: date(2020, 10, 1), Until: date(2020, 12, 31)},
+				{Since: date(2020, 7, 1), Until: date(2020, 9, 30)},
+				{Since: date(2020, 4, 1), Until: date(2020, 6, 30)},
+			},
+		},
+		{
+			name: "quarterly from february",
+			cfg:  &Config{FiscalStartMonth: 2},
+			kind: Quarterly,
+			want: []Span{
+				{Since: date(2020, 8, 1), Until: date(2020, 10, 31)},
+				{Since: date(2020, 5, 1), Until: date(2020, 7, 31)},
+				{Since: date(2020, 2, 1), Until: date(2020, 4, 30)},
+			},
+		},
+		{
+			name: "half-yearly",
+			kind: HalfYearly,
+			want: []Span{
+				{Since: date(2020, 10, 1), Until: date(2021, 3, 31)},
+				{Since: date(2020, 4, 1), Until: date(2020, 9, 30)},
+				{Since: date(2019, 10, 1), Until: date(2020, 3, 31)},
+			},
+		},
+		{
+			name: "fiscal-year",
+			kind: FiscalYear,
+			want: []Span{
+				{Since: date(2020, 4, 1), Until: date(2021, 3, 31)},
+				{Since: date(2019, 4, 1), Until: date(2020, 3, 31)},
+				{Since: date(2018, 4, 1), Until: date(2019, 3, 31)},
+			},
+		},
+		{
+			name: "fiscal-year from january",
+			cfg:  &Config{FiscalStartMonth: 1},
+			kind: FiscalYear,
+			want: []Span{
+				{Since: date(2020, 1, 1), Until: date(2020, 12, 31)},
+				{Since: date(2019, 1, 1), Until: date(2019, 12, 31)},
+				{Since: date(2018, 1, 1), Until: date(2018, 12, 31)},
+			},
+		},
+		{
+			name:    "custom needs range",
+			kind:    Custom,
+			wantErr: true,
+		},
+		{
+			name:    "unknown kind",
+			kind:    Kind("yearly"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.Spans(tt.kind, origin, 3)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Planner.Spans() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Planner.Spans() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanner_CustomSpans(t *testing.T) {
+	p, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	since, _ := p.ParseDate("2020-10-01")
+	until, _ := p.ParseDate("2020-10-15")
+	got, err := p.CustomSpans(since, until, 3)
+	if err != nil {
+		t.Fatalf("Planner.CustomSpans() error = %v", err)
+	}
+	want := []string{"2020-10-01~2020-10-15", "2020-09-16~2020-09-30", "2020-09-01~2020-09-15"}
+	for i, span := range got {
+		if span.String() != want[i] {
+			t.Errorf("Planner.CustomSpans()[%d] = %s, want %s", i, span, want[i])
+		}
+	}
+	if _, err := p.CustomSpans(until, since, 3); err == nil {
+		t.Errorf("Planner.CustomSpans() with until before since should fail")
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *Config
+		wantErr bool
+	}{
+		{name: "default", cfg: nil},
+		{name: "full weekday name", cfg: &Config{WeekStart: "Sunday", FiscalStartMonth: 10}},
+		{name: "unknown weekday", cfg: &Config{WeekStart: "su"}, wantErr: true},
+		{name: "fiscal start month out of range", cfg: &Config{FiscalStartMonth: 13}, wantErr: true},
+		{name: "unknown location", cfg: &Config{Location: "Mars/Olympus"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSpan_End(t *testing.T) {
+	p, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	span, err := p.Span(Daily, time.Date(2020, 10, 3, 12, 0, 0, 0, p.Location()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// an issue closed at the end of the last day is before End
+	closed := time.Date(2020, 10, 3, 23, 59, 59, 0, p.Location())
+	if !closed.After(span.Since) || !closed.Before(span.End()) {
+		t.Errorf("Span.End() = %s, want after %s", span.End(), closed)
+	}
+	if next := span.Until.AddDate(0, 0, 1); next.Before(span.End()) {
+		t.Errorf("Span.End() = %s, want before the next day %s", span.End(), next)
+	}
+	if got := span.End().Format("2006-01-02"); got != "2020-10-03" {
+		t.Errorf("Span.End() is on %s, want 2020-10-03", got)
+	}
+}
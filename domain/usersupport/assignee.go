@@ -0,0 +1,166 @@
+package usersupport
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Unassigned is the login which issues without assignee are counted for
+const Unassigned = "(unassigned)"
+
+// AssigneeStats is workload per assignee. Assignees are keyed by login
+type AssigneeStats struct {
+	// Span is "since~until" of closed issues formatted in 2006-01-02
+	Span   string `json:"span" yaml:"span"`
+	DayAgo int    `json:"day_ago" yaml:"day_ago"`
+	// MaxOpenIssues is the threshold of open issues to flag an assignee as overloaded, 0 disables it
+	MaxOpenIssues int                         `json:"max_open_issues" yaml:"max_open_issues"`
+	Assignees     map[string]*AssigneeSummary `json:"assignees" yaml:"assignees"`
+}
+
+// AssigneeSummary is workload of an assignee
+// an issue with several assignees is counted for each of them
+type AssigneeSummary struct {
+	Login string `json:"login" yaml:"login"`
+	// NumOpenIssues and NumStaleIssues are of currently open issues, stale ones have not been updated for DayAgo days
+	NumOpenIssues  int `json:"num_open_issues" yaml:"num_open_issues"`
+	NumStaleIssues int `json:"num_stale_issues" yaml:"num_stale_issues"`
+	// NumClosedIssues is of issues closed in the span
+	NumClosedIssues int `json:"num_closed_issues" yaml:"num_closed_issues"`
+	// ResolutionMedian is median hours from created to closed of closed issues
+	ResolutionMedian    int `json:"resolution_median" yaml:"resolution_median"`
+	NumEscalationIssues int `json:"num_escalation_issues" yaml:"num_escalation_issues"`
+	// EscalationRatio is percentage of escalated issues in closed issues
+	EscalationRatio float64 `json:"escalation_ratio" yaml:"escalation_ratio"`
+	// Overloaded is true when NumOpenIssues exceeds MaxOpenIssues
+	Overloaded bool `json:"overloaded" yaml:"overloaded"`
+}
+
+// GetAssigneeReportStats returns workload of open issues at now and issues closed in the span per assignee
+func (us *userSupport) GetAssigneeReportStats(ctx context.Context, now, since, until time.Time, dayAgo int) (*AssigneeStats, error) {
+	opi, err := us.repo.GetCurrentOpenSupportIssues(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get open issues : %w", err)
+	}
+	stale, err := us.repo.GetCurrentOpenNotUpdatedSupportIssues(ctx, us.daysBefore(now, dayAgo))
+	if err != nil {
+		return nil, fmt.Errorf("get not updated issues : %w", err)
+	}
+	cli, err := us.repo.GetClosedSupportIssues(ctx, since, until)
+	if err != nil {
+		return nil, fmt.Errorf("get closed issues : %w", err)
+	}
+	as := &AssigneeStats{
+		Span:          fmt.Sprintf("%s~%s", since.Format("2006-01-02"), until.Format("2006-01-02")),
+		DayAgo:        dayAgo,
+		MaxOpenIssues: us.maxOpenIssues,
+		Assignees:     make(map[string]*AssigneeSummary),
+	}
+	summary := func(login string) *AssigneeSummary {
+		if _, ok := as.Assignees[login]; !ok {
+			as.Assignees[login] = &AssigneeSummary{Login: login}
+		}
+		return as.Assignees[login]
+	}
+	for _, issue := range opi {
+		for _, login := range assigneeLogins(issue) {
+			summary(login).NumOpenIssues++
+		}
+	}
+	for _, issue := range stale {
+		for _, login := range assigneeLogins(issue) {
+			summary(login).NumStaleIssues++
+		}
+	}
+	resolutions := make(map[string][]int)
+	for _, issue := range cli {
+		lc := us.tx().ClassifyIssue(issue)
+		for _, login := range assigneeLogins(issue) {
+			s := summary(login)
+			s.NumClosedIssues++
+			if lc.Escalation {
+				s.NumEscalationIssues++
+			}
+			resolutions[login] = append(resolutions[login], us.openDuration(issue))
+		}
+	}
+	for login, s := range as.Assignees {
+		s.ResolutionMedian = percentile(resolutions[login], 50)
+		if s.NumClosedIssues > 0 {
+			s.EscalationRatio = round1(float64(s.NumEscalationIssues) / float64(s.NumClosedIssues) * 100)
+		}
+		s.Overloaded = as.MaxOpenIssues > 0 && login != Unassigned && s.NumOpenIssues > as.MaxOpenIssues
+	}
+	return as, nil
+}
+
+// assigneeLogins returns logins of assignees, or Unassigned
+func assigneeLogins(issue *Issue) []string {
+	var logins []string
+	for _, a := range issue.Assignees {
+		if a.Login != "" {
+			logins = append(logins, a.Login)
+		}
+	}
+	if len(logins) == 0 {
+		return []string{Unassigned}
+	}
+	return logins
+}
+
+// List returns summaries in order of open issues, then login
+func (as *AssigneeStats) List() []*AssigneeSummary {
+	list := make([]*AssigneeSummary, 0, len(as.Assignees))
+	for _, s := range as.Assignees {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].NumOpenIssues != list[j].NumOpenIssues {
+			return list[i].NumOpenIssues > list[j].NumOpenIssues
+		}
+		return list[i].Login < list[j].Login
+	})
+	return list
+}
+
+func (as *AssigneeStats) header() []string {
+	return []string{"担当者", "対応中", fmt.Sprintf("%d日以上未更新", as.DayAgo), "クローズ", "解決時間 中央値(時間)", "エスカレ率(％)", "過負荷"}
+}
+
+func (as *AssigneeStats) records() [][]string {
+	var records [][]string
+	for _, s := range as.List() {
+		overloaded := ""
+		if s.Overloaded {
+			overloaded = "○"
+		}
+		records = append(records, []string{s.Login, strconv.Itoa(s.NumOpenIssues), strconv.Itoa(s.NumStaleIssues), strconv.Itoa(s.NumClosedIssues), strconv.Itoa(s.ResolutionMedian), fmt.Sprintf("%.1f", s.EscalationRatio), overloaded})
+	}
+	return records
+}
+
+// GenMarkdown generates table of workload per assignee
+func (as *AssigneeStats) GenMarkdown() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## 担当者別 (クローズ: %s) \n", as.Span))
+	header := as.header()
+	sb.WriteString(fmt.Sprintf("|%s|\n", strings.Join(header, "|")))
+	sb.WriteString(strings.Repeat("|----", len(header)) + "|\n")
+	for _, r := range as.records() {
+		r[0] = escapeMarkdownCell(r[0])
+		sb.WriteString(fmt.Sprintf("|%s|\n", strings.Join(r, "|")))
+	}
+	if as.MaxOpenIssues > 0 {
+		sb.WriteString(fmt.Sprintf("\n過負荷: 対応中が %d 件を超える担当者\n", as.MaxOpenIssues))
+	}
+	return sb.String()
+}
+
+// GenCSV generates workload per assignee
+func (as *AssigneeStats) GenCSV() string {
+	return genCSV(append([][]string{as.header()}, as.records()...))
+}
@@ -0,0 +1,70 @@
+package usersupport
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func Test_userSupport_GetAssigneeReportStats(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	assign := func(issue *Issue, logins ...string) *Issue {
+		is := *issue
+		is.Assignees = nil
+		for _, l := range logins {
+			is.Assignees = append(is.Assignees, User{Login: l})
+		}
+		return &is
+	}
+	open := []*Issue{
+		assign(issuePatterns[2], "alice"),
+		assign(issuePatterns[3], "alice", "bob"),
+		assign(issuePatterns[3]),
+	}
+	stale := []*Issue{open[1]}
+	// issue 1 is escalated and resolved in 168 hours, issue 2 in 96 hours
+	closed := []*Issue{
+		assign(issuePatterns[0], "alice"),
+		assign(issuePatterns[1], "alice", "bob"),
+	}
+	musr := NewMockRepository(c)
+	musr.EXPECT().GetCurrentOpenSupportIssues(gomock.Any()).Return(open, nil)
+	musr.EXPECT().GetCurrentOpenNotUpdatedSupportIssues(gomock.Any(), gomock.Any()).Return(stale, nil)
+	musr.EXPECT().GetClosedSupportIssues(gomock.Any(), firstDayOfMonth, lastDayOfMonth).Return(closed, nil)
+
+	us := NewUserSupport(musr, &Config{MaxOpenIssues: 1})
+	got, err := us.GetAssigneeReportStats(context.Background(), now, firstDayOfMonth, lastDayOfMonth, 3)
+	if err != nil {
+		t.Fatalf("userSupport.GetAssigneeReportStats() error = %v", err)
+	}
+	want := &AssigneeStats{
+		Span:          startEnd,
+		DayAgo:        3,
+		MaxOpenIssues: 1,
+		Assignees: map[string]*AssigneeSummary{
+			"alice":    {Login: "alice", NumOpenIssues: 2, NumStaleIssues: 1, NumClosedIssues: 2, ResolutionMedian: 96, NumEscalationIssues: 1, EscalationRatio: 50, Overloaded: true},
+			"bob":      {Login: "bob", NumOpenIssues: 1, NumStaleIssues: 1, NumClosedIssues: 1, ResolutionMedian: 96},
+			Unassigned: {Login: Unassigned, NumOpenIssues: 1},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		for k, v := range got.Assignees {
+			t.Logf("%s: %+v", k, v)
+		}
+		t.Fatalf("userSupport.GetAssigneeReportStats() = %+v, want %+v", got, want)
+	}
+
+	wantMarkdown := "## 担当者別 (クローズ: " + startEnd + ") \n" +
+		"|担当者|対応中|3日以上未更新|クローズ|解決時間 中央値(時間)|エスカレ率(％)|過負荷|\n" +
+		"|----|----|----|----|----|----|----|\n" +
+		"|alice|2|1|2|96|50.0|○|\n" +
+		"|(unassigned)|1|0|0|0|0.0||\n" +
+		"|bob|1|1|1|96|0.0||\n" +
+		"\n過負荷: 対応中が 1 件を超える担当者\n"
+	if got := got.GenMarkdown(); got != wantMarkdown {
+		t.Errorf("AssigneeStats.GenMarkdown() = %v, want %v", got, wantMarkdown)
+	}
+}
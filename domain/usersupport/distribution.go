@@ -0,0 +1,125 @@
+package usersupport
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Distribution is percentiles of durations by nearest-rank method
+type Distribution struct {
+	Count int `json:"count" yaml:"count"`
+	P50   int `json:"p50" yaml:"p50"`
+	P75   int `json:"p75" yaml:"p75"`
+	P90   int `json:"p90" yaml:"p90"`
+	P95   int `json:"p95" yaml:"p95"`
+	Max   int `json:"max" yaml:"max"`
+}
+
+// DistributionStats is distribution of durations of all issues and broken down by classes
+// ByGenre and ByUrgency are keyed by class, issues without class are counted only in All
+type DistributionStats struct {
+	All       *Distribution            `json:"all" yaml:"all"`
+	ByGenre   map[string]*Distribution `json:"by_genre" yaml:"by_genre"`
+	ByUrgency map[string]*Distribution `json:"by_urgency" yaml:"by_urgency"`
+}
+
+// newDistribution returns distribution of values
+func newDistribution(values []int) *Distribution {
+	return &Distribution{
+		Count: len(values),
+		P50:   percentile(values, 50),
+		P75:   percentile(values, 75),
+		P90:   percentile(values, 90),
+		P95:   percentile(values, 95),
+		Max:   percentile(values, 100),
+	}
+}
+
+// durationSamples collects durations of issues to build DistributionStats
+type durationSamples struct {
+	all     []int
+	genre   map[string][]int
+	urgency map[string][]int
+}
+
+func newDurationSamples() *durationSamples {
+	return &durationSamples{
+		genre:   make(map[string][]int),
+		urgency: make(map[string][]int),
+	}
+}
+
+func (s *durationSamples) add(lc *LabelClass, value int) {
+	s.all = append(s.all, value)
+	if lc.GenreClass != "" {
+		s.genre[lc.GenreClass] = append(s.genre[lc.GenreClass], value)
+	}
+	if lc.UrgencyClass != "" {
+		s.urgency[lc.UrgencyClass] = append(s.urgency[lc.UrgencyClass], value)
+	}
+}
+
+func (s *durationSamples) stats() *DistributionStats {
+	ds := &DistributionStats{
+		All:       newDistribution(s.all),
+		ByGenre:   make(map[string]*Distribution, len(s.genre)),
+		ByUrgency: make(map[string]*Distribution, len(s.urgency)),
+	}
+	for class, values := range s.genre {
+		ds.ByGenre[class] = newDistribution(values)
+	}
+	for class, values := range s.urgency {
+		ds.ByUrgency[class] = newDistribution(values)
+	}
+	return ds
+}
+
+// distributionClasses returns classes which m has distributions of
+func distributionClasses(m map[string]*Distribution) []string {
+	classes := make([]string, 0, len(m))
+	for c := range m {
+		classes = append(classes, c)
+	}
+	return classes
+}
+
+// writeDistributions writes table of distributions of the summaries, nothing is written when no summary has them
+// classes are named by genres and urgencies
+func writeDistributions(sb *strings.Builder, summaries []*SummaryStats, genres, urgencies []*ClassName) {
+	var rows [][]string
+	for _, ss := range summaries {
+		for _, m := range []struct {
+			name string
+			ds   *DistributionStats
+		}{
+			{"解決時間(時間)", ss.ResolutionTime},
+			{"初回応答時間(分)", ss.FirstResponseTime},
+		} {
+			if m.ds == nil {
+				continue
+			}
+			rows = append(rows, distributionRow(ss.Span, m.name, "全体", m.ds.All))
+			for _, c := range sortedClasses(distributionClasses(m.ds.ByGenre), genres) {
+				rows = append(rows, distributionRow(ss.Span, m.name, "ジャンル:"+c.Display, m.ds.ByGenre[c.Class]))
+			}
+			for _, c := range sortedClasses(distributionClasses(m.ds.ByUrgency), urgencies) {
+				rows = append(rows, distributionRow(ss.Span, m.name, "緊急度:"+c.Display, m.ds.ByUrgency[c.Class]))
+			}
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+	sb.WriteString("## 分布 \n")
+	sb.WriteString("|期間|項目|区分|件数|p50|p75|p90|p95|最大|\n")
+	sb.WriteString("|----|----|----|----|----|----|----|----|----|\n")
+	for _, row := range rows {
+		sb.WriteString(fmt.Sprintf("|%s|\n", strings.Join(row, "|")))
+	}
+	sb.WriteString("\n")
+}
+
+func distributionRow(span, metric, group string, d *Distribution) []string {
+	return []string{span, metric, group, strconv.Itoa(d.Count), strconv.Itoa(d.P50), strconv.Itoa(d.P75), strconv.Itoa(d.P90), strconv.Itoa(d.P95), strconv.Itoa(d.Max)}
+}
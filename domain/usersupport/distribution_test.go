@@ -0,0 +1,70 @@
+package usersupport
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewDistribution(t *testing.T) {
+	values := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 100}
+	want := &Distribution{Count: 10, P50: 5, P75: 8, P90: 9, P95: 100, Max: 100}
+	if got := newDistribution(values); !reflect.DeepEqual(got, want) {
+		t.Errorf("newDistribution() = %+v, want %+v", got, want)
+	}
+	if got := newDistribution(nil); !reflect.DeepEqual(got, &Distribution{}) {
+		t.Errorf("newDistribution(nil) = %+v, want zero", got)
+	}
+}
+
+func TestWriteDistributions(t *testing.T) {
+	samples := newDurationSamples()
+	samples.add(&LabelClass{GenreClass: ClassGenreFailure, UrgencyClass: ClassUrgencyHigh}, 2)
+	samples.add(&LabelClass{GenreClass: ClassGenreNormal}, 30)
+	summaries := []*SummaryStats{
+		{Span: "2020-10-01~2020-10-31", ResolutionTime: samples.stats()},
+		// summaries without distributions are skipped
+		{Span: "2020-09-01~2020-09-30"},
+	}
+	tx := DefaultTaxonomy()
+	var sb strings.Builder
+	writeDistributions(&sb, summaries, tx.Classes(DimensionGenre), tx.Classes(DimensionUrgency))
+	want := `## 分布 
+|期間|項目|区分|件数|p50|p75|p90|p95|最大|
+|----|----|----|----|----|----|----|----|----|
+|2020-10-01~2020-10-31|解決時間(時間)|全体|2|2|30|30|30|30|
+|2020-10-01~2020-10-31|解決時間(時間)|ジャンル:通常問合せ|1|30|30|30|30|30|
+|2020-10-01~2020-10-31|解決時間(時間)|ジャンル:サービス障害|1|2|2|2|2|2|
+|2020-10-01~2020-10-31|解決時間(時間)|緊急度:高|1|2|2|2|2|2|
+
+`
+	if got := sb.String(); got != want {
+		t.Errorf("writeDistributions() = %v, want %v", got, want)
+	}
+
+	sb.Reset()
+	writeDistributions(&sb, summaries[1:], tx.Classes(DimensionGenre), tx.Classes(DimensionUrgency))
+	if got := sb.String(); got != "" {
+		t.Errorf("writeDistributions() = %v, want empty", got)
+	}
+
+	// classes are named by display of the configured taxonomy, and those out of it by themselves
+	tx = &Taxonomy{Rules: []LabelRule{
+		{Dimension: DimensionGenre, Label: "type:incident", Display: "Incident", Class: ClassGenreFailure},
+		{Dimension: DimensionUrgency, Label: "priority:high", Class: ClassUrgencyHigh},
+	}}
+	sb.Reset()
+	writeDistributions(&sb, summaries, tx.Classes(DimensionGenre), tx.Classes(DimensionUrgency))
+	want = `## 分布 
+|期間|項目|区分|件数|p50|p75|p90|p95|最大|
+|----|----|----|----|----|----|----|----|----|
+|2020-10-01~2020-10-31|解決時間(時間)|全体|2|2|30|30|30|30|
+|2020-10-01~2020-10-31|解決時間(時間)|ジャンル:Incident|1|2|2|2|2|2|
+|2020-10-01~2020-10-31|解決時間(時間)|ジャンル:normal|1|30|30|30|30|30|
+|2020-10-01~2020-10-31|解決時間(時間)|緊急度:high|1|2|2|2|2|2|
+
+`
+	if got := sb.String(); got != want {
+		t.Errorf("writeDistributions() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,39 @@
+package usersupport
+
+import (
+	"time"
+)
+
+// duration returns time between from and to, which is in business hours when calendar is configured
+func (us *userSupport) duration(from, to time.Time) time.Duration {
+	if us.calendar == nil {
+		return to.Sub(from)
+	}
+	return us.calendar.BusinessDuration(from, to)
+}
+
+// dayHours returns hours of a day to score durations, which is working hours when calendar is configured
+func (us *userSupport) dayHours() int {
+	if us.calendar == nil {
+		return 24
+	}
+	return int(us.calendar.WorkingHours().Hours())
+}
+
+// daysBefore returns the time days before now, which skips non-business days when calendar is configured
+func (us *userSupport) daysBefore(now time.Time, days int) time.Time {
+	if us.calendar == nil {
+		return now.Add(time.Duration(-24*days) * time.Hour)
+	}
+	return us.calendar.AddBusinessDays(now, -days)
+}
+
+// openDuration returns hours from created to closed, or to last updated when still open
+// it is 0 when the times are missing
+func (us *userSupport) openDuration(issue *Issue) int {
+	end := issue.closedOrUpdatedAt()
+	if issue.CreatedAt.IsZero() || end.Before(issue.CreatedAt) {
+		return 0
+	}
+	return int(us.duration(issue.CreatedAt, end).Hours())
+}
@@ -0,0 +1,60 @@
+package usersupport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sataga/go-github-sample/domain/calendar"
+)
+
+func TestUserSupport_BusinessHours(t *testing.T) {
+	cal, err := calendar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// opened friday evening and closed next tuesday morning
+	created := time.Date(2020, 10, 2, 17, 0, 0, 0, loc)
+	closed := time.Date(2020, 10, 6, 10, 0, 0, 0, loc)
+	issue := &Issue{
+		State:     "closed",
+		CreatedAt: created,
+		ClosedAt:  closed,
+	}
+	now := time.Date(2020, 10, 5, 10, 0, 0, 0, loc)
+	tests := []struct {
+		name             string
+		cfg              *Config
+		wantOpenDuration int
+		wantDayHours     int
+		wantDaysBefore   time.Time
+	}{
+		{
+			name:             "wall-clock",
+			cfg:              &Config{},
+			wantOpenDuration: 89,
+			wantDayHours:     24,
+			wantDaysBefore:   time.Date(2020, 10, 3, 10, 0, 0, 0, loc),
+		},
+		{
+			name:             "business hours",
+			cfg:              &Config{Calendar: cal},
+			wantOpenDuration: 11,
+			wantDayHours:     9,
+			wantDaysBefore:   time.Date(2020, 10, 1, 10, 0, 0, 0, loc),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := NewUserSupport(nil, tt.cfg).(*userSupport)
+			if got := us.openDuration(issue); got != tt.wantOpenDuration {
+				t.Errorf("openDuration() = %d, want %d", got, tt.wantOpenDuration)
+			}
+			if got := us.dayHours(); got != tt.wantDayHours {
+				t.Errorf("dayHours() = %d, want %d", got, tt.wantDayHours)
+			}
+			if got := us.daysBefore(now, 2); !got.Equal(tt.wantDaysBefore) {
+				t.Errorf("daysBefore() = %s, want %s", got, tt.wantDaysBefore)
+			}
+		})
+	}
+}
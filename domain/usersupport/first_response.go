@@ -0,0 +1,60 @@
+package usersupport
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// firstResponse returns the first comment by the support team after the issue was created
+// when no team member is configured, a comment by anyone other than the author is regarded as a response
+func (us *userSupport) firstResponse(issue *Issue, comments []*Comment) *Comment {
+	sorted := make([]*Comment, 0, len(comments))
+	for _, c := range comments {
+		if c != nil {
+			sorted = append(sorted, c)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+	author := issue.User.Login
+	for _, c := range sorted {
+		if c.CreatedAt.Before(issue.CreatedAt) {
+			continue
+		}
+		login := c.User.Login
+		if len(us.teamMembers) == 0 {
+			if login != author {
+				return c
+			}
+			continue
+		}
+		if us.teamMembers[strings.ToLower(login)] {
+			return c
+		}
+	}
+	return nil
+}
+
+// writeFirstResponse writes minutes until the first response of the support team
+func (ds *DetailStats) writeFirstResponse(elapsed time.Duration) {
+	ds.Responded = true
+	ds.FirstResponse = int(elapsed / time.Minute)
+}
+
+// percentile returns p-th percentile of values by nearest-rank method, 0 when values are empty
+func percentile(values []int, p float64) int {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]int, len(values))
+	copy(sorted, values)
+	sort.Ints(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
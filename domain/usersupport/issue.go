@@ -0,0 +1,84 @@
+package usersupport
+
+import "time"
+
+// Issue is a support issue, which does not depend on where it comes from
+// fields which the source does not have are left zero
+type Issue struct {
+	ID int64
+	// Repository is full name (owner/name) of the repository which the issue belongs to
+	Repository string
+	Number     int
+	Title      string
+	Body       string
+	// State is open or closed
+	State     string
+	HTMLURL   string
+	User      User
+	Assignees []User
+	Labels    []Label
+	// NumComments is the number of comments, comments themselves are read by Repository.GetIssueComments
+	NumComments int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	// ClosedAt is zero while the issue is open
+	ClosedAt time.Time
+}
+
+// User is an account who opens, is assigned to and comments on issues
+type User struct {
+	Login string
+}
+
+// Label is a label attached to issues
+type Label struct {
+	Name string
+}
+
+// Comment is a comment on an issue
+type Comment struct {
+	ID        int64
+	Body      string
+	User      User
+	CreatedAt time.Time
+}
+
+// Event is an event of an issue such as labeled, unlabeled and closed
+type Event struct {
+	Event string
+	// Label is the label added or removed by labeled and unlabeled events
+	Label     Label
+	CreatedAt time.Time
+}
+
+// IsClosed returns whether the issue is closed
+func (is *Issue) IsClosed() bool {
+	return is.State == "closed"
+}
+
+// LabelNames returns names of labels attached to the issue
+func (is *Issue) LabelNames() []string {
+	names := make([]string, 0, len(is.Labels))
+	for _, l := range is.Labels {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+// HasLabel returns whether the label is attached to the issue
+func (is *Issue) HasLabel(name string) bool {
+	for _, l := range is.Labels {
+		if l.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// closedOrUpdatedAt returns when the issue was closed, or last updated when it is open or closed time is missing
+func (is *Issue) closedOrUpdatedAt() time.Time {
+	if is.IsClosed() && !is.ClosedAt.IsZero() {
+		return is.ClosedAt
+	}
+	return is.UpdatedAt
+}
@@ -0,0 +1,95 @@
+package usersupport
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ClassNone is class of issues which have no classified label, used as value of metrics labels
+const ClassNone = "none"
+
+// IssueClasses is classes of an issue which metrics are broken down by
+type IssueClasses struct {
+	Urgency string
+	Team    string
+	Genre   string
+}
+
+// MetricsStats is snapshot of support issues to export as metrics
+type MetricsStats struct {
+	DayAgo int
+	// OpenIssues are counts of open issues, which have every combination of classes of the taxonomy
+	OpenIssues map[IssueClasses]int
+	// StaleIssues is count of open issues which have not been updated for DayAgo days
+	StaleIssues int
+	// ResolutionHours are hours from created to closed of issues closed in the last WindowDays days
+	WindowDays      int
+	ResolutionHours map[IssueClasses][]int
+}
+
+// GetMetricsStats returns snapshot of open issues and resolution time of recently closed issues
+func (us *userSupport) GetMetricsStats(ctx context.Context, now time.Time, dayAgo, windowDays int) (*MetricsStats, error) {
+	opi, err := us.repo.GetCurrentOpenSupportIssues(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get open issues : %w", err)
+	}
+	stale, err := us.repo.GetCurrentOpenNotUpdatedSupportIssues(ctx, us.daysBefore(now, dayAgo))
+	if err != nil {
+		return nil, fmt.Errorf("get not updated issues : %w", err)
+	}
+	cli, err := us.repo.GetClosedSupportIssues(ctx, now.AddDate(0, 0, -windowDays), now)
+	if err != nil {
+		return nil, fmt.Errorf("get closed issues : %w", err)
+	}
+	ms := &MetricsStats{
+		DayAgo:          dayAgo,
+		OpenIssues:      us.zeroIssueClasses(),
+		StaleIssues:     len(stale),
+		WindowDays:      windowDays,
+		ResolutionHours: make(map[IssueClasses][]int),
+	}
+	for _, issue := range opi {
+		ms.OpenIssues[issueClasses(us.tx().ClassifyIssue(issue))]++
+	}
+	for _, issue := range cli {
+		ic := issueClasses(us.tx().ClassifyIssue(issue))
+		ms.ResolutionHours[ic] = append(ms.ResolutionHours[ic], us.openDuration(issue))
+	}
+	return ms, nil
+}
+
+// zeroIssueClasses returns counts of every combination of classes of the taxonomy and ClassNone,
+// so metrics of classes without open issues report 0 rather than disappear
+func (us *userSupport) zeroIssueClasses() map[IssueClasses]int {
+	classes := func(dim Dimension) []string {
+		names := []string{ClassNone}
+		for _, c := range us.tx().Classes(dim) {
+			names = append(names, c.Class)
+		}
+		return names
+	}
+	counts := make(map[IssueClasses]int)
+	for _, urgency := range classes(DimensionUrgency) {
+		for _, team := range classes(DimensionTeam) {
+			for _, genre := range classes(DimensionGenre) {
+				counts[IssueClasses{Urgency: urgency, Team: team, Genre: genre}] = 0
+			}
+		}
+	}
+	return counts
+}
+
+func issueClasses(lc *LabelClass) IssueClasses {
+	orNone := func(class string) string {
+		if class == "" {
+			return ClassNone
+		}
+		return class
+	}
+	return IssueClasses{
+		Urgency: orNone(lc.UrgencyClass),
+		Team:    orNone(lc.TeamClass),
+		Genre:   orNone(lc.GenreClass),
+	}
+}
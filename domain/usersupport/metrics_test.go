@@ -0,0 +1,56 @@
+package usersupport
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func Test_userSupport_GetMetricsStats(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	unclassified := &Issue{
+		State:     "open",
+		CreatedAt: threeDayAgo,
+		UpdatedAt: tenDayAgo,
+		Labels:    []Label{{Name: "PF_Support"}},
+	}
+	open := []*Issue{issuePatterns[2], issuePatterns[3], unclassified}
+	closed := []*Issue{issuePatterns[0], issuePatterns[1]}
+	musr := NewMockRepository(c)
+	musr.EXPECT().GetCurrentOpenSupportIssues(gomock.Any()).Return(open, nil)
+	musr.EXPECT().GetCurrentOpenNotUpdatedSupportIssues(gomock.Any(), gomock.Any()).Return([]*Issue{unclassified}, nil)
+	musr.EXPECT().GetClosedSupportIssues(gomock.Any(), now.AddDate(0, 0, -30), now).Return(closed, nil)
+
+	got, err := NewUserSupport(musr, nil).GetMetricsStats(context.Background(), now, 7, 30)
+	if err != nil {
+		t.Fatalf("userSupport.GetMetricsStats() error = %v", err)
+	}
+	// every combination of classes is counted, so classes without open issues are 0
+	openIssues := make(map[IssueClasses]int)
+	for _, urgency := range []string{ClassNone, ClassUrgencyHigh, ClassUrgencyMedium, ClassUrgencyLow} {
+		for _, team := range []string{ClassNone, ClassTeamA, ClassTeamB} {
+			for _, genre := range []string{ClassNone, ClassGenreNormal, ClassGenreRequest, ClassGenreFailure} {
+				openIssues[IssueClasses{Urgency: urgency, Team: team, Genre: genre}] = 0
+			}
+		}
+	}
+	openIssues[IssueClasses{Urgency: ClassUrgencyHigh, Team: ClassTeamA, Genre: ClassGenreFailure}] = 1
+	openIssues[IssueClasses{Urgency: ClassUrgencyLow, Team: ClassTeamB, Genre: ClassGenreNormal}] = 1
+	openIssues[IssueClasses{Urgency: ClassNone, Team: ClassNone, Genre: ClassNone}] = 1
+	want := &MetricsStats{
+		DayAgo:      7,
+		OpenIssues:  openIssues,
+		StaleIssues: 1,
+		WindowDays:  30,
+		ResolutionHours: map[IssueClasses][]int{
+			{Urgency: ClassUrgencyLow, Team: ClassTeamA, Genre: ClassGenreNormal}:     {168},
+			{Urgency: ClassUrgencyMedium, Team: ClassTeamA, Genre: ClassGenreRequest}: {96},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("userSupport.GetMetricsStats() = %+v, want %+v", got, want)
+	}
+}
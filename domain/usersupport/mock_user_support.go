@@ -5,11 +5,11 @@
 package usersupport
 
 import (
+	context "context"
 	reflect "reflect"
 	time "time"
 
 	gomock "github.com/golang/mock/gomock"
-	github "github.com/google/go-github/github"
 )
 
 // MockUserSupport is a mock of UserSupport interface.
@@ -36,78 +36,138 @@ func (m *MockUserSupport) EXPECT() *MockUserSupportMockRecorder {
 }
 
 // GetAnalysisReportStats mocks base method.
-func (m *MockUserSupport) GetAnalysisReportStats(since, until time.Time, state string, span int) (*AnalysisStats, error) {
+func (m *MockUserSupport) GetAnalysisReportStats(ctx context.Context, since, until time.Time, state string) (*AnalysisStats, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAnalysisReportStats", since, until, state, span)
+	ret := m.ctrl.Call(m, "GetAnalysisReportStats", ctx, since, until, state)
 	ret0, _ := ret[0].(*AnalysisStats)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAnalysisReportStats indicates an expected call of GetAnalysisReportStats.
-func (mr *MockUserSupportMockRecorder) GetAnalysisReportStats(since, until, state, span interface{}) *gomock.Call {
+func (mr *MockUserSupportMockRecorder) GetAnalysisReportStats(ctx, since, until, state interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAnalysisReportStats", reflect.TypeOf((*MockUserSupport)(nil).GetAnalysisReportStats), since, until, state, span)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAnalysisReportStats", reflect.TypeOf((*MockUserSupport)(nil).GetAnalysisReportStats), ctx, since, until, state)
+}
+
+// GetAssigneeReportStats mocks base method.
+func (m *MockUserSupport) GetAssigneeReportStats(ctx context.Context, now, since, until time.Time, dayAgo int) (*AssigneeStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAssigneeReportStats", ctx, now, since, until, dayAgo)
+	ret0, _ := ret[0].(*AssigneeStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAssigneeReportStats indicates an expected call of GetAssigneeReportStats.
+func (mr *MockUserSupportMockRecorder) GetAssigneeReportStats(ctx, now, since, until, dayAgo interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAssigneeReportStats", reflect.TypeOf((*MockUserSupport)(nil).GetAssigneeReportStats), ctx, now, since, until, dayAgo)
 }
 
 // GetDailyReportStats mocks base method.
-func (m *MockUserSupport) GetDailyReportStats(now time.Time, dayAgo int) (*DailyStats, error) {
+func (m *MockUserSupport) GetDailyReportStats(ctx context.Context, now time.Time, dayAgo int) (*DailyStats, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetDailyReportStats", now, dayAgo)
+	ret := m.ctrl.Call(m, "GetDailyReportStats", ctx, now, dayAgo)
 	ret0, _ := ret[0].(*DailyStats)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetDailyReportStats indicates an expected call of GetDailyReportStats.
-func (mr *MockUserSupportMockRecorder) GetDailyReportStats(now, dayAgo interface{}) *gomock.Call {
+func (mr *MockUserSupportMockRecorder) GetDailyReportStats(ctx, now, dayAgo interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDailyReportStats", reflect.TypeOf((*MockUserSupport)(nil).GetDailyReportStats), now, dayAgo)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDailyReportStats", reflect.TypeOf((*MockUserSupport)(nil).GetDailyReportStats), ctx, now, dayAgo)
 }
 
 // GetKeywordReportStats mocks base method.
-func (m *MockUserSupport) GetKeywordReportStats(until time.Time, kind string, span int) (*KeywordStats, error) {
+func (m *MockUserSupport) GetKeywordReportStats(ctx context.Context, since, until time.Time) (*KeywordStats, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetKeywordReportStats", until, kind, span)
+	ret := m.ctrl.Call(m, "GetKeywordReportStats", ctx, since, until)
 	ret0, _ := ret[0].(*KeywordStats)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetKeywordReportStats indicates an expected call of GetKeywordReportStats.
-func (mr *MockUserSupportMockRecorder) GetKeywordReportStats(until, kind, span interface{}) *gomock.Call {
+func (mr *MockUserSupportMockRecorder) GetKeywordReportStats(ctx, since, until interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeywordReportStats", reflect.TypeOf((*MockUserSupport)(nil).GetKeywordReportStats), until, kind, span)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeywordReportStats", reflect.TypeOf((*MockUserSupport)(nil).GetKeywordReportStats), ctx, since, until)
 }
 
 // GetLongTermReportStats mocks base method.
-func (m *MockUserSupport) GetLongTermReportStats(until time.Time, kind string, span int) (*LongTermStats, error) {
+func (m *MockUserSupport) GetLongTermReportStats(ctx context.Context, since, until time.Time) (*LongTermStats, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLongTermReportStats", until, kind, span)
+	ret := m.ctrl.Call(m, "GetLongTermReportStats", ctx, since, until)
 	ret0, _ := ret[0].(*LongTermStats)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetLongTermReportStats indicates an expected call of GetLongTermReportStats.
-func (mr *MockUserSupportMockRecorder) GetLongTermReportStats(until, kind, span interface{}) *gomock.Call {
+func (mr *MockUserSupportMockRecorder) GetLongTermReportStats(ctx, since, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLongTermReportStats", reflect.TypeOf((*MockUserSupport)(nil).GetLongTermReportStats), ctx, since, until)
+}
+
+// GetMetricsStats mocks base method.
+func (m *MockUserSupport) GetMetricsStats(ctx context.Context, now time.Time, dayAgo, windowDays int) (*MetricsStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMetricsStats", ctx, now, dayAgo, windowDays)
+	ret0, _ := ret[0].(*MetricsStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMetricsStats indicates an expected call of GetMetricsStats.
+func (mr *MockUserSupportMockRecorder) GetMetricsStats(ctx, now, dayAgo, windowDays interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetricsStats", reflect.TypeOf((*MockUserSupport)(nil).GetMetricsStats), ctx, now, dayAgo, windowDays)
+}
+
+// GetTimelineReportStats mocks base method.
+func (m *MockUserSupport) GetTimelineReportStats(ctx context.Context, since, until time.Time) (*TimelineStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTimelineReportStats", ctx, since, until)
+	ret0, _ := ret[0].(*TimelineStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTimelineReportStats indicates an expected call of GetTimelineReportStats.
+func (mr *MockUserSupportMockRecorder) GetTimelineReportStats(ctx, since, until interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLongTermReportStats", reflect.TypeOf((*MockUserSupport)(nil).GetLongTermReportStats), until, kind, span)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTimelineReportStats", reflect.TypeOf((*MockUserSupport)(nil).GetTimelineReportStats), ctx, since, until)
 }
 
 // MethodTest mocks base method.
-func (m *MockUserSupport) MethodTest(since, until time.Time) (*AnalysisStats, error) {
+func (m *MockUserSupport) MethodTest(ctx context.Context, since, until time.Time) (*AnalysisStats, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "MethodTest", since, until)
+	ret := m.ctrl.Call(m, "MethodTest", ctx, since, until)
 	ret0, _ := ret[0].(*AnalysisStats)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // MethodTest indicates an expected call of MethodTest.
-func (mr *MockUserSupportMockRecorder) MethodTest(since, until interface{}) *gomock.Call {
+func (mr *MockUserSupportMockRecorder) MethodTest(ctx, since, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MethodTest", reflect.TypeOf((*MockUserSupport)(nil).MethodTest), ctx, since, until)
+}
+
+// NudgeStaleIssues mocks base method.
+func (m *MockUserSupport) NudgeStaleIssues(ctx context.Context, now time.Time, dayAgo int, dryRun bool) (*NudgeStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NudgeStaleIssues", ctx, now, dayAgo, dryRun)
+	ret0, _ := ret[0].(*NudgeStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NudgeStaleIssues indicates an expected call of NudgeStaleIssues.
+func (mr *MockUserSupportMockRecorder) NudgeStaleIssues(ctx, now, dayAgo, dryRun interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MethodTest", reflect.TypeOf((*MockUserSupport)(nil).MethodTest), since, until)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NudgeStaleIssues", reflect.TypeOf((*MockUserSupport)(nil).NudgeStaleIssues), ctx, now, dayAgo, dryRun)
 }
 
 // MockRepository is a mock of Repository interface.
@@ -133,92 +193,137 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CreateIssueComment mocks base method.
+func (m *MockRepository) CreateIssueComment(ctx context.Context, issue *Issue, body string) (*Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIssueComment", ctx, issue, body)
+	ret0, _ := ret[0].(*Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIssueComment indicates an expected call of CreateIssueComment.
+func (mr *MockRepositoryMockRecorder) CreateIssueComment(ctx, issue, body interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIssueComment", reflect.TypeOf((*MockRepository)(nil).CreateIssueComment), ctx, issue, body)
+}
+
 // GetClosedSupportIssues mocks base method.
-func (m *MockRepository) GetClosedSupportIssues(since, until time.Time) ([]*github.Issue, error) {
+func (m *MockRepository) GetClosedSupportIssues(ctx context.Context, since, until time.Time) ([]*Issue, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetClosedSupportIssues", since, until)
-	ret0, _ := ret[0].([]*github.Issue)
+	ret := m.ctrl.Call(m, "GetClosedSupportIssues", ctx, since, until)
+	ret0, _ := ret[0].([]*Issue)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetClosedSupportIssues indicates an expected call of GetClosedSupportIssues.
-func (mr *MockRepositoryMockRecorder) GetClosedSupportIssues(since, until interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetClosedSupportIssues(ctx, since, until interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClosedSupportIssues", reflect.TypeOf((*MockRepository)(nil).GetClosedSupportIssues), since, until)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClosedSupportIssues", reflect.TypeOf((*MockRepository)(nil).GetClosedSupportIssues), ctx, since, until)
 }
 
 // GetCreatedSupportIssues mocks base method.
-func (m *MockRepository) GetCreatedSupportIssues(since, until time.Time) ([]*github.Issue, error) {
+func (m *MockRepository) GetCreatedSupportIssues(ctx context.Context, since, until time.Time) ([]*Issue, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCreatedSupportIssues", since, until)
-	ret0, _ := ret[0].([]*github.Issue)
+	ret := m.ctrl.Call(m, "GetCreatedSupportIssues", ctx, since, until)
+	ret0, _ := ret[0].([]*Issue)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetCreatedSupportIssues indicates an expected call of GetCreatedSupportIssues.
-func (mr *MockRepositoryMockRecorder) GetCreatedSupportIssues(since, until interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetCreatedSupportIssues(ctx, since, until interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCreatedSupportIssues", reflect.TypeOf((*MockRepository)(nil).GetCreatedSupportIssues), since, until)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCreatedSupportIssues", reflect.TypeOf((*MockRepository)(nil).GetCreatedSupportIssues), ctx, since, until)
 }
 
 // GetCurrentOpenNotUpdatedSupportIssues mocks base method.
-func (m *MockRepository) GetCurrentOpenNotUpdatedSupportIssues(until time.Time) ([]*github.Issue, error) {
+func (m *MockRepository) GetCurrentOpenNotUpdatedSupportIssues(ctx context.Context, until time.Time) ([]*Issue, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCurrentOpenNotUpdatedSupportIssues", until)
-	ret0, _ := ret[0].([]*github.Issue)
+	ret := m.ctrl.Call(m, "GetCurrentOpenNotUpdatedSupportIssues", ctx, until)
+	ret0, _ := ret[0].([]*Issue)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetCurrentOpenNotUpdatedSupportIssues indicates an expected call of GetCurrentOpenNotUpdatedSupportIssues.
-func (mr *MockRepositoryMockRecorder) GetCurrentOpenNotUpdatedSupportIssues(until interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetCurrentOpenNotUpdatedSupportIssues(ctx, until interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentOpenNotUpdatedSupportIssues", reflect.TypeOf((*MockRepository)(nil).GetCurrentOpenNotUpdatedSupportIssues), until)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentOpenNotUpdatedSupportIssues", reflect.TypeOf((*MockRepository)(nil).GetCurrentOpenNotUpdatedSupportIssues), ctx, until)
 }
 
 // GetCurrentOpenSupportIssues mocks base method.
-func (m *MockRepository) GetCurrentOpenSupportIssues() ([]*github.Issue, error) {
+func (m *MockRepository) GetCurrentOpenSupportIssues(ctx context.Context) ([]*Issue, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCurrentOpenSupportIssues")
-	ret0, _ := ret[0].([]*github.Issue)
+	ret := m.ctrl.Call(m, "GetCurrentOpenSupportIssues", ctx)
+	ret0, _ := ret[0].([]*Issue)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetCurrentOpenSupportIssues indicates an expected call of GetCurrentOpenSupportIssues.
-func (mr *MockRepositoryMockRecorder) GetCurrentOpenSupportIssues() *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetCurrentOpenSupportIssues(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentOpenSupportIssues", reflect.TypeOf((*MockRepository)(nil).GetCurrentOpenSupportIssues), ctx)
+}
+
+// GetIssueComments mocks base method.
+func (m *MockRepository) GetIssueComments(ctx context.Context, issue *Issue) ([]*Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIssueComments", ctx, issue)
+	ret0, _ := ret[0].([]*Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIssueComments indicates an expected call of GetIssueComments.
+func (mr *MockRepositoryMockRecorder) GetIssueComments(ctx, issue interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIssueComments", reflect.TypeOf((*MockRepository)(nil).GetIssueComments), ctx, issue)
+}
+
+// GetIssueEvents mocks base method.
+func (m *MockRepository) GetIssueEvents(ctx context.Context, issue *Issue) ([]*Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIssueEvents", ctx, issue)
+	ret0, _ := ret[0].([]*Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIssueEvents indicates an expected call of GetIssueEvents.
+func (mr *MockRepositoryMockRecorder) GetIssueEvents(ctx, issue interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentOpenSupportIssues", reflect.TypeOf((*MockRepository)(nil).GetCurrentOpenSupportIssues))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIssueEvents", reflect.TypeOf((*MockRepository)(nil).GetIssueEvents), ctx, issue)
 }
 
 // GetLabelsByQuery mocks base method.
-func (m *MockRepository) GetLabelsByQuery(query string) ([]*github.LabelResult, error) {
+func (m *MockRepository) GetLabelsByQuery(ctx context.Context, query string) ([]*Label, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLabelsByQuery", query)
-	ret0, _ := ret[0].([]*github.LabelResult)
+	ret := m.ctrl.Call(m, "GetLabelsByQuery", ctx, query)
+	ret0, _ := ret[0].([]*Label)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetLabelsByQuery indicates an expected call of GetLabelsByQuery.
-func (mr *MockRepositoryMockRecorder) GetLabelsByQuery(query interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetLabelsByQuery(ctx, query interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLabelsByQuery", reflect.TypeOf((*MockRepository)(nil).GetLabelsByQuery), query)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLabelsByQuery", reflect.TypeOf((*MockRepository)(nil).GetLabelsByQuery), ctx, query)
 }
 
 // GetUpdatedSupportIssues mocks base method.
-func (m *MockRepository) GetUpdatedSupportIssues(since, until time.Time) ([]*github.Issue, error) {
+func (m *MockRepository) GetUpdatedSupportIssues(ctx context.Context, since, until time.Time) ([]*Issue, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUpdatedSupportIssues", since, until)
-	ret0, _ := ret[0].([]*github.Issue)
+	ret := m.ctrl.Call(m, "GetUpdatedSupportIssues", ctx, since, until)
+	ret0, _ := ret[0].([]*Issue)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUpdatedSupportIssues indicates an expected call of GetUpdatedSupportIssues.
-func (mr *MockRepositoryMockRecorder) GetUpdatedSupportIssues(since, until interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetUpdatedSupportIssues(ctx, since, until interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpdatedSupportIssues", reflect.TypeOf((*MockRepository)(nil).GetUpdatedSupportIssues), since, until)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpdatedSupportIssues", reflect.TypeOf((*MockRepository)(nil).GetUpdatedSupportIssues), ctx, since, until)
 }
@@ -0,0 +1,212 @@
+package usersupport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// NudgeMarker is hidden in nudge comments to find the last nudge
+const NudgeMarker = "<!-- go-github-sample:nudge -->"
+
+const defaultNudgeTemplate = `{{if .Mentions}}{{.Mentions}} {{end}}この Issue は {{.DayAgo}} 日以上更新されていません。状況の更新をお願いします。`
+
+// Statuses of nudges
+const (
+	NudgeStatusNudged   = "nudged"
+	NudgeStatusDryRun   = "dry-run"
+	NudgeStatusExcluded = "excluded"
+	NudgeStatusCooldown = "cooldown"
+	NudgeStatusFailed   = "failed"
+)
+
+// NudgeConfig is settings of comments on stale issues
+type NudgeConfig struct {
+	// Template is text/template of the comment executed with NudgeData, default one is used when empty
+	Template string `yaml:"template"`
+	// ExcludeLabels are labels of issues which are never nudged
+	ExcludeLabels []string `yaml:"exclude_labels"`
+	// CooldownDays is days not to nudge the same issue again
+	CooldownDays int `yaml:"cooldown_days"`
+}
+
+// DefaultNudgeConfig returns NudgeConfig which nudges each issue at most once a week
+func DefaultNudgeConfig() *NudgeConfig {
+	return &NudgeConfig{CooldownDays: 7}
+}
+
+// Validate checks the template
+func (c *NudgeConfig) Validate() error {
+	if c.CooldownDays < 0 {
+		return fmt.Errorf("nudge cooldown_days must not be negative")
+	}
+	if _, err := c.template(); err != nil {
+		return fmt.Errorf("nudge template: %s", err)
+	}
+	return nil
+}
+
+func (c *NudgeConfig) template() (*template.Template, error) {
+	text := c.Template
+	if text == "" {
+		text = defaultNudgeTemplate
+	}
+	return template.New("nudge").Parse(text)
+}
+
+// NudgeData is passed to the template of nudge comments
+type NudgeData struct {
+	Issue *Issue
+	// Mentions are space separated @login of assignees
+	Mentions string
+	DayAgo   int
+	// DaysNotUpdated is days since the issue was updated
+	DaysNotUpdated int
+}
+
+// NudgeStats is result of nudging stale issues
+type NudgeStats struct {
+	DayAgo int          `json:"day_ago" yaml:"day_ago"`
+	DryRun bool         `json:"dry_run" yaml:"dry_run"`
+	Nudges []*NudgeItem `json:"nudges" yaml:"nudges"`
+}
+
+// NudgeItem is a stale issue and whether it was nudged
+type NudgeItem struct {
+	Repository string `json:"repository" yaml:"repository"`
+	Title      string `json:"title" yaml:"title"`
+	HTMLURL    string `json:"html_url" yaml:"html_url"`
+	// Status is one of nudged, dry-run, excluded, cooldown and failed
+	Status string `json:"status" yaml:"status"`
+	// Body is the comment which is posted, or would be posted in dry-run
+	Body string `json:"body" yaml:"body"`
+}
+
+// nudgeConfig returns settings of nudges, default one is used when not configured
+func (us *userSupport) nudgeConfig() *NudgeConfig {
+	if us.nudge == nil {
+		return DefaultNudgeConfig()
+	}
+	return us.nudge
+}
+
+// NudgeStaleIssues comments on open issues which have not been updated for dayAgo days, mentioning their assignees
+// issues which have an exclusion label or were nudged within cooldown are skipped, and nothing is posted in dry-run
+// on error after listing issues, it returns stats of issues handled until the error with it, so comments already posted are reported
+func (us *userSupport) NudgeStaleIssues(ctx context.Context, now time.Time, dayAgo int, dryRun bool) (*NudgeStats, error) {
+	cfg := us.nudgeConfig()
+	tmpl, err := cfg.template()
+	if err != nil {
+		return nil, fmt.Errorf("parse nudge template: %s", err)
+	}
+	stale, err := us.repo.GetCurrentOpenNotUpdatedSupportIssues(ctx, us.daysBefore(now, dayAgo))
+	if err != nil {
+		return nil, fmt.Errorf("get not updated issues : %w", err)
+	}
+	ns := &NudgeStats{
+		DayAgo: dayAgo,
+		DryRun: dryRun,
+		Nudges: make([]*NudgeItem, 0, len(stale)),
+	}
+	cooldownSince := now.AddDate(0, 0, -cfg.CooldownDays)
+	for _, issue := range stale {
+		item := &NudgeItem{
+			Repository: issue.Repository,
+			Title:      issue.Title,
+			HTMLURL:    issue.HTMLURL,
+		}
+		ns.Nudges = append(ns.Nudges, item)
+		if hasAnyLabel(issue, cfg.ExcludeLabels) {
+			item.Status = NudgeStatusExcluded
+			continue
+		}
+		comments, err := us.repo.GetIssueComments(ctx, issue)
+		if err != nil {
+			item.Status = NudgeStatusFailed
+			return ns, fmt.Errorf("get issue comments : %w", err)
+		}
+		if nudgedSince(comments, cooldownSince) {
+			item.Status = NudgeStatusCooldown
+			continue
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, &NudgeData{
+			Issue:          issue,
+			Mentions:       mentions(issue),
+			DayAgo:         dayAgo,
+			DaysNotUpdated: int(now.Sub(issue.UpdatedAt).Hours() / 24),
+		}); err != nil {
+			item.Status = NudgeStatusFailed
+			return ns, fmt.Errorf("execute nudge template: %s", err)
+		}
+		item.Body = buf.String() + "\n\n" + NudgeMarker
+		if dryRun {
+			item.Status = NudgeStatusDryRun
+			continue
+		}
+		if _, err := us.repo.CreateIssueComment(ctx, issue, item.Body); err != nil {
+			item.Status = NudgeStatusFailed
+			return ns, fmt.Errorf("create nudge comment : %w", err)
+		}
+		item.Status = NudgeStatusNudged
+	}
+	return ns, nil
+}
+
+// nudgedSince checks whether any nudge comment was posted since the time
+func nudgedSince(comments []*Comment, since time.Time) bool {
+	for _, c := range comments {
+		if c != nil && strings.Contains(c.Body, NudgeMarker) && !c.CreatedAt.Before(since) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyLabel(issue *Issue, names []string) bool {
+	for _, name := range names {
+		if issue.HasLabel(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// mentions returns space separated @login of assignees
+func mentions(issue *Issue) string {
+	var logins []string
+	for _, a := range issue.Assignees {
+		if a.Login != "" {
+			logins = append(logins, "@"+a.Login)
+		}
+	}
+	return strings.Join(logins, " ")
+}
+
+// GenMarkdown generates list of stale issues and whether they were nudged
+func (ns *NudgeStats) GenMarkdown() string {
+	var sb strings.Builder
+	title := "催促コメント"
+	if ns.DryRun {
+		title += " (dry-run)"
+	}
+	sb.WriteString(fmt.Sprintf("=== %s: %d日以上未更新 %d件 ===\n", title, ns.DayAgo, len(ns.Nudges)))
+	for _, n := range ns.Nudges {
+		sb.WriteString(fmt.Sprintf("- [%s](%s) %s\n", escapeMarkdownCell(n.Title), n.HTMLURL, n.Status))
+	}
+	return sb.String()
+}
+
+// GenCSV generates list of stale issues, status and comments
+func (ns *NudgeStats) GenCSV() string {
+	records := [][]string{
+		{"リポジトリ", "Title", "URL", "ステータス", "コメント"},
+	}
+	for _, n := range ns.Nudges {
+		records = append(records, []string{n.Repository, n.Title, n.HTMLURL, n.Status, n.Body})
+	}
+	return genCSV(records)
+}
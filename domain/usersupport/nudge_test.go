@@ -0,0 +1,114 @@
+package usersupport
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func Test_userSupport_NudgeStaleIssues(t *testing.T) {
+	stale := func(number int, labels ...string) *Issue {
+		is := &Issue{
+			Number:      number,
+			Title:       "stale issue",
+			State:       "open",
+			CreatedAt:   tenDayAgo,
+			UpdatedAt:   tenDayAgo,
+			NumComments: 1,
+			Assignees:   []User{{Login: "alice"}, {Login: "bob"}},
+			HTMLURL:     "https://github.com/sataga/issue-warehouse/issues/1",
+			Repository:  "sataga/issue-warehouse",
+		}
+		for _, l := range labels {
+			is.Labels = append(is.Labels, Label{Name: l})
+		}
+		return is
+	}
+	excluded := stale(1, "PF_Support", "on-hold")
+	cooled := stale(2, "PF_Support")
+	target := stale(3, "PF_Support")
+	issues := []*Issue{excluded, cooled, target}
+	nudgedComments := []*Comment{
+		{Body: "ping\n\n" + NudgeMarker, CreatedAt: threeDayAgo},
+	}
+	oldComments := []*Comment{
+		{Body: "ping\n\n" + NudgeMarker, CreatedAt: tenDayAgo},
+	}
+	cfg := &Config{Nudge: &NudgeConfig{
+		Template:      "{{.Mentions}} {{.DaysNotUpdated}}日更新がありません #{{.Issue.Number}}",
+		ExcludeLabels: []string{"on-hold"},
+		CooldownDays:  7,
+	}}
+	wantBody := "@alice @bob 10日更新がありません #3\n\n" + NudgeMarker
+
+	for _, dryRun := range []bool{true, false} {
+		c := gomock.NewController(t)
+		musr := NewMockRepository(c)
+		musr.EXPECT().GetCurrentOpenNotUpdatedSupportIssues(gomock.Any(), gomock.Any()).Return(issues, nil)
+		musr.EXPECT().GetIssueComments(gomock.Any(), cooled).Return(nudgedComments, nil)
+		musr.EXPECT().GetIssueComments(gomock.Any(), target).Return(oldComments, nil)
+		wantStatus := NudgeStatusDryRun
+		if !dryRun {
+			musr.EXPECT().CreateIssueComment(gomock.Any(), target, wantBody).Return(&Comment{}, nil)
+			wantStatus = NudgeStatusNudged
+		}
+		got, err := NewUserSupport(musr, cfg).NudgeStaleIssues(context.Background(), now, 7, dryRun)
+		if err != nil {
+			t.Fatalf("userSupport.NudgeStaleIssues() error = %v", err)
+		}
+		want := &NudgeStats{
+			DayAgo: 7,
+			DryRun: dryRun,
+			Nudges: []*NudgeItem{
+				{Repository: "sataga/issue-warehouse", Title: "stale issue", HTMLURL: "https://github.com/sataga/issue-warehouse/issues/1", Status: NudgeStatusExcluded},
+				{Repository: "sataga/issue-warehouse", Title: "stale issue", HTMLURL: "https://github.com/sataga/issue-warehouse/issues/1", Status: NudgeStatusCooldown},
+				{Repository: "sataga/issue-warehouse", Title: "stale issue", HTMLURL: "https://github.com/sataga/issue-warehouse/issues/1", Status: wantStatus, Body: wantBody},
+			},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("userSupport.NudgeStaleIssues(dryRun=%t) = %+v, want %+v", dryRun, got.Nudges, want.Nudges)
+		}
+		c.Finish()
+	}
+}
+
+func Test_userSupport_NudgeStaleIssues_Failure(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	first := &Issue{Number: 1, Title: "first", UpdatedAt: tenDayAgo}
+	second := &Issue{Number: 2, Title: "second", UpdatedAt: tenDayAgo}
+	third := &Issue{Number: 3, Title: "third", UpdatedAt: tenDayAgo}
+	failed := errors.New("502 bad gateway")
+	musr := NewMockRepository(c)
+	musr.EXPECT().GetCurrentOpenNotUpdatedSupportIssues(gomock.Any(), gomock.Any()).Return([]*Issue{first, second, third}, nil)
+	musr.EXPECT().GetIssueComments(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+	musr.EXPECT().CreateIssueComment(gomock.Any(), first, gomock.Any()).Return(&Comment{}, nil)
+	musr.EXPECT().CreateIssueComment(gomock.Any(), second, gomock.Any()).Return(nil, failed)
+
+	got, err := NewUserSupport(musr, nil).NudgeStaleIssues(context.Background(), now, 7, false)
+	if !errors.Is(err, failed) {
+		t.Fatalf("userSupport.NudgeStaleIssues() error = %v, want %v", err, failed)
+	}
+	// the issue already nudged is reported with the error
+	if got == nil || len(got.Nudges) != 2 {
+		t.Fatalf("userSupport.NudgeStaleIssues() = %+v, want first and second issues", got)
+	}
+	if got.Nudges[0].Title != "first" || got.Nudges[0].Status != NudgeStatusNudged {
+		t.Errorf("nudge of first issue = %+v, want nudged", got.Nudges[0])
+	}
+	if got.Nudges[1].Title != "second" || got.Nudges[1].Status != NudgeStatusFailed {
+		t.Errorf("nudge of second issue = %+v, want failed", got.Nudges[1])
+	}
+}
+
+func TestNudgeConfig_Validate(t *testing.T) {
+	if err := DefaultNudgeConfig().Validate(); err != nil {
+		t.Errorf("DefaultNudgeConfig().Validate() error = %v", err)
+	}
+	if err := (&NudgeConfig{Template: "{{.Mentions"}).Validate(); err == nil {
+		t.Error("Validate() of broken template should fail")
+	}
+}
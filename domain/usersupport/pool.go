@@ -0,0 +1,49 @@
+package usersupport
+
+import (
+	"context"
+	"sync"
+)
+
+// ForEach calls fn with 0 to n-1 in the pool of workers, less than 1 worker calls it serially
+// it cancels the rest on the first error and returns it
+func ForEach(ctx context.Context, n, workers int, fn func(ctx context.Context, i int) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+		canceled error
+	)
+	sem := make(chan struct{}, workers)
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if canceled = ctx.Err(); canceled != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(ctx, i); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	// the rest are left when ctx is canceled before they start
+	return canceled
+}
@@ -0,0 +1,231 @@
+package usersupport
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Format is output format of reports
+type Format string
+
+// Formats which reports can be rendered in
+const (
+	FormatMarkdown Format = "markdown"
+	FormatCSV      Format = "csv"
+	FormatJSON     Format = "json"
+	FormatYAML     Format = "yaml"
+)
+
+// ParseFormat parses name of format
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatMarkdown, FormatCSV, FormatJSON, FormatYAML:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format: %s (choose on json, yaml, markdown, csv)", s)
+}
+
+// Report is stats which can be rendered in every format
+type Report interface {
+	GenMarkdown() string
+	GenCSV() string
+}
+
+// Render renders the report in the format
+// json and yaml serialize the stats itself, whose map keys are sorted
+func Render(r Report, format Format) (string, error) {
+	switch format {
+	case FormatMarkdown:
+		return r.GenMarkdown(), nil
+	case FormatCSV:
+		return r.GenCSV(), nil
+	case FormatJSON:
+		b, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("marshal json: %s", err)
+		}
+		return string(b) + "\n", nil
+	case FormatYAML:
+		b, err := yaml.Marshal(r)
+		if err != nil {
+			return "", fmt.Errorf("marshal yaml: %s", err)
+		}
+		return string(b), nil
+	}
+	return "", fmt.Errorf("unknown format: %s", format)
+}
+
+// DetailStatsMap is detail stats keyed by sequence number
+// it is serialized as a list in order of the key
+type DetailStatsMap map[int]*DetailStats
+
+// List returns detail stats in order of the key
+func (m DetailStatsMap) List() []*DetailStats {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	list := make([]*DetailStats, 0, len(m))
+	for _, k := range keys {
+		list = append(list, m[k])
+	}
+	return list
+}
+
+// MarshalJSON serializes detail stats as a list
+func (m DetailStatsMap) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.List())
+}
+
+// MarshalYAML serializes detail stats as a list
+func (m DetailStatsMap) MarshalYAML() (interface{}, error) {
+	return m.List(), nil
+}
+
+// GenMarkdown generates daily report
+func (ds *DailyStats) GenMarkdown() string {
+	return ds.GetDailyReportStats()
+}
+
+// GenCSV generates detail of not updated issues
+func (ds *DailyStats) GenCSV() string {
+	return (&AnalysisStats{DetailStats: ds.DetailStats}).GenAnalysisReport()
+}
+
+// GenMarkdown generates longterm report
+func (lts *LongTermStats) GenMarkdown() string {
+	return lts.GenLongTermReport()
+}
+
+// GenCSV generates detail of closed issues
+func (lts *LongTermStats) GenCSV() string {
+	return (&AnalysisStats{DetailStats: lts.DetailStats}).GenAnalysisReport()
+}
+
+// GenSummary generates list of counts and score per span, newest first
+func (lts *LongTermStats) GenSummary() string {
+	summaries := make([]*SummaryStats, 0, len(lts.SummaryStats))
+	for _, ss := range lts.SummaryStats {
+		summaries = append(summaries, ss)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Span > summaries[j].Span
+	})
+	var sb strings.Builder
+	for _, ss := range summaries {
+		sb.WriteString(fmt.Sprintf("- %s: 起票 %d 件, クローズ %d 件, 合計スコア %.2f\n", ss.Span, ss.NumCreatedIssues, ss.NumClosedIssues, ss.NumTotalScore))
+	}
+	return sb.String()
+}
+
+// GenMarkdown generates table of issues
+func (as *AnalysisStats) GenMarkdown() string {
+	var sb strings.Builder
+	sb.WriteString("|期間|リポジトリ|Title|起票日|クローズ日|ステータス|担当チーム|担当アサイン|緊急度|問い合わせ種別|エスカレ有無|コメント数|経過時間|Keywordラベル|\n")
+	sb.WriteString("|----|----|----|----|----|----|----|----|----|----|----|----|----|----|\n")
+	details := as.DetailStats.List()
+	sort.SliceStable(details, func(i, j int) bool {
+		return details[i].CreatedAt < details[j].CreatedAt
+	})
+	for _, d := range details {
+		sb.WriteString(fmt.Sprintf("|%s|%s|[%s](%s)|%s|%s|%s|%s|%s|%s|%s|%t|%d|%d|%s|\n", d.TargetSpan, d.Repository, escapeMarkdownCell(d.Title), d.HTMLURL, d.CreatedAt, d.ClosedAt, d.State, d.TeamName, d.Assignee, d.Urgency, d.Genre, d.Escalation, d.NumComments, d.OpenDuration, d.Labels))
+	}
+	return sb.String()
+}
+
+// GenCSV generates analysis report
+func (as *AnalysisStats) GenCSV() string {
+	return as.GenAnalysisReport()
+}
+
+// GenMarkdown generates keyword report
+func (ks *KeywordStats) GenMarkdown() string {
+	return ks.GenKeywordReport()
+}
+
+// GenSummary generates the most used keywords per span, newest first
+func (ks *KeywordStats) GenSummary() string {
+	spans := make([]string, 0, len(ks.KeywordSummary))
+	for span := range ks.KeywordSummary {
+		spans = append(spans, span)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(spans)))
+	var sb strings.Builder
+	for _, span := range spans {
+		counts := ks.KeywordSummary[span].KeywordCountAsAll
+		keywords := make([]string, 0, len(counts))
+		for k := range counts {
+			keywords = append(keywords, k)
+		}
+		sort.Slice(keywords, func(i, j int) bool {
+			if counts[keywords[i]] != counts[keywords[j]] {
+				return counts[keywords[i]] > counts[keywords[j]]
+			}
+			return keywords[i] < keywords[j]
+		})
+		if len(keywords) > 3 {
+			keywords = keywords[:3]
+		}
+		top := make([]string, 0, len(keywords))
+		for _, k := range keywords {
+			top = append(top, fmt.Sprintf("%s (%d)", k, counts[k]))
+		}
+		sb.WriteString(fmt.Sprintf("- %s: %d 種類 %s\n", ks.KeywordSummary[span].Span, len(counts), strings.Join(top, ", ")))
+	}
+	return sb.String()
+}
+
+// GenCSV generates count of keyword labels per span
+func (ks *KeywordStats) GenCSV() string {
+	records := [][]string{
+		{"期間", "Keywordラベル", "全体件数", "Escalation件数"},
+	}
+	spans := make([]string, 0, len(ks.KeywordSummary))
+	for span := range ks.KeywordSummary {
+		spans = append(spans, span)
+	}
+	sort.Strings(spans)
+	for _, span := range spans {
+		summary := ks.KeywordSummary[span]
+		keywords := make([]string, 0, len(summary.KeywordCountAsAll))
+		for k := range summary.KeywordCountAsAll {
+			keywords = append(keywords, k)
+		}
+		sort.Strings(keywords)
+		for _, k := range keywords {
+			records = append(records, []string{summary.Span, k, strconv.Itoa(summary.KeywordCountAsAll[k]), strconv.Itoa(summary.KeywordCountAsEscalation[k])})
+		}
+	}
+	return genCSV(records)
+}
+
+// genCSV generates RFC 4180 CSV, which quotes fields containing comma, quote or newline and ends lines with CRLF
+func genCSV(records [][]string) string {
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	w.UseCRLF = true
+	// writing to strings.Builder never fails
+	_ = w.WriteAll(records)
+	return sb.String()
+}
+
+// escapeMarkdownCell escapes pipe which breaks markdown table
+func escapeMarkdownCell(s string) string {
+	return strings.Replace(s, "|", "\\|", -1)
+}
+
+// mrkdwnEscaper escapes control characters of slack mrkdwn, which daily report is written in
+// https://api.slack.com/reference/surfaces/formatting#escaping
+var mrkdwnEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// EscapeMrkdwn escapes text to be put in slack mrkdwn, so it never breaks links nor mentions everyone by <!channel>
+func EscapeMrkdwn(s string) string {
+	return mrkdwnEscaper.Replace(s)
+}
@@ -0,0 +1,158 @@
+package usersupport
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	ks := &KeywordStats{
+		KeywordSummary: map[string]*KeywordSummary{
+			"2020-01-01~2020-01-31": {
+				Span:                     "2020-01-01~2020-01-31",
+				KeywordCountAsAll:        map[string]int{"keyword:Network": 2, "keyword:DNS": 1},
+				KeywordCountAsEscalation: map[string]int{"keyword:Network": 1, "keyword:DNS": 0},
+			},
+		},
+	}
+	as := &AnalysisStats{
+		DetailStats: map[int]*DetailStats{
+			1: {Title: "issue 2", State: "open", OpenDuration: 3},
+			0: {Title: "issue 1", State: "closed", Escalation: true},
+		},
+	}
+	tests := []struct {
+		name   string
+		report Report
+		format Format
+		want   string
+	}{
+		{
+			name:   "keyword stats in json",
+			report: ks,
+			format: FormatJSON,
+			want: `{
+  "keyword_summary": {
+    "2020-01-01~2020-01-31": {
+      "span": "2020-01-01~2020-01-31",
+      "keyword_count_as_all": {
+        "keyword:DNS": 1,
+        "keyword:Network": 2
+      },
+      "keyword_count_as_escalation": {
+        "keyword:DNS": 0,
+        "keyword:Network": 1
+      }
+    }
+  }
+}
+`,
+		},
+		{
+			name:   "keyword stats in csv",
+			report: ks,
+			format: FormatCSV,
+			want: `期間,Keywordラベル,全体件数,Escalation件数
+2020-01-01~2020-01-31,keyword:DNS,1,0
+2020-01-01~2020-01-31,keyword:Network,2,1
+`,
+		},
+		{
+			name:   "detail stats are serialized as a list in yaml",
+			report: as,
+			format: FormatYAML,
+			want: `detail_stats:
+- repository: ""
+  title: issue 1
+  service_id: ""
+  html_url: ""
+  created_at: ""
+  closed_at: ""
+  state: closed
+  target_span: ""
+  team_name: ""
+  urgency: ""
+  genre: ""
+  labels: ""
+  assignee: ""
+  num_comments: 0
+  open_duration: 0
+  escalation: true
+  first_response: 0
+  responded: false
+- repository: ""
+  title: issue 2
+  service_id: ""
+  html_url: ""
+  created_at: ""
+  closed_at: ""
+  state: open
+  target_span: ""
+  team_name: ""
+  urgency: ""
+  genre: ""
+  labels: ""
+  assignee: ""
+  num_comments: 0
+  open_duration: 3
+  escalation: false
+  first_response: 0
+  responded: false
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.report, tt.format)
+			if err != nil {
+				t.Errorf("Render() error = %v", err)
+				return
+			}
+			if tt.format == FormatCSV {
+				tt.want = strings.Replace(tt.want, "\n", "\r\n", -1)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenSummary(t *testing.T) {
+	lts := &LongTermStats{
+		SummaryStats: map[string]*SummaryStats{
+			"2020-10-01~2020-10-31": {Span: "2020-10-01~2020-10-31", NumCreatedIssues: 1, NumClosedIssues: 2, NumTotalScore: 1.5},
+			"2020-11-01~2020-11-30": {Span: "2020-11-01~2020-11-30", NumCreatedIssues: 3},
+		},
+	}
+	want := "- 2020-11-01~2020-11-30: 起票 3 件, クローズ 0 件, 合計スコア 0.00\n- 2020-10-01~2020-10-31: 起票 1 件, クローズ 2 件, 合計スコア 1.50\n"
+	if got := lts.GenSummary(); got != want {
+		t.Errorf("LongTermStats.GenSummary() = %v, want %v", got, want)
+	}
+
+	ks := &KeywordStats{
+		KeywordSummary: map[string]*KeywordSummary{
+			"2020-11-01~2020-11-30": {
+				Span:              "2020-11-01~2020-11-30",
+				KeywordCountAsAll: map[string]int{"Network": 1, "Kubernetes": 3, "Openstack": 1, "DNS": 2},
+			},
+		},
+	}
+	want = "- 2020-11-01~2020-11-30: 4 種類 Kubernetes (3), DNS (2), Network (1)\n"
+	if got := ks.GenSummary(); got != want {
+		t.Errorf("KeywordStats.GenSummary() = %v, want %v", got, want)
+	}
+}
+
+func TestDailyStats_GenMarkdownEscapesTitle(t *testing.T) {
+	ds := &DailyStats{
+		DayAgo: 5,
+		DetailStats: DetailStatsMap{
+			0: {Title: "<!channel> A & B > C", HTMLURL: "https://github.com/sataga/issue-warehouse/issues/1"},
+		},
+	}
+	want := "- <https://github.com/sataga/issue-warehouse/issues/1|&lt;!channel&gt; A &amp; B &gt; C> "
+	if got := ds.GenMarkdown(); !strings.Contains(got, want) {
+		t.Errorf("DailyStats.GenMarkdown() = %s, want %s", got, want)
+	}
+}
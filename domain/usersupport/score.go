@@ -0,0 +1,127 @@
+package usersupport
+
+import (
+	"fmt"
+)
+
+// Scoring is buckets of resolution time to score closed issues
+type Scoring struct {
+	// Buckets are used for issues which no rule matches
+	Buckets []ScoreBucket `yaml:"buckets"`
+	// Rules override buckets for issues of the genre or urgency, the first matching rule is used
+	Rules []ScoreRule `yaml:"rules"`
+}
+
+// ScoreBucket is a range of resolution time
+// buckets are ordered by MaxDays and the last one has no upper bound
+type ScoreBucket struct {
+	Label string `yaml:"label"`
+	// MaxDays is the inclusive upper bound of days to resolve, 0 means no bound
+	MaxDays float64 `yaml:"max_days"`
+	// Weight is used for weighted mean of scores (NumTotalScore)
+	Weight float64 `yaml:"weight"`
+}
+
+// ScoreRule is buckets for issues of the genre and urgency classes, empty class matches every issue
+type ScoreRule struct {
+	Genre   string        `yaml:"genre"`
+	Urgency string        `yaml:"urgency"`
+	Buckets []ScoreBucket `yaml:"buckets"`
+}
+
+// DefaultScoring returns A-F buckets (A: <= 2days, B: <= 5days, C: <= 10days, D: <= 20days, E: <= 30days, F: others)
+func DefaultScoring() *Scoring {
+	return &Scoring{
+		Buckets: []ScoreBucket{
+			{Label: "A", MaxDays: 2, Weight: 1},
+			{Label: "B", MaxDays: 5, Weight: 2},
+			{Label: "C", MaxDays: 10, Weight: 3},
+			{Label: "D", MaxDays: 20, Weight: 4},
+			{Label: "E", MaxDays: 30, Weight: 5},
+			{Label: "F", Weight: 6},
+		},
+	}
+}
+
+// Validate checks that buckets are ordered and end with unbounded one
+func (s *Scoring) Validate() error {
+	if err := validateBuckets(s.Buckets); err != nil {
+		return fmt.Errorf("scoring buckets: %s", err)
+	}
+	for i, r := range s.Rules {
+		if r.Genre == "" && r.Urgency == "" {
+			return fmt.Errorf("scoring rule %d: need to set genre or urgency", i)
+		}
+		if err := validateBuckets(r.Buckets); err != nil {
+			return fmt.Errorf("scoring rule %d: %s", i, err)
+		}
+	}
+	return nil
+}
+
+func validateBuckets(buckets []ScoreBucket) error {
+	if len(buckets) == 0 {
+		return fmt.Errorf("need at least one bucket")
+	}
+	seen := make(map[string]bool, len(buckets))
+	for i, b := range buckets {
+		if b.Label == "" {
+			return fmt.Errorf("bucket %d: need to set label", i)
+		}
+		if seen[b.Label] {
+			return fmt.Errorf("bucket %d: duplicated label %s", i, b.Label)
+		}
+		seen[b.Label] = true
+		last := i == len(buckets)-1
+		if last && b.MaxDays != 0 {
+			return fmt.Errorf("bucket %s: the last bucket must not set max_days", b.Label)
+		}
+		if !last && b.MaxDays <= 0 {
+			return fmt.Errorf("bucket %s: need to set positive max_days", b.Label)
+		}
+		if i > 0 && !last && b.MaxDays <= buckets[i-1].MaxDays {
+			return fmt.Errorf("bucket %s: max_days must be larger than the previous bucket", b.Label)
+		}
+	}
+	return nil
+}
+
+// bucketsFor returns buckets of the first rule which matches classes of the issue
+func (s *Scoring) bucketsFor(lc *LabelClass) []ScoreBucket {
+	for _, r := range s.Rules {
+		if (r.Genre == "" || r.Genre == lc.GenreClass) && (r.Urgency == "" || r.Urgency == lc.UrgencyClass) {
+			return r.Buckets
+		}
+	}
+	return s.Buckets
+}
+
+// Score returns the bucket which resolution hours fall in, dayHours is hours of a day
+func (s *Scoring) Score(lc *LabelClass, hours, dayHours int) ScoreBucket {
+	buckets := s.bucketsFor(lc)
+	for _, b := range buckets {
+		if b.MaxDays != 0 && float64(hours) <= b.MaxDays*float64(dayHours) {
+			return b
+		}
+	}
+	return buckets[len(buckets)-1]
+}
+
+// Labels returns labels of every bucket, the default buckets come first
+func (s *Scoring) Labels() []string {
+	var labels []string
+	seen := make(map[string]bool)
+	add := func(buckets []ScoreBucket) {
+		for _, b := range buckets {
+			if !seen[b.Label] {
+				seen[b.Label] = true
+				labels = append(labels, b.Label)
+			}
+		}
+	}
+	add(s.Buckets)
+	for _, r := range s.Rules {
+		add(r.Buckets)
+	}
+	return labels
+}
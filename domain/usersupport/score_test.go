@@ -0,0 +1,67 @@
+package usersupport
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScoring_Score(t *testing.T) {
+	scoring := DefaultScoring()
+	scoring.Rules = []ScoreRule{
+		{
+			Genre: ClassGenreFailure,
+			Buckets: []ScoreBucket{
+				{Label: "A", MaxDays: 0.5, Weight: 1},
+				{Label: "B", MaxDays: 1, Weight: 2},
+				{Label: "X", Weight: 10},
+			},
+		},
+	}
+	if err := scoring.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		lc    *LabelClass
+		hours int
+		want  string
+	}{
+		{name: "boundary of A", lc: &LabelClass{GenreClass: ClassGenreNormal}, hours: 48, want: "A"},
+		{name: "over A", lc: &LabelClass{GenreClass: ClassGenreNormal}, hours: 49, want: "B"},
+		{name: "unbounded", lc: &LabelClass{GenreClass: ClassGenreRequest}, hours: 24 * 100, want: "F"},
+		{name: "failure within half a day", lc: &LabelClass{GenreClass: ClassGenreFailure}, hours: 12, want: "A"},
+		{name: "failure over a day", lc: &LabelClass{GenreClass: ClassGenreFailure}, hours: 25, want: "X"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scoring.Score(tt.lc, tt.hours, 24); got.Label != tt.want {
+				t.Errorf("Scoring.Score() = %s, want %s", got.Label, tt.want)
+			}
+		})
+	}
+	if got, want := scoring.Labels(), []string{"A", "B", "C", "D", "E", "F", "X"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Scoring.Labels() = %v, want %v", got, want)
+	}
+}
+
+func TestScoring_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		scoring *Scoring
+		wantErr bool
+	}{
+		{name: "default", scoring: DefaultScoring()},
+		{name: "no bucket", scoring: &Scoring{}, wantErr: true},
+		{name: "bounded last bucket", scoring: &Scoring{Buckets: []ScoreBucket{{Label: "A", MaxDays: 2}}}, wantErr: true},
+		{name: "unordered", scoring: &Scoring{Buckets: []ScoreBucket{{Label: "A", MaxDays: 5}, {Label: "B", MaxDays: 2}, {Label: "C"}}}, wantErr: true},
+		{name: "duplicated label", scoring: &Scoring{Buckets: []ScoreBucket{{Label: "A", MaxDays: 2}, {Label: "A"}}}, wantErr: true},
+		{name: "rule without class", scoring: &Scoring{Buckets: []ScoreBucket{{Label: "A"}}, Rules: []ScoreRule{{Buckets: []ScoreBucket{{Label: "A"}}}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.scoring.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Scoring.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
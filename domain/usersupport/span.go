@@ -0,0 +1,146 @@
+package usersupport
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sataga/go-github-sample/domain/period"
+)
+
+// SpanFetcher fetches reports of spans concurrently and merges them in order of spans
+// requests share rate limit and search interval of the repository's client, so workers only bound how many wait at once
+type SpanFetcher struct {
+	us      UserSupport
+	workers int
+}
+
+// NewSpanFetcher creates SpanFetcher which fetches at most workers spans at once, less than 1 fetches them serially
+func NewSpanFetcher(us UserSupport, workers int) *SpanFetcher {
+	if workers < 1 {
+		workers = 1
+	}
+	return &SpanFetcher{us: us, workers: workers}
+}
+
+// each calls fetch for every span in the worker pool
+// it cancels the rest on the first error and returns it
+func (f *SpanFetcher) each(ctx context.Context, spans []period.Span, fetch func(ctx context.Context, i int, span period.Span) error) error {
+	return ForEach(ctx, len(spans), f.workers, func(ctx context.Context, i int) error {
+		return fetch(ctx, i, spans[i])
+	})
+}
+
+// LongTermStats fetches longterm stats of spans
+// detail stats are numbered in order of spans, then in order of each span
+func (f *SpanFetcher) LongTermStats(ctx context.Context, spans []period.Span) (*LongTermStats, error) {
+	results := make([]*LongTermStats, len(spans))
+	err := f.each(ctx, spans, func(ctx context.Context, i int, span period.Span) error {
+		result, err := f.us.GetLongTermReportStats(ctx, span.Since, span.End())
+		if err != nil {
+			return fmt.Errorf("get longterm stats of %s: %w", span, err)
+		}
+		results[i] = result
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	merged := &LongTermStats{
+		SummaryStats: make(map[string]*SummaryStats, len(spans)),
+		DetailStats:  make(map[int]*DetailStats),
+	}
+	for _, result := range results {
+		merged.ScoreLabels = result.ScoreLabels
+		merged.GenreClasses = result.GenreClasses
+		merged.UrgencyClasses = result.UrgencyClasses
+		merged.TeamClasses = result.TeamClasses
+		for key, val := range result.SummaryStats {
+			merged.SummaryStats[key] = val
+		}
+		for _, val := range result.DetailStats.List() {
+			merged.DetailStats[len(merged.DetailStats)] = val
+		}
+	}
+	return merged, nil
+}
+
+// AnalysisStats fetches analysis stats of spans
+// detail stats are numbered in order of spans, then in order of each span
+func (f *SpanFetcher) AnalysisStats(ctx context.Context, spans []period.Span, state string) (*AnalysisStats, error) {
+	results := make([]*AnalysisStats, len(spans))
+	err := f.each(ctx, spans, func(ctx context.Context, i int, span period.Span) error {
+		result, err := f.us.GetAnalysisReportStats(ctx, span.Since, span.End(), state)
+		if err != nil {
+			return fmt.Errorf("get analysis stats of %s: %w", span, err)
+		}
+		results[i] = result
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	merged := &AnalysisStats{
+		DetailStats: make(map[int]*DetailStats),
+	}
+	for _, result := range results {
+		for _, val := range result.DetailStats.List() {
+			merged.DetailStats[len(merged.DetailStats)] = val
+		}
+	}
+	return merged, nil
+}
+
+// KeywordStats fetches keyword stats of spans
+func (f *SpanFetcher) KeywordStats(ctx context.Context, spans []period.Span) (*KeywordStats, error) {
+	results := make([]*KeywordStats, len(spans))
+	err := f.each(ctx, spans, func(ctx context.Context, i int, span period.Span) error {
+		result, err := f.us.GetKeywordReportStats(ctx, span.Since, span.End())
+		if err != nil {
+			return fmt.Errorf("get keyword stats of %s: %w", span, err)
+		}
+		results[i] = result
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	merged := &KeywordStats{
+		KeywordSummary: make(map[string]*KeywordSummary, len(spans)),
+	}
+	for _, result := range results {
+		for key, val := range result.KeywordSummary {
+			merged.KeywordSummary[key] = val
+		}
+	}
+	return merged, nil
+}
+
+// TimelineStats fetches timeline stats of spans
+// timelines are in order of spans
+func (f *SpanFetcher) TimelineStats(ctx context.Context, spans []period.Span) (*TimelineStats, error) {
+	results := make([]*TimelineStats, len(spans))
+	err := f.each(ctx, spans, func(ctx context.Context, i int, span period.Span) error {
+		result, err := f.us.GetTimelineReportStats(ctx, span.Since, span.End())
+		if err != nil {
+			return fmt.Errorf("get timeline stats of %s: %w", span, err)
+		}
+		results[i] = result
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	merged := &TimelineStats{
+		Summary:   make(map[string]*TimelineSummary, len(spans)),
+		Timelines: make([]*Timeline, 0),
+	}
+	for _, result := range results {
+		merged.UrgencyClasses = result.UrgencyClasses
+		merged.TeamClasses = result.TeamClasses
+		for key, val := range result.Summary {
+			merged.Summary[key] = val
+		}
+		merged.Timelines = append(merged.Timelines, result.Timelines...)
+	}
+	return merged, nil
+}
@@ -0,0 +1,95 @@
+package usersupport
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sataga/go-github-sample/domain/period"
+)
+
+func newPlanner(t *testing.T) *period.Planner {
+	p, err := period.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestSpanFetcher_AnalysisStats(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	spans, err := newPlanner(t).Spans(period.Monthly, time.Date(2020, 10, 15, 0, 0, 0, 0, jp), 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	mus := NewMockUserSupport(c)
+	mus.EXPECT().GetAnalysisReportStats(gomock.Any(), gomock.Any(), gomock.Any(), "closed").Times(len(spans)).DoAndReturn(
+		func(ctx context.Context, since, until time.Time, state string) (*AnalysisStats, error) {
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+			// older spans finish later, so merged order must not depend on finish order
+			time.Sleep(time.Duration(12-since.Month()) * 5 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			span := period.Span{Since: since, Until: until}.String()
+			return &AnalysisStats{DetailStats: DetailStatsMap{
+				0: {TargetSpan: span, Title: "first"},
+				1: {TargetSpan: span, Title: "second"},
+			}}, nil
+		})
+
+	got, err := NewSpanFetcher(mus, 2).AnalysisStats(context.Background(), spans, "closed")
+	if err != nil {
+		t.Fatalf("SpanFetcher.AnalysisStats() error = %v", err)
+	}
+	if maxRunning > 2 {
+		t.Errorf("SpanFetcher.AnalysisStats() fetched %d spans at once, want at most 2", maxRunning)
+	}
+	details := got.DetailStats.List()
+	if len(details) != 2*len(spans) {
+		t.Fatalf("SpanFetcher.AnalysisStats() = %d details, want %d", len(details), 2*len(spans))
+	}
+	for i, d := range details {
+		if want := spans[i/2].String(); d.TargetSpan != want {
+			t.Errorf("detail %d is of %s, want %s", i, d.TargetSpan, want)
+		}
+		if want := []string{"first", "second"}[i%2]; d.Title != want {
+			t.Errorf("detail %d = %s, want %s", i, d.Title, want)
+		}
+	}
+}
+
+func TestSpanFetcher_Error(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	spans, err := newPlanner(t).Spans(period.Weekly, time.Date(2020, 10, 15, 0, 0, 0, 0, jp), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := errors.New("bad credentials")
+	mus := NewMockUserSupport(c)
+	// the failure cancels spans in flight and ones which have not started
+	mus.EXPECT().GetKeywordReportStats(gomock.Any(), spans[0].Since, spans[0].End()).Return(nil, failed)
+	mus.EXPECT().GetKeywordReportStats(gomock.Any(), gomock.Any(), gomock.Any()).MaxTimes(1).DoAndReturn(
+		func(ctx context.Context, since, until time.Time) (*KeywordStats, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+	_, err = NewSpanFetcher(mus, 2).KeywordStats(context.Background(), spans)
+	if !errors.Is(err, failed) {
+		t.Errorf("SpanFetcher.KeywordStats() error = %v, want %v", err, failed)
+	}
+}
@@ -0,0 +1,240 @@
+package usersupport
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Dimension is a kind of classification given by labels
+type Dimension string
+
+// Dimensions which reports aggregate by
+const (
+	DimensionUrgency    Dimension = "urgency"
+	DimensionTeam       Dimension = "team"
+	DimensionGenre      Dimension = "genre"
+	DimensionEscalation Dimension = "escalation"
+	DimensionKeyword    Dimension = "keyword"
+)
+
+// Classes of the default taxonomy, summary stats count by any class of the configured one
+const (
+	ClassUrgencyHigh   = "high"
+	ClassUrgencyMedium = "medium"
+	ClassUrgencyLow    = "low"
+	ClassTeamA         = "team_a"
+	ClassTeamB         = "team_b"
+	ClassGenreNormal   = "normal"
+	ClassGenreRequest  = "request"
+	ClassGenreFailure  = "failure"
+)
+
+// LabelRule maps a label name or a label prefix to a dimension
+type LabelRule struct {
+	Dimension Dimension `yaml:"dimension"`
+	// Label matches the label name exactly
+	Label string `yaml:"label,omitempty"`
+	// Prefix matches the beginning of the label name, used when Label is empty
+	Prefix string `yaml:"prefix,omitempty"`
+	// Display is a name shown in reports. the label name without Prefix is used when empty
+	Display string `yaml:"display,omitempty"`
+	// Class is a group which summary stats count by (e.g. high, team_a, failure)
+	Class string `yaml:"class,omitempty"`
+}
+
+// Taxonomy is a declarative definition of support labels
+type Taxonomy struct {
+	Rules []LabelRule `yaml:"rules"`
+}
+
+// ClassName is a class of a dimension and its display name
+type ClassName struct {
+	Class   string `json:"class" yaml:"class"`
+	Display string `json:"display" yaml:"display"`
+}
+
+// LabelClass is a classification of labels attached to an issue
+type LabelClass struct {
+	Urgency      string
+	UrgencyClass string
+	Team         string
+	TeamClass    string
+	Genre        string
+	GenreClass   string
+	Escalation   bool
+	Keywords     []string
+}
+
+// DefaultTaxonomy returns taxonomy for labels of sataga/issue-warehouse
+func DefaultTaxonomy() *Taxonomy {
+	return &Taxonomy{
+		Rules: []LabelRule{
+			{Dimension: DimensionUrgency, Label: "緊急度：高", Display: "高", Class: ClassUrgencyHigh},
+			{Dimension: DimensionUrgency, Label: "緊急度：中", Display: "中", Class: ClassUrgencyMedium},
+			{Dimension: DimensionUrgency, Label: "緊急度：低", Display: "低", Class: ClassUrgencyLow},
+			{Dimension: DimensionUrgency, Prefix: "緊急度："},
+			{Dimension: DimensionTeam, Label: "CaaS-A 対応中", Display: "CaaS-A", Class: ClassTeamA},
+			{Dimension: DimensionTeam, Label: "CaaS-B 対応中", Display: "CaaS-B", Class: ClassTeamB},
+			{Dimension: DimensionGenre, Label: "genre:通常問合せ", Display: "通常問合せ", Class: ClassGenreNormal},
+			{Dimension: DimensionGenre, Label: "genre:要望", Display: "要望", Class: ClassGenreRequest},
+			{Dimension: DimensionGenre, Label: "genre:サービス障害", Display: "サービス障害", Class: ClassGenreFailure},
+			{Dimension: DimensionGenre, Prefix: "genre:"},
+			{Dimension: DimensionEscalation, Label: "Escalation"},
+			{Dimension: DimensionKeyword, Prefix: "keyword:"},
+		},
+	}
+}
+
+// Validate checks every rule has a dimension and a matcher
+func (t *Taxonomy) Validate() error {
+	for i, r := range t.Rules {
+		switch r.Dimension {
+		case DimensionUrgency, DimensionTeam, DimensionGenre, DimensionEscalation, DimensionKeyword:
+		default:
+			return fmt.Errorf("taxonomy rule %d: unknown dimension %q", i, r.Dimension)
+		}
+		if r.Label == "" && r.Prefix == "" {
+			return fmt.Errorf("taxonomy rule %d: need to set label or prefix", i)
+		}
+	}
+	return nil
+}
+
+// Match returns the first rule matching the label and its display name
+func (t *Taxonomy) Match(label string) (*LabelRule, string, bool) {
+	for i := range t.Rules {
+		r := &t.Rules[i]
+		switch {
+		case r.Label != "" && r.Label == label:
+		case r.Label == "" && r.Prefix != "" && strings.HasPrefix(label, r.Prefix):
+		default:
+			continue
+		}
+		if r.Display != "" {
+			return r, r.Display, true
+		}
+		return r, strings.TrimPrefix(label, r.Prefix), true
+	}
+	return nil, "", false
+}
+
+// Classify classifies label names. the last matched label wins on each dimension
+func (t *Taxonomy) Classify(labels []string) *LabelClass {
+	lc := &LabelClass{}
+	for _, label := range labels {
+		r, display, ok := t.Match(label)
+		if !ok {
+			continue
+		}
+		switch r.Dimension {
+		case DimensionUrgency:
+			lc.Urgency, lc.UrgencyClass = display, r.Class
+		case DimensionTeam:
+			lc.Team, lc.TeamClass = display, r.Class
+		case DimensionGenre:
+			lc.Genre, lc.GenreClass = display, r.Class
+		case DimensionEscalation:
+			lc.Escalation = true
+		case DimensionKeyword:
+			lc.Keywords = append(lc.Keywords, display)
+		}
+	}
+	return lc
+}
+
+// ClassifyIssue classifies labels attached to the issue
+func (t *Taxonomy) ClassifyIssue(issue *Issue) *LabelClass {
+	return t.Classify(issue.LabelNames())
+}
+
+// IsKeyword returns whether the label is a keyword label
+func (t *Taxonomy) IsKeyword(label string) bool {
+	r, _, ok := t.Match(label)
+	return ok && r.Dimension == DimensionKeyword
+}
+
+// Classes returns classes of the dimension in order of rules, named by Display of the first rule of each class
+func (t *Taxonomy) Classes(dim Dimension) []*ClassName {
+	var classes []*ClassName
+	seen := make(map[string]bool)
+	for _, r := range t.Rules {
+		if r.Dimension != dim || r.Class == "" || seen[r.Class] {
+			continue
+		}
+		seen[r.Class] = true
+		display := r.Display
+		if display == "" {
+			display = r.Class
+		}
+		classes = append(classes, &ClassName{Class: r.Class, Display: display})
+	}
+	return classes
+}
+
+// sortedClasses returns classes with display names, classes of the taxonomy come first in its order
+// and unknown classes follow in alphabetical order named by themselves
+func sortedClasses(classes []string, known []*ClassName) []*ClassName {
+	has := make(map[string]bool, len(classes))
+	for _, c := range classes {
+		has[c] = true
+	}
+	var sorted []*ClassName
+	seen := make(map[string]bool, len(known))
+	for _, c := range known {
+		seen[c.Class] = true
+		if has[c.Class] {
+			sorted = append(sorted, c)
+		}
+	}
+	var others []string
+	for c := range has {
+		if !seen[c] {
+			others = append(others, c)
+		}
+	}
+	sort.Strings(others)
+	for _, c := range others {
+		sorted = append(sorted, &ClassName{Class: c, Display: c})
+	}
+	return sorted
+}
+
+// zeroCounts returns counts keyed by the classes, so reports list classes without issues as well
+func zeroCounts(classes []*ClassName) map[string]int {
+	counts := make(map[string]int, len(classes))
+	for _, c := range classes {
+		counts[c.Class] = 0
+	}
+	return counts
+}
+
+// countedClasses returns the known classes and other classes which the counts have, see sortedClasses for the order
+func countedClasses(known []*ClassName, counts ...map[string]int) []*ClassName {
+	var classes []string
+	for _, c := range known {
+		classes = append(classes, c.Class)
+	}
+	for _, m := range counts {
+		for c := range m {
+			classes = append(classes, c)
+		}
+	}
+	return sortedClasses(classes, known)
+}
+
+// KeywordQueries returns queries to search keyword labels
+func (t *Taxonomy) KeywordQueries() []string {
+	var queries []string
+	for _, r := range t.Rules {
+		if r.Dimension != DimensionKeyword {
+			continue
+		}
+		if r.Label != "" {
+			queries = append(queries, r.Label)
+		} else {
+			queries = append(queries, r.Prefix)
+		}
+	}
+	return queries
+}
@@ -0,0 +1,125 @@
+package usersupport
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTaxonomy_Classify(t *testing.T) {
+	english := &Taxonomy{
+		Rules: []LabelRule{
+			{Dimension: DimensionUrgency, Label: "priority:high", Display: "High", Class: ClassUrgencyHigh},
+			{Dimension: DimensionTeam, Label: "team:first-line", Display: "FirstLine", Class: ClassTeamA},
+			{Dimension: DimensionGenre, Label: "type:incident", Display: "Incident", Class: ClassGenreFailure},
+			{Dimension: DimensionEscalation, Label: "escalated"},
+			{Dimension: DimensionKeyword, Prefix: "topic:"},
+		},
+	}
+	tests := []struct {
+		name     string
+		taxonomy *Taxonomy
+		labels   []string
+		want     *LabelClass
+	}{
+		{
+			name:     "default taxonomy",
+			taxonomy: DefaultTaxonomy(),
+			labels:   []string{"PF_Support", "緊急度：中", "CaaS-B 対応中", "genre:要望", "Escalation", "keyword:Network", "keyword:DNS"},
+			want: &LabelClass{
+				Urgency:      "中",
+				UrgencyClass: ClassUrgencyMedium,
+				Team:         "CaaS-B",
+				TeamClass:    ClassTeamB,
+				Genre:        "要望",
+				GenreClass:   ClassGenreRequest,
+				Escalation:   true,
+				Keywords:     []string{"Network", "DNS"},
+			},
+		},
+		{
+			name:     "unknown genre falls back to prefix rule",
+			taxonomy: DefaultTaxonomy(),
+			labels:   []string{"genre:その他"},
+			want: &LabelClass{
+				Genre: "その他",
+			},
+		},
+		{
+			name:     "english labels",
+			taxonomy: english,
+			labels:   []string{"priority:high", "team:first-line", "type:incident", "escalated", "topic:k8s"},
+			want: &LabelClass{
+				Urgency:      "High",
+				UrgencyClass: ClassUrgencyHigh,
+				Team:         "FirstLine",
+				TeamClass:    ClassTeamA,
+				Genre:        "Incident",
+				GenreClass:   ClassGenreFailure,
+				Escalation:   true,
+				Keywords:     []string{"k8s"},
+			},
+		},
+		{
+			name:     "no matched labels",
+			taxonomy: english,
+			labels:   []string{"緊急度：高", "Escalation"},
+			want:     &LabelClass{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.taxonomy.Classify(tt.labels); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Taxonomy.Classify() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaxonomy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []LabelRule
+		wantErr bool
+	}{
+		{
+			name:    "default taxonomy",
+			rules:   DefaultTaxonomy().Rules,
+			wantErr: false,
+		},
+		{
+			name:    "unknown dimension",
+			rules:   []LabelRule{{Dimension: "priority", Label: "p1"}},
+			wantErr: true,
+		},
+		{
+			name:    "no matcher",
+			rules:   []LabelRule{{Dimension: DimensionGenre, Display: "bug"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &Taxonomy{Rules: tt.rules}
+			if err := tx.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Taxonomy.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTaxonomy_Classes(t *testing.T) {
+	tx := &Taxonomy{Rules: []LabelRule{
+		{Dimension: DimensionGenre, Label: "type:incident", Display: "Incident", Class: ClassGenreFailure},
+		{Dimension: DimensionGenre, Label: "type:outage", Display: "Outage", Class: ClassGenreFailure},
+		{Dimension: DimensionGenre, Label: "type:question", Class: ClassGenreNormal},
+		{Dimension: DimensionGenre, Prefix: "type:"},
+		{Dimension: DimensionUrgency, Label: "priority:high", Display: "High", Class: ClassUrgencyHigh},
+	}}
+	want := []*ClassName{
+		{Class: ClassGenreFailure, Display: "Incident"},
+		{Class: ClassGenreNormal, Display: ClassGenreNormal},
+	}
+	if got := tx.Classes(DimensionGenre); !reflect.DeepEqual(got, want) {
+		t.Errorf("Taxonomy.Classes() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,319 @@
+package usersupport
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimelineStats is dwell time per stage of issues closed in spans. Summary is keyed by span
+type TimelineStats struct {
+	Summary   map[string]*TimelineSummary `json:"summary" yaml:"summary"`
+	Timelines []*Timeline                 `json:"timelines" yaml:"timelines"`
+	// UrgencyClasses and TeamClasses are classes of the taxonomy in order of the report
+	UrgencyClasses []*ClassName `json:"urgency_classes" yaml:"urgency_classes"`
+	TeamClasses    []*ClassName `json:"team_classes" yaml:"team_classes"`
+}
+
+// TimelineSummary is average dwell time per stage of issues closed in the span
+type TimelineSummary struct {
+	Span      string `json:"span" yaml:"span"`
+	NumIssues int    `json:"num_issues" yaml:"num_issues"`
+	// AvgTeamHours and AvgUrgencyHours are keyed by class of the stage, averaged over issues which stayed in it
+	AvgTeamHours    map[string]float64 `json:"avg_team_hours" yaml:"avg_team_hours"`
+	AvgUrgencyHours map[string]float64 `json:"avg_urgency_hours" yaml:"avg_urgency_hours"`
+	NumEscalated    int                `json:"num_escalated" yaml:"num_escalated"`
+	// NumEscalatedWithoutEvent is escalated issues whose escalation label event is not found
+	NumEscalatedWithoutEvent int `json:"num_escalated_without_event" yaml:"num_escalated_without_event"`
+	// AvgHoursToEscalation is averaged over escalated issues except ones without escalation event
+	AvgHoursToEscalation float64 `json:"avg_hours_to_escalation" yaml:"avg_hours_to_escalation"`
+}
+
+// Timeline is stages of an issue reconstructed from label events
+type Timeline struct {
+	Repository string `json:"repository" yaml:"repository"`
+	Title      string `json:"title" yaml:"title"`
+	HTMLURL    string `json:"html_url" yaml:"html_url"`
+	TargetSpan string `json:"target_span" yaml:"target_span"`
+	// TeamHours and UrgencyHours are hours spent in each stage, keyed by class of the stage
+	TeamHours    map[string]float64 `json:"team_hours" yaml:"team_hours"`
+	UrgencyHours map[string]float64 `json:"urgency_hours" yaml:"urgency_hours"`
+	Escalated    bool               `json:"escalated" yaml:"escalated"`
+	// EscalatedWithoutEvent is whether the issue has been escalated since created as far as label events tell
+	EscalatedWithoutEvent bool `json:"escalated_without_event" yaml:"escalated_without_event"`
+	// HoursToEscalation is hours from created to escalated, which is valid when Escalated and not EscalatedWithoutEvent
+	HoursToEscalation float64 `json:"hours_to_escalation" yaml:"hours_to_escalation"`
+}
+
+func (us *userSupport) GetTimelineReportStats(ctx context.Context, since, until time.Time) (*TimelineStats, error) {
+	startEnd := fmt.Sprintf("%s~%s", since.Format("2006-01-02"), until.Format("2006-01-02"))
+	cli, err := us.repo.GetClosedSupportIssues(ctx, since, until)
+	if err != nil {
+		return nil, fmt.Errorf("get closed issues : %w", err)
+	}
+	ts := &TimelineStats{
+		Summary:        make(map[string]*TimelineSummary),
+		Timelines:      make([]*Timeline, 0, len(cli)),
+		UrgencyClasses: us.tx().Classes(DimensionUrgency),
+		TeamClasses:    us.tx().Classes(DimensionTeam),
+	}
+	// events are fetched at once rather than one by one in the loop below
+	events, err := us.issueEvents(ctx, cli)
+	if err != nil {
+		return nil, err
+	}
+	for i, issue := range cli {
+		tl := us.buildTimeline(issue, events[i], until)
+		tl.TargetSpan = startEnd
+		ts.Timelines = append(ts.Timelines, tl)
+	}
+	ts.Summary[startEnd] = summarizeTimelines(startEnd, ts.Timelines)
+	return ts, nil
+}
+
+// issueEvents fetches events of the issues in the pool of workers
+func (us *userSupport) issueEvents(ctx context.Context, issues []*Issue) ([][]*Event, error) {
+	events := make([][]*Event, len(issues))
+	err := ForEach(ctx, len(issues), us.workers, func(ctx context.Context, i int) error {
+		e, err := us.repo.GetIssueEvents(ctx, issues[i])
+		if err != nil {
+			return fmt.Errorf("get issue events : %w", err)
+		}
+		events[i] = e
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// buildTimeline reconstructs stages of the issue by replaying labeled and unlabeled events
+// labels are regarded as attached since created when the issue has no label event
+func (us *userSupport) buildTimeline(issue *Issue, events []*Event, until time.Time) *Timeline {
+	tl := &Timeline{
+		Repository: issue.Repository,
+		Title:      issue.Title,
+		HTMLURL:    issue.HTMLURL,
+	}
+	created := issue.CreatedAt
+	end := until
+	if !issue.ClosedAt.IsZero() {
+		end = issue.ClosedAt
+	}
+
+	sorted := make([]*Event, 0, len(events))
+	for _, e := range events {
+		if e != nil && (e.Event == "labeled" || e.Event == "unlabeled") {
+			sorted = append(sorted, e)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+	// labels are kept in attached order, so the latest label wins when a dimension has several
+	var labels []string
+	if len(sorted) == 0 {
+		labels = issue.LabelNames()
+	}
+
+	team := make(map[string]time.Duration)
+	urgency := make(map[string]time.Duration)
+	lc := us.tx().Classify(labels)
+	from := created
+	account := func(to time.Time) {
+		if !to.After(from) {
+			return
+		}
+		if lc.TeamClass != "" {
+			team[lc.TeamClass] += us.duration(from, to)
+		}
+		if lc.UrgencyClass != "" {
+			urgency[lc.UrgencyClass] += us.duration(from, to)
+		}
+		from = to
+	}
+	// the time of escalation is unknown when the initial labels are already escalated
+	if lc.Escalation {
+		tl.Escalated = true
+		tl.EscalatedWithoutEvent = true
+	}
+	for _, e := range sorted {
+		at := e.CreatedAt
+		if at.After(end) {
+			break
+		}
+		if at.Before(created) {
+			at = created
+		}
+		account(at)
+		name := e.Label.Name
+		labels = removeLabel(labels, name)
+		if e.Event == "labeled" {
+			labels = append(labels, name)
+		}
+		lc = us.tx().Classify(labels)
+		if lc.Escalation && !tl.Escalated {
+			tl.Escalated = true
+			tl.HoursToEscalation = hours(us.duration(created, at))
+		}
+	}
+	account(end)
+
+	tl.TeamHours = make(map[string]float64, len(team))
+	for k, v := range team {
+		tl.TeamHours[k] = hours(v)
+	}
+	tl.UrgencyHours = make(map[string]float64, len(urgency))
+	for k, v := range urgency {
+		tl.UrgencyHours[k] = hours(v)
+	}
+	return tl
+}
+
+// summarizeTimelines averages dwell time of every stage
+func summarizeTimelines(span string, timelines []*Timeline) *TimelineSummary {
+	s := &TimelineSummary{
+		Span:            span,
+		NumIssues:       len(timelines),
+		AvgTeamHours:    make(map[string]float64),
+		AvgUrgencyHours: make(map[string]float64),
+	}
+	average := func(dst map[string]float64, get func(tl *Timeline) map[string]float64) {
+		count := make(map[string]int)
+		for _, tl := range timelines {
+			for k, v := range get(tl) {
+				dst[k] += v
+				count[k]++
+			}
+		}
+		for k := range dst {
+			dst[k] = round1(dst[k] / float64(count[k]))
+		}
+	}
+	average(s.AvgTeamHours, func(tl *Timeline) map[string]float64 { return tl.TeamHours })
+	average(s.AvgUrgencyHours, func(tl *Timeline) map[string]float64 { return tl.UrgencyHours })
+	var total float64
+	for _, tl := range timelines {
+		if !tl.Escalated {
+			continue
+		}
+		s.NumEscalated++
+		if tl.EscalatedWithoutEvent {
+			s.NumEscalatedWithoutEvent++
+			continue
+		}
+		total += tl.HoursToEscalation
+	}
+	if timed := s.NumEscalated - s.NumEscalatedWithoutEvent; timed > 0 {
+		s.AvgHoursToEscalation = round1(total / float64(timed))
+	}
+	return s
+}
+
+// GenMarkdown generates table of average dwell time per stage
+func (ts *TimelineStats) GenMarkdown() string {
+	var sb strings.Builder
+	summaries := make([]*TimelineSummary, 0, len(ts.Summary))
+	for _, s := range ts.Summary {
+		summaries = append(summaries, s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Span < summaries[j].Span
+	})
+	teams := sortedClasses(stageNames(summaries, func(s *TimelineSummary) map[string]float64 { return s.AvgTeamHours }), ts.TeamClasses)
+	urgencies := sortedClasses(stageNames(summaries, func(s *TimelineSummary) map[string]float64 { return s.AvgUrgencyHours }), ts.UrgencyClasses)
+
+	row := func(name string, value func(s *TimelineSummary) string) {
+		values := make([]string, 0, len(summaries))
+		for _, s := range summaries {
+			values = append(values, value(s))
+		}
+		sb.WriteString(fmt.Sprintf("|%s|%s|\n", name, strings.Join(values, "|")))
+	}
+	sb.WriteString("## ステージ別平均滞留時間 (hour) \n")
+	row("項目", func(s *TimelineSummary) string { return s.Span })
+	row("----", func(s *TimelineSummary) string { return "----" })
+	row("クローズ件数", func(s *TimelineSummary) string { return strconv.Itoa(s.NumIssues) })
+	for _, team := range teams {
+		row("担当:"+team.Display, func(s *TimelineSummary) string { return formatHours(s.AvgTeamHours[team.Class]) })
+	}
+	for _, urgency := range urgencies {
+		row("緊急度:"+urgency.Display, func(s *TimelineSummary) string { return formatHours(s.AvgUrgencyHours[urgency.Class]) })
+	}
+	row("エスカレーション件数", func(s *TimelineSummary) string { return strconv.Itoa(s.NumEscalated) })
+	row("エスカレーション時刻不明件数", func(s *TimelineSummary) string { return strconv.Itoa(s.NumEscalatedWithoutEvent) })
+	row("エスカレーションまでの時間", func(s *TimelineSummary) string { return formatHours(s.AvgHoursToEscalation) })
+	return sb.String()
+}
+
+// GenCSV generates dwell time per stage of every issue
+func (ts *TimelineStats) GenCSV() string {
+	records := [][]string{
+		{"期間", "リポジトリ", "Title", "種別", "ステージ", "滞留時間(hour)", "URL"},
+	}
+	for _, tl := range ts.Timelines {
+		for _, team := range sortedClasses(sortedKeys(tl.TeamHours), ts.TeamClasses) {
+			records = append(records, []string{tl.TargetSpan, tl.Repository, tl.Title, "担当", team.Display, formatHours(tl.TeamHours[team.Class]), tl.HTMLURL})
+		}
+		for _, urgency := range sortedClasses(sortedKeys(tl.UrgencyHours), ts.UrgencyClasses) {
+			records = append(records, []string{tl.TargetSpan, tl.Repository, tl.Title, "緊急度", urgency.Display, formatHours(tl.UrgencyHours[urgency.Class]), tl.HTMLURL})
+		}
+		if tl.Escalated {
+			// hours are left blank when the time of escalation is unknown
+			h := ""
+			if !tl.EscalatedWithoutEvent {
+				h = formatHours(tl.HoursToEscalation)
+			}
+			records = append(records, []string{tl.TargetSpan, tl.Repository, tl.Title, "エスカレーション", "エスカレーションまで", h, tl.HTMLURL})
+		}
+	}
+	return genCSV(records)
+}
+
+// stageNames returns sorted classes of stages which appear in summaries
+func stageNames(summaries []*TimelineSummary, get func(s *TimelineSummary) map[string]float64) []string {
+	seen := make(map[string]float64)
+	for _, s := range summaries {
+		for k := range get(s) {
+			seen[k] = 0
+		}
+	}
+	return sortedKeys(seen)
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// removeLabel removes the label keeping order of the others
+func removeLabel(labels []string, name string) []string {
+	out := labels[:0]
+	for _, l := range labels {
+		if l != name {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+func hours(d time.Duration) float64 {
+	return round1(d.Hours())
+}
+
+func round1(f float64) float64 {
+	return math.Round(f*10) / 10
+}
+
+func formatHours(h float64) string {
+	return strconv.FormatFloat(h, 'f', 1, 64)
+}
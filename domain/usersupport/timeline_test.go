@@ -0,0 +1,167 @@
+package usersupport
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestUserSupport_GetTimelineReportStats(t *testing.T) {
+	since := time.Date(2020, 10, 1, 0, 0, 0, 0, loc)
+	until := time.Date(2020, 10, 31, 0, 0, 0, 0, loc)
+	created := time.Date(2020, 10, 5, 9, 0, 0, 0, loc)
+	closed := created.Add(30 * time.Hour)
+	label := func(event, name string, after time.Duration) *Event {
+		return &Event{
+			Event:     event,
+			Label:     Label{Name: name},
+			CreatedAt: created.Add(after),
+		}
+	}
+	escalated := &Issue{
+		Title:      "escalated issue",
+		HTMLURL:    "https://github.com/sataga/issue-warehouse/issues/1",
+		Repository: "sataga/issue-warehouse",
+		State:      "closed",
+		CreatedAt:  created,
+		ClosedAt:   closed,
+	}
+	// issue without label events keeps current labels since created
+	unlabeled := &Issue{
+		Title:      "quiet issue",
+		HTMLURL:    "https://github.com/sataga/issue-warehouse/issues/2",
+		Repository: "sataga/issue-warehouse",
+		State:      "closed",
+		CreatedAt:  created,
+		ClosedAt:   created.Add(10 * time.Hour),
+		Labels: []Label{
+			{Name: "CaaS-A 対応中"},
+			{Name: "緊急度：低"},
+		},
+	}
+	// issue escalated before its label events were kept has no time of escalation
+	untimed := &Issue{
+		Title:      "untimed issue",
+		HTMLURL:    "https://github.com/sataga/issue-warehouse/issues/3",
+		Repository: "sataga/issue-warehouse",
+		State:      "closed",
+		CreatedAt:  created,
+		ClosedAt:   created.Add(5 * time.Hour),
+		Labels: []Label{
+			{Name: "Escalation"},
+		},
+	}
+	events := []*Event{
+		label("labeled", "PF_Support", 0),
+		label("labeled", "CaaS-A 対応中", 0),
+		label("labeled", "緊急度：低", 0),
+		{Event: "commented", CreatedAt: created.Add(time.Hour)},
+		label("labeled", "緊急度：高", 4*time.Hour),
+		label("unlabeled", "緊急度：低", 4*time.Hour),
+		label("labeled", "Escalation", 6*time.Hour),
+		label("unlabeled", "CaaS-A 対応中", 6*time.Hour),
+		label("labeled", "CaaS-B 対応中", 6*time.Hour),
+		// events after closed are ignored
+		label("labeled", "CaaS-A 対応中", 40*time.Hour),
+	}
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+	musr := NewMockRepository(c)
+	musr.EXPECT().GetClosedSupportIssues(gomock.Any(), since, until).Return([]*Issue{escalated, unlabeled, untimed}, nil)
+	musr.EXPECT().GetIssueEvents(gomock.Any(), escalated).Return(events, nil)
+	musr.EXPECT().GetIssueEvents(gomock.Any(), unlabeled).Return([]*Event{}, nil)
+	musr.EXPECT().GetIssueEvents(gomock.Any(), untimed).Return([]*Event{}, nil)
+
+	// timelines are in order of issues whichever events are fetched first
+	us := NewUserSupport(musr, &Config{Workers: 2})
+	got, err := us.GetTimelineReportStats(context.Background(), since, until)
+	if err != nil {
+		t.Fatalf("GetTimelineReportStats() error = %v", err)
+	}
+	span := "2020-10-01~2020-10-31"
+	want := &TimelineStats{
+		Summary: map[string]*TimelineSummary{
+			span: {
+				Span:                     span,
+				NumIssues:                3,
+				AvgTeamHours:             map[string]float64{ClassTeamA: 8, ClassTeamB: 24},
+				AvgUrgencyHours:          map[string]float64{ClassUrgencyLow: 7, ClassUrgencyHigh: 26},
+				NumEscalated:             2,
+				NumEscalatedWithoutEvent: 1,
+				AvgHoursToEscalation:     6,
+			},
+		},
+		Timelines: []*Timeline{
+			{
+				Repository:        "sataga/issue-warehouse",
+				Title:             "escalated issue",
+				HTMLURL:           "https://github.com/sataga/issue-warehouse/issues/1",
+				TargetSpan:        span,
+				TeamHours:         map[string]float64{ClassTeamA: 6, ClassTeamB: 24},
+				UrgencyHours:      map[string]float64{ClassUrgencyLow: 4, ClassUrgencyHigh: 26},
+				Escalated:         true,
+				HoursToEscalation: 6,
+			},
+			{
+				Repository:   "sataga/issue-warehouse",
+				Title:        "quiet issue",
+				HTMLURL:      "https://github.com/sataga/issue-warehouse/issues/2",
+				TargetSpan:   span,
+				TeamHours:    map[string]float64{ClassTeamA: 10},
+				UrgencyHours: map[string]float64{ClassUrgencyLow: 10},
+			},
+			{
+				Repository:            "sataga/issue-warehouse",
+				Title:                 "untimed issue",
+				HTMLURL:               "https://github.com/sataga/issue-warehouse/issues/3",
+				TargetSpan:            span,
+				TeamHours:             map[string]float64{},
+				UrgencyHours:          map[string]float64{},
+				Escalated:             true,
+				EscalatedWithoutEvent: true,
+			},
+		},
+		UrgencyClasses: DefaultTaxonomy().Classes(DimensionUrgency),
+		TeamClasses:    DefaultTaxonomy().Classes(DimensionTeam),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetTimelineReportStats() = %+v, want %+v", got, want)
+	}
+
+	wantMarkdown := `## ステージ別平均滞留時間 (hour) 
+|項目|2020-10-01~2020-10-31|
+|----|----|
+|クローズ件数|3|
+|担当:CaaS-A|8.0|
+|担当:CaaS-B|24.0|
+|緊急度:高|26.0|
+|緊急度:低|7.0|
+|エスカレーション件数|2|
+|エスカレーション時刻不明件数|1|
+|エスカレーションまでの時間|6.0|
+`
+	if md := got.GenMarkdown(); md != wantMarkdown {
+		t.Errorf("GenMarkdown() = %v, want %v", md, wantMarkdown)
+	}
+
+	// stages are named by display of the classes
+	wantCSV := `期間,リポジトリ,Title,種別,ステージ,滞留時間(hour),URL
+2020-10-01~2020-10-31,sataga/issue-warehouse,escalated issue,担当,CaaS-A,6.0,https://github.com/sataga/issue-warehouse/issues/1
+2020-10-01~2020-10-31,sataga/issue-warehouse,escalated issue,担当,CaaS-B,24.0,https://github.com/sataga/issue-warehouse/issues/1
+2020-10-01~2020-10-31,sataga/issue-warehouse,escalated issue,緊急度,高,26.0,https://github.com/sataga/issue-warehouse/issues/1
+2020-10-01~2020-10-31,sataga/issue-warehouse,escalated issue,緊急度,低,4.0,https://github.com/sataga/issue-warehouse/issues/1
+2020-10-01~2020-10-31,sataga/issue-warehouse,escalated issue,エスカレーション,エスカレーションまで,6.0,https://github.com/sataga/issue-warehouse/issues/1
+2020-10-01~2020-10-31,sataga/issue-warehouse,quiet issue,担当,CaaS-A,10.0,https://github.com/sataga/issue-warehouse/issues/2
+2020-10-01~2020-10-31,sataga/issue-warehouse,quiet issue,緊急度,低,10.0,https://github.com/sataga/issue-warehouse/issues/2
+2020-10-01~2020-10-31,sataga/issue-warehouse,untimed issue,エスカレーション,エスカレーションまで,,https://github.com/sataga/issue-warehouse/issues/3
+`
+	wantCSV = strings.Replace(wantCSV, "\n", "\r\n", -1)
+	if csv := got.GenCSV(); csv != wantCSV {
+		t.Errorf("GenCSV() = %v, want %v", csv, wantCSV)
+	}
+}
@@ -4,6 +4,7 @@
 package usersupport
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"sort"
@@ -11,7 +12,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/google/go-github/github"
+	"github.com/sataga/go-github-sample/domain/calendar"
 )
 
 var (
@@ -21,141 +22,241 @@ var (
 
 // UserSupport is interface for getting user support info
 type UserSupport interface {
-	GetDailyReportStats(now time.Time, dayAgo int) (*DailyStats, error)
-	GetLongTermReportStats(since, until time.Time) (*LongTermStats, error)
-	GetAnalysisReportStats(since, until time.Time, state string) (*AnalysisStats, error)
-	GetKeywordReportStats(since, until time.Time) (*KeywordStats, error)
-	MethodTest(since, until time.Time) (*AnalysisStats, error)
+	GetDailyReportStats(ctx context.Context, now time.Time, dayAgo int) (*DailyStats, error)
+	GetLongTermReportStats(ctx context.Context, since, until time.Time) (*LongTermStats, error)
+	GetAnalysisReportStats(ctx context.Context, since, until time.Time, state string) (*AnalysisStats, error)
+	GetKeywordReportStats(ctx context.Context, since, until time.Time) (*KeywordStats, error)
+	GetTimelineReportStats(ctx context.Context, since, until time.Time) (*TimelineStats, error)
+	GetMetricsStats(ctx context.Context, now time.Time, dayAgo, windowDays int) (*MetricsStats, error)
+	GetAssigneeReportStats(ctx context.Context, now, since, until time.Time, dayAgo int) (*AssigneeStats, error)
+	NudgeStaleIssues(ctx context.Context, now time.Time, dayAgo int, dryRun bool) (*NudgeStats, error)
+	MethodTest(ctx context.Context, since, until time.Time) (*AnalysisStats, error)
 	// GenMonthlyReport(data map[string]*LongTermStats) string
 }
 
 // Repository r/w data which usersupport domain requires
 type Repository interface {
-	GetUpdatedSupportIssues(since, until time.Time) ([]*github.Issue, error)
-	GetClosedSupportIssues(since, until time.Time) ([]*github.Issue, error)
-	GetCurrentOpenNotUpdatedSupportIssues(until time.Time) ([]*github.Issue, error)
-	GetCurrentOpenSupportIssues() ([]*github.Issue, error)
-	GetCreatedSupportIssues(since, until time.Time) ([]*github.Issue, error)
-	GetLabelsByQuery(query string) ([]*github.LabelResult, error)
+	GetUpdatedSupportIssues(ctx context.Context, since, until time.Time) ([]*Issue, error)
+	GetClosedSupportIssues(ctx context.Context, since, until time.Time) ([]*Issue, error)
+	GetCurrentOpenNotUpdatedSupportIssues(ctx context.Context, until time.Time) ([]*Issue, error)
+	GetCurrentOpenSupportIssues(ctx context.Context) ([]*Issue, error)
+	GetCreatedSupportIssues(ctx context.Context, since, until time.Time) ([]*Issue, error)
+	GetLabelsByQuery(ctx context.Context, query string) ([]*Label, error)
+	GetIssueComments(ctx context.Context, issue *Issue) ([]*Comment, error)
+	GetIssueEvents(ctx context.Context, issue *Issue) ([]*Event, error)
+	CreateIssueComment(ctx context.Context, issue *Issue, body string) (*Comment, error)
 }
 
 type userSupport struct {
-	repo Repository
+	repo     Repository
+	taxonomy *Taxonomy
+	// teamMembers are lower cased logins of the support team
+	teamMembers map[string]bool
+	calendar    *calendar.Calendar
+	scoring     *Scoring
+	// maxOpenIssues is the threshold to flag an assignee as overloaded
+	maxOpenIssues int
+	nudge         *NudgeConfig
+	// loc is time zone of dates in detail stats
+	loc *time.Location
+	// workers bounds how many comments or events of issues are fetched at once
+	workers int
 }
 
-// DailyStats is stats open data from GitHub
+// Config is settings of usersupport domain
+type Config struct {
+	Taxonomy *Taxonomy
+	// TeamMembers are logins of the support team, whose comment is regarded as a response
+	TeamMembers []string
+	// Calendar measures durations, scores and days of daily report in business hours when set
+	Calendar *calendar.Calendar
+	// Scoring is buckets of resolution time, A-F buckets are used when nil
+	Scoring *Scoring
+	// MaxOpenIssues flags assignees who have more open issues than it in assignee report, 0 disables it
+	MaxOpenIssues int
+	// Nudge is settings of comments on stale issues, default one is used when nil
+	Nudge *NudgeConfig
+	// Location is time zone of dates in detail stats, Asia/Tokyo is used when nil
+	Location *time.Location
+	// Workers bounds how many comments or events of issues are fetched at once, less than 1 fetches them serially
+	Workers int
+}
+
+// DailyStats is stats of open issues which have not been updated for DayAgo days
 type DailyStats struct {
-	dayAgo              int                  `yaml:"day_ago"`
-	NumNotUpdatedIssues int                  `yaml:"num_not_updated_issues"`
-	NumTeamAResponse    int                  `yaml:"num_team_a_response"`
-	NumTeamBResponse    int                  `yaml:"num_team_b_response"`
-	NumTeamAHighIssues  int                  `yaml:"num_team_a_high_issues"`
-	NumTeamBLowIssues   int                  `yaml:"num_team_b_low_issues"`
-	UrgencyHighIssues   int                  `yaml:"num_urgency_high_issues"`
-	UrgencyLowIssues    int                  `yaml:"num_urgency_low_issues"`
-	DetailStats         map[int]*DetailStats `yaml:"detail_stats"`
+	DayAgo              int `json:"day_ago" yaml:"day_ago"`
+	NumNotUpdatedIssues int `json:"num_not_updated_issues" yaml:"num_not_updated_issues"`
+	// NumUrgencyIssues and NumTeamIssues are counts of issues keyed by class of the taxonomy
+	NumUrgencyIssues map[string]int `json:"num_urgency_issues" yaml:"num_urgency_issues"`
+	NumTeamIssues    map[string]int `json:"num_team_issues" yaml:"num_team_issues"`
+	// UrgencyClasses and TeamClasses are classes of the taxonomy in order of the report
+	UrgencyClasses []*ClassName   `json:"urgency_classes" yaml:"urgency_classes"`
+	TeamClasses    []*ClassName   `json:"team_classes" yaml:"team_classes"`
+	DetailStats    DetailStatsMap `json:"detail_stats" yaml:"detail_stats"`
 }
+
+// LongTermStats is stats of issues per span. SummaryStats is keyed by span
 type LongTermStats struct {
-	SummaryStats map[string]*SummaryStats `yaml:"summary_stats"`
-	DetailStats  map[int]*DetailStats     `yaml:"detail_stats"`
+	SummaryStats map[string]*SummaryStats `json:"summary_stats" yaml:"summary_stats"`
+	// ScoreLabels are labels of score buckets in order of the report
+	ScoreLabels []string `json:"score_labels" yaml:"score_labels"`
+	// GenreClasses, UrgencyClasses and TeamClasses are classes of the taxonomy in order of the report
+	GenreClasses   []*ClassName   `json:"genre_classes" yaml:"genre_classes"`
+	UrgencyClasses []*ClassName   `json:"urgency_classes" yaml:"urgency_classes"`
+	TeamClasses    []*ClassName   `json:"team_classes" yaml:"team_classes"`
+	DetailStats    DetailStatsMap `json:"detail_stats" yaml:"detail_stats"`
 }
 
+// AnalysisStats is detail of issues to analyze in spreadsheet
 type AnalysisStats struct {
-	DetailStats map[int]*DetailStats `yaml:"detail_stats"`
+	DetailStats DetailStatsMap `json:"detail_stats" yaml:"detail_stats"`
 }
 
+// KeywordStats is count of keyword labels per span. KeywordSummary is keyed by span
 type KeywordStats struct {
-	KeywordSummary map[string]*KeywordSummary `yaml:"keyword_summary"`
+	KeywordSummary map[string]*KeywordSummary `json:"keyword_summary" yaml:"keyword_summary"`
 }
 
+// KeywordSummary is count of closed issues per keyword label in the span
 type KeywordSummary struct {
-	Span                     string         `yaml:"span"`
-	KeywordCountAsAll        map[string]int `yaml:"keyword_count_as_all"`
-	KeywordCountAsEscalation map[string]int `yaml:"keyword_count_as_escalation"`
+	Span                     string         `json:"span" yaml:"span"`
+	KeywordCountAsAll        map[string]int `json:"keyword_count_as_all" yaml:"keyword_count_as_all"`
+	KeywordCountAsEscalation map[string]int `json:"keyword_count_as_escalation" yaml:"keyword_count_as_escalation"`
 }
+
+// SummaryStats is summary of issues in the span
+// counts other than NumCreatedIssues are of issues closed in the span
 type SummaryStats struct {
-	Span                       string  `yajl:"span"`
-	NumCreatedIssues           int     `yaml:"num_created_issues"`
-	NumClosedIssues            int     `yaml:"num_closed_issues"`
-	NumGenreNormalIssues       int     `yaml:"num_genre_log_survey_issues"`
-	NumGenreRequestIssues      int     `yaml:"num_genre_issues"`
-	NumGenreFailureIssues      int     `yaml:"num_genre_impact_survey_issues"`
-	NumEscalationAllIssues     int     `yaml:"num_escalation_all_issues"`
-	NumEscalationNormalIssues  int     `yaml:"num_escalation_normal_issues"`
-	NumEscalationRequestIssues int     `yaml:"num_escalation_request_issues"`
-	NumEscalationFailureIssues int     `yaml:"num_escalation_failure_issues"`
-	NumUrgencyHighIssues       int     `yaml:"num_urgency_high_issues"`
-	NumUrgencyLowIssues        int     `yaml:"num_urgency_low_issues"`
-	NumScoreA                  int     `yaml:"num_score_A"`
-	NumScoreB                  int     `yaml:"num_score_B"`
-	NumScoreC                  int     `yaml:"num_score_C"`
-	NumScoreD                  int     `yaml:"num_score_D"`
-	NumScoreE                  int     `yaml:"num_score_E"`
-	NumScoreF                  int     `yaml:"num_score_F"`
-	NumTotalScore              float64 `yaml:"num_total_score"`
+	// Span is "since~until" formatted in 2006-01-02
+	Span                   string         `json:"span" yaml:"span"`
+	NumCreatedIssues       int            `json:"num_created_issues" yaml:"num_created_issues"`
+	NumClosedIssues        int            `json:"num_closed_issues" yaml:"num_closed_issues"`
+	NumCreatedIssuesByRepo map[string]int `json:"num_created_issues_by_repo" yaml:"num_created_issues_by_repo"`
+	NumClosedIssuesByRepo  map[string]int `json:"num_closed_issues_by_repo" yaml:"num_closed_issues_by_repo"`
+	NumEscalationAllIssues int            `json:"num_escalation_all_issues" yaml:"num_escalation_all_issues"`
+	// NumGenreIssues, NumEscalationIssues and NumUrgencyIssues are counts of issues keyed by class of the taxonomy
+	// NumEscalationIssues is of escalated issues per genre class, issues without class are counted only in totals
+	NumGenreIssues      map[string]int `json:"num_genre_issues" yaml:"num_genre_issues"`
+	NumEscalationIssues map[string]int `json:"num_escalation_issues" yaml:"num_escalation_issues"`
+	NumUrgencyIssues    map[string]int `json:"num_urgency_issues" yaml:"num_urgency_issues"`
+	// NumScores are counts of issues keyed by label of the score bucket which resolution time falls in
+	NumScores map[string]int `json:"num_scores" yaml:"num_scores"`
+	// NumTotalScore is mean of weights of the score buckets
+	NumTotalScore float64 `json:"num_total_score" yaml:"num_total_score"`
+	// FirstResponseMedian and FirstResponseP90 are minutes until the first response of responded issues
+	FirstResponseMedian int `json:"first_response_median" yaml:"first_response_median"`
+	FirstResponseP90    int `json:"first_response_p90" yaml:"first_response_p90"`
+	NumNoResponseIssues int `json:"num_no_response_issues" yaml:"num_no_response_issues"`
+	// ResolutionTime is distribution of hours from created to closed
+	ResolutionTime *DistributionStats `json:"resolution_time" yaml:"resolution_time"`
+	// FirstResponseTime is distribution of minutes until the first response of responded issues
+	FirstResponseTime *DistributionStats `json:"first_response_time" yaml:"first_response_time"`
 }
 
+// DetailStats is detail of an issue
 type DetailStats struct {
-	Title        string `yaml:"detail_stats_of_title"`
-	ServiceID    string `yaml:"detail_stats_of_service_id"`
-	HTMLURL      string `yaml:"detail_stats_of_issue_url"`
-	CreatedAt    string `yaml:"detail_stats_of_created_at"`
-	ClosedAt     string `yaml:"detail_stats_of_closed_at"`
-	State        string `yaml:"detail_stats_of_state"`
-	TargetSpan   string `yaml:"detail_stats_of_target_span"`
-	TeamName     string `yaml:"detail_stats_of_team_name"`
-	Urgency      string `yaml:"detail_stats_of_urgency"`
-	Genre        string `yaml:"detail_stats_of_genre"`
-	Labels       string `yaml:"detail_stats_of_labels"`
-	Assignee     string `yaml:"detail_stats_of_assign"`
-	NumComments  int    `yaml:"detail_stats_of_num_comment"`
-	OpenDuration int    `yaml:"detail_stats_of_open_duration"`
-	Escalation   bool   `yaml:"detail_stats_of_esalation"`
+	// Repository is full name (owner/name) of the repository
+	Repository string `json:"repository" yaml:"repository"`
+	Title      string `json:"title" yaml:"title"`
+	// ServiceID is INC number in the title
+	ServiceID string `json:"service_id" yaml:"service_id"`
+	HTMLURL   string `json:"html_url" yaml:"html_url"`
+	// CreatedAt and ClosedAt are formatted in 2006-01-02 of JST
+	CreatedAt string `json:"created_at" yaml:"created_at"`
+	ClosedAt  string `json:"closed_at" yaml:"closed_at"`
+	State     string `json:"state" yaml:"state"`
+	// TargetSpan is the span which the issue is aggregated in
+	TargetSpan string `json:"target_span" yaml:"target_span"`
+	TeamName   string `json:"team_name" yaml:"team_name"`
+	Urgency    string `json:"urgency" yaml:"urgency"`
+	Genre      string `json:"genre" yaml:"genre"`
+	// Labels are space separated keywords
+	Labels string `json:"labels" yaml:"labels"`
+	// Assignee is space separated @login
+	Assignee    string `json:"assignee" yaml:"assignee"`
+	NumComments int    `json:"num_comments" yaml:"num_comments"`
+	// OpenDuration is hours from created to closed, or to last updated when still open
+	OpenDuration int  `json:"open_duration" yaml:"open_duration"`
+	Escalation   bool `json:"escalation" yaml:"escalation"`
+	// FirstResponse is minutes from created to the first comment of the support team, which is valid when Responded
+	FirstResponse int  `json:"first_response" yaml:"first_response"`
+	Responded     bool `json:"responded" yaml:"responded"`
 }
 
 // NewUserSupport creates UserSupport
-func NewUserSupport(repo Repository) UserSupport {
-	return &userSupport{
+func NewUserSupport(repo Repository, cfg *Config) UserSupport {
+	us := &userSupport{
 		repo: repo,
 	}
+	if cfg != nil {
+		us.loc = cfg.Location
+		us.taxonomy = cfg.Taxonomy
+		us.calendar = cfg.Calendar
+		us.scoring = cfg.Scoring
+		us.maxOpenIssues = cfg.MaxOpenIssues
+		us.nudge = cfg.Nudge
+		us.workers = cfg.Workers
+		if len(cfg.TeamMembers) > 0 {
+			us.teamMembers = make(map[string]bool, len(cfg.TeamMembers))
+			for _, m := range cfg.TeamMembers {
+				us.teamMembers[strings.ToLower(m)] = true
+			}
+		}
+	}
+	return us
+}
+
+// location returns time zone of dates, Asia/Tokyo is used when not configured
+func (us *userSupport) location() *time.Location {
+	if us.loc == nil {
+		return jp
+	}
+	return us.loc
+}
+
+// tx returns taxonomy of labels, default one is used when not configured
+func (us *userSupport) tx() *Taxonomy {
+	if us.taxonomy == nil {
+		return DefaultTaxonomy()
+	}
+	return us.taxonomy
+}
+
+// sc returns scoring of resolution time, default one is used when not configured
+func (us *userSupport) sc() *Scoring {
+	if us.scoring == nil {
+		return DefaultScoring()
+	}
+	return us.scoring
 }
 
 // GetDailryReport
-func (us *userSupport) GetDailyReportStats(now time.Time, dayAgo int) (*DailyStats, error) {
-	until := now.Add(time.Duration(-24*dayAgo) * time.Hour)
+func (us *userSupport) GetDailyReportStats(ctx context.Context, now time.Time, dayAgo int) (*DailyStats, error) {
+	until := us.daysBefore(now, dayAgo)
 	startEnd := fmt.Sprintf("%s", until.Format("2006-01-02"))
-	opi, err := us.repo.GetCurrentOpenNotUpdatedSupportIssues(until)
+	opi, err := us.repo.GetCurrentOpenNotUpdatedSupportIssues(ctx, until)
 	if err != nil {
-		return nil, fmt.Errorf("get open issues : %s", err)
+		return nil, fmt.Errorf("get open issues : %w", err)
 	}
 	DailyStats := &DailyStats{
-		dayAgo:              dayAgo,
+		DayAgo:              dayAgo,
 		NumNotUpdatedIssues: len(opi),
+		UrgencyClasses:      us.tx().Classes(DimensionUrgency),
+		TeamClasses:         us.tx().Classes(DimensionTeam),
 		DetailStats:         make(map[int]*DetailStats, len(opi)),
 	}
+	DailyStats.NumUrgencyIssues = zeroCounts(DailyStats.UrgencyClasses)
+	DailyStats.NumTeamIssues = zeroCounts(DailyStats.TeamClasses)
 	for i, issue := range opi {
+		lc := us.tx().ClassifyIssue(issue)
 		DailyStats.DetailStats[i] = &DetailStats{}
-		if labelContains(issue.Labels, "緊急度：高") {
-			DailyStats.UrgencyHighIssues++
-			DailyStats.DetailStats[i].Urgency = "高"
-		}
-		if labelContains(issue.Labels, "緊急度：中") {
-			DailyStats.UrgencyHighIssues++
-			DailyStats.DetailStats[i].Urgency = "中"
-		}
-		if labelContains(issue.Labels, "緊急度：低") {
-			DailyStats.UrgencyLowIssues++
-			DailyStats.DetailStats[i].Urgency = "低"
+		if lc.UrgencyClass != "" {
+			DailyStats.NumUrgencyIssues[lc.UrgencyClass]++
 		}
-		if labelContains(issue.Labels, "CaaS-A 対応中") {
-			DailyStats.NumTeamAResponse++
-			DailyStats.DetailStats[i].TeamName = "CaaS-A 対応中"
+		if lc.TeamClass != "" {
+			DailyStats.NumTeamIssues[lc.TeamClass]++
 		}
-		if labelContains(issue.Labels, "CaaS-B 対応中") {
-			DailyStats.NumTeamBResponse++
-			DailyStats.DetailStats[i].TeamName = "CaaS-B 対応中"
-		}
-		DailyStats.DetailStats[i].writeDetailStats(issue, startEnd)
+		DailyStats.DetailStats[i].writeDetailStats(issue, lc, startEnd, us.openDuration(issue), us.location())
 	}
 	return DailyStats, nil
 }
@@ -174,16 +275,20 @@ func (ds *DailyStats) GetDailyReportStats() string {
 	sort.Slice(kvArrForDetail, func(i, j int) bool {
 		return kvArrForDetail[i].Val.CreatedAt < kvArrForDetail[j].Val.CreatedAt
 	})
-	sb.WriteString(fmt.Sprintf("■ *%d日間* 以上更新がなかったチケット一覧\n", ds.dayAgo))
+	sb.WriteString(fmt.Sprintf("■ *%d日間* 以上更新がなかったチケット一覧\n", ds.DayAgo))
 	sb.WriteString(fmt.Sprintf("=== サマリー ===\n"))
 	sb.WriteString(fmt.Sprintf("総未更新チケット数: %d 件\n", ds.NumNotUpdatedIssues))
-	sb.WriteString(fmt.Sprintf("    緊急度：高・中: %d 件\n", ds.NumTeamAResponse))
-	sb.WriteString(fmt.Sprintf("    緊急度：低: %d 件\n", ds.NumTeamBResponse))
+	for _, c := range ds.Urgencies() {
+		sb.WriteString(fmt.Sprintf("    緊急度：%s: %d 件\n", c.Display, ds.NumUrgencyIssues[c.Class]))
+	}
+	for _, c := range ds.Teams() {
+		sb.WriteString(fmt.Sprintf("    担当チーム：%s: %d 件\n", c.Display, ds.NumTeamIssues[c.Class]))
+	}
 	sb.WriteString(fmt.Sprintf("=== 詳細 ===\n"))
 	for _, d := range kvArrForDetail {
 		dates := d.Val.OpenDuration / 24
 		hours := d.Val.OpenDuration % 24
-		sb.WriteString(fmt.Sprintf("- <%s|%s> ", d.Val.HTMLURL, d.Val.Title))
+		sb.WriteString(fmt.Sprintf("- <%s|%s> ", d.Val.HTMLURL, EscapeMrkdwn(d.Val.Title)))
 		sb.WriteString(fmt.Sprintf("経過時間:%dd%dh ", dates, hours))
 		sb.WriteString(fmt.Sprintf("緊急度：%s ", d.Val.Urgency))
 		sb.WriteString(fmt.Sprintf("%s\n", d.Val.Assignee))
@@ -191,90 +296,130 @@ func (ds *DailyStats) GetDailyReportStats() string {
 	return sb.String()
 }
 
-func (us *userSupport) GetLongTermReportStats(since, until time.Time) (*LongTermStats, error) {
+// Urgencies returns urgency classes of the taxonomy in its order, followed by other classes counted in the stats
+func (ds *DailyStats) Urgencies() []*ClassName {
+	return countedClasses(ds.UrgencyClasses, ds.NumUrgencyIssues)
+}
+
+// Teams returns team classes of the taxonomy in its order, followed by other classes counted in the stats
+func (ds *DailyStats) Teams() []*ClassName {
+	return countedClasses(ds.TeamClasses, ds.NumTeamIssues)
+}
+
+// issueComments fetches comments of the issues in the pool of workers, issues without comments are not requested
+func (us *userSupport) issueComments(ctx context.Context, issues []*Issue) ([][]*Comment, error) {
+	comments := make([][]*Comment, len(issues))
+	err := ForEach(ctx, len(issues), us.workers, func(ctx context.Context, i int) error {
+		if issues[i].NumComments == 0 {
+			return nil
+		}
+		c, err := us.repo.GetIssueComments(ctx, issues[i])
+		if err != nil {
+			return fmt.Errorf("get issue comments : %w", err)
+		}
+		comments[i] = c
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (us *userSupport) GetLongTermReportStats(ctx context.Context, since, until time.Time) (*LongTermStats, error) {
 
+	scoring := us.sc()
 	LongTermStats := &LongTermStats{
-		SummaryStats: make(map[string]*SummaryStats),
-		DetailStats:  make(map[int]*DetailStats),
+		SummaryStats:   make(map[string]*SummaryStats),
+		ScoreLabels:    scoring.Labels(),
+		GenreClasses:   us.tx().Classes(DimensionGenre),
+		UrgencyClasses: us.tx().Classes(DimensionUrgency),
+		TeamClasses:    us.tx().Classes(DimensionTeam),
+		DetailStats:    make(map[int]*DetailStats),
 	}
 	cnt := 0
 	startEnd := fmt.Sprintf("%s~%s", since.Format("2006-01-02"), until.Format("2006-01-02"))
-	cri, err := us.repo.GetCreatedSupportIssues(since, until)
+	cri, err := us.repo.GetCreatedSupportIssues(ctx, since, until)
 	if err != nil {
-		return nil, fmt.Errorf("get open issues : %s", err)
+		return nil, fmt.Errorf("get open issues : %w", err)
 	}
 
-	cli, err := us.repo.GetClosedSupportIssues(since, until)
+	cli, err := us.repo.GetClosedSupportIssues(ctx, since, until)
 	if err != nil {
-		return nil, fmt.Errorf("get updated issues : %s", err)
+		return nil, fmt.Errorf("get updated issues : %w", err)
 	}
 
 	LongTermStats.SummaryStats[startEnd] = &SummaryStats{
-		Span:             startEnd,
-		NumCreatedIssues: len(cri),
+		Span:                   startEnd,
+		NumCreatedIssues:       len(cri),
+		NumCreatedIssuesByRepo: make(map[string]int),
+		NumClosedIssuesByRepo:  make(map[string]int),
+		NumGenreIssues:         zeroCounts(LongTermStats.GenreClasses),
+		NumEscalationIssues:    zeroCounts(LongTermStats.GenreClasses),
+		NumUrgencyIssues:       zeroCounts(LongTermStats.UrgencyClasses),
+		NumScores:              make(map[string]int, len(LongTermStats.ScoreLabels)),
+	}
+	for _, label := range LongTermStats.ScoreLabels {
+		LongTermStats.SummaryStats[startEnd].NumScores[label] = 0
+	}
+	for _, issue := range cri {
+		LongTermStats.SummaryStats[startEnd].NumCreatedIssuesByRepo[issue.Repository]++
+	}
+	// comments are fetched at once rather than one by one in the loop below
+	comments, err := us.issueComments(ctx, cli)
+	if err != nil {
+		return nil, err
 	}
-	for _, issue := range cli {
+	var firstResponses []int
+	var totalWeight float64
+	resolutionTimes := newDurationSamples()
+	firstResponseTimes := newDurationSamples()
+	for i, issue := range cli {
+		LongTermStats.SummaryStats[startEnd].NumClosedIssuesByRepo[issue.Repository]++
+		lc := us.tx().ClassifyIssue(issue)
 		LongTermStats.DetailStats[cnt] = &DetailStats{
 			Escalation: false,
 		}
-		if labelContains(issue.Labels, "genre:通常問合せ") {
-			LongTermStats.SummaryStats[startEnd].NumGenreNormalIssues++
-		}
-		if labelContains(issue.Labels, "genre:要望") {
-			LongTermStats.SummaryStats[startEnd].NumGenreRequestIssues++
+		if lc.GenreClass != "" {
+			LongTermStats.SummaryStats[startEnd].NumGenreIssues[lc.GenreClass]++
 		}
-		if labelContains(issue.Labels, "genre:サービス障害") {
-			LongTermStats.SummaryStats[startEnd].NumGenreFailureIssues++
-		}
-		if labelContains(issue.Labels, "Escalation") {
+		if lc.Escalation {
 			LongTermStats.SummaryStats[startEnd].NumEscalationAllIssues++
-			if labelContains(issue.Labels, "genre:通常問合せ") {
-				LongTermStats.SummaryStats[startEnd].NumEscalationNormalIssues++
-			}
-			if labelContains(issue.Labels, "genre:要望") {
-				LongTermStats.SummaryStats[startEnd].NumEscalationRequestIssues++
+			if lc.GenreClass != "" {
+				LongTermStats.SummaryStats[startEnd].NumEscalationIssues[lc.GenreClass]++
 			}
-			if labelContains(issue.Labels, "genre:サービス障害") {
-				LongTermStats.SummaryStats[startEnd].NumEscalationFailureIssues++
-			}
-		}
-		if labelContains(issue.Labels, "緊急度：高") || labelContains(issue.Labels, "緊急度：中") {
-			LongTermStats.SummaryStats[startEnd].NumUrgencyHighIssues++
 		}
-		if labelContains(issue.Labels, "緊急度：低") {
-			LongTermStats.SummaryStats[startEnd].NumUrgencyLowIssues++
+		if lc.UrgencyClass != "" {
+			LongTermStats.SummaryStats[startEnd].NumUrgencyIssues[lc.UrgencyClass]++
 		}
 
-		var totalTime int
-		if issue.State != nil && *issue.State == "closed" {
-			totalTime = int(issue.ClosedAt.Sub(*issue.CreatedAt).Hours())
+		totalTime := us.openDuration(issue)
+		score := scoring.Score(lc, totalTime, us.dayHours())
+		LongTermStats.SummaryStats[startEnd].NumScores[score.Label]++
+		totalWeight += score.Weight
+		resolutionTimes.add(lc, totalTime)
+
+		LongTermStats.DetailStats[cnt].writeDetailStats(issue, lc, startEnd, totalTime, us.location())
+		if first := us.firstResponse(issue, comments[i]); first != nil {
+			LongTermStats.DetailStats[cnt].writeFirstResponse(us.duration(issue.CreatedAt, first.CreatedAt))
+		}
+		if LongTermStats.DetailStats[cnt].Responded {
+			firstResponses = append(firstResponses, LongTermStats.DetailStats[cnt].FirstResponse)
+			firstResponseTimes.add(lc, LongTermStats.DetailStats[cnt].FirstResponse)
 		} else {
-			totalTime = int(issue.UpdatedAt.Sub(*issue.CreatedAt).Hours())
-		}
-		switch {
-		case totalTime <= 2*24:
-			LongTermStats.SummaryStats[startEnd].NumScoreA++
-		case totalTime <= 5*24:
-			LongTermStats.SummaryStats[startEnd].NumScoreB++
-		case totalTime <= 10*24:
-			LongTermStats.SummaryStats[startEnd].NumScoreC++
-		case totalTime <= 20*24:
-			LongTermStats.SummaryStats[startEnd].NumScoreD++
-		case totalTime <= 30*24:
-			LongTermStats.SummaryStats[startEnd].NumScoreE++
-		default:
-			LongTermStats.SummaryStats[startEnd].NumScoreF++
-		}
-
-		LongTermStats.DetailStats[cnt].writeDetailStats(issue, startEnd)
+			LongTermStats.SummaryStats[startEnd].NumNoResponseIssues++
+		}
 		cnt++
 	}
+	LongTermStats.SummaryStats[startEnd].FirstResponseMedian = percentile(firstResponses, 50)
+	LongTermStats.SummaryStats[startEnd].FirstResponseP90 = percentile(firstResponses, 90)
+	LongTermStats.SummaryStats[startEnd].ResolutionTime = resolutionTimes.stats()
+	LongTermStats.SummaryStats[startEnd].FirstResponseTime = firstResponseTimes.stats()
 	LongTermStats.SummaryStats[startEnd].NumClosedIssues = len(cli)
 	if LongTermStats.SummaryStats[startEnd].NumClosedIssues == 0 {
 		LongTermStats.SummaryStats[startEnd].NumTotalScore = 0
 	} else {
-		tmpTotal := (LongTermStats.SummaryStats[startEnd].NumScoreA * 1) + (LongTermStats.SummaryStats[startEnd].NumScoreB * 2) + (LongTermStats.SummaryStats[startEnd].NumScoreC * 3) + (LongTermStats.SummaryStats[startEnd].NumScoreD * 4) + (LongTermStats.SummaryStats[startEnd].NumScoreE * 5) + (LongTermStats.SummaryStats[startEnd].NumScoreF * 6)
-		LongTermStats.SummaryStats[startEnd].NumTotalScore = float64(tmpTotal) / float64(LongTermStats.SummaryStats[startEnd].NumClosedIssues)
+		LongTermStats.SummaryStats[startEnd].NumTotalScore = totalWeight / float64(LongTermStats.SummaryStats[startEnd].NumClosedIssues)
 	}
 
 	return LongTermStats, nil
@@ -285,22 +430,17 @@ func (lts *LongTermStats) GenLongTermReport() string {
 	var Span []string
 	var NumCreatedIssues []string
 	var NumClosedIssues []string
-	var NumGenreRequestIssues []string
-	var NumGenreNormalIssues []string
-	var NumGenreFailureIssues []string
-	var NumUrgencyHighIssues []string
-	var NumUrgencyLowIssues []string
 	var NumEscalationAllIssues []string
 	var NumTeamAResolveAllPercentage []string
-	var NumEscalationNormalIssues []string
-	var NumTeamAResolveNormalPercentage []string
-	var NumScoreA []string
-	var NumScoreB []string
-	var NumScoreC []string
-	var NumScoreD []string
-	var NumScoreE []string
-	var NumScoreF []string
+	scoreLabels := lts.ScoreLabels
+	if len(scoreLabels) == 0 {
+		scoreLabels = DefaultScoring().Labels()
+	}
+	NumScores := make(map[string][]string, len(scoreLabels))
 	var NumTotalScore []string
+	var FirstResponseMedian []string
+	var FirstResponseP90 []string
+	var NumNoResponseIssues []string
 
 	type kvSummary struct {
 		Key string
@@ -340,40 +480,16 @@ func (lts *LongTermStats) GenLongTermReport() string {
 		Span = append(Span, d.Val.Span)
 		NumCreatedIssues = append(NumCreatedIssues, strconv.Itoa(d.Val.NumCreatedIssues))
 		NumClosedIssues = append(NumClosedIssues, strconv.Itoa(d.Val.NumClosedIssues))
-		NumGenreRequestIssues = append(NumGenreRequestIssues, strconv.Itoa(d.Val.NumGenreRequestIssues))
-		NumGenreNormalIssues = append(NumGenreNormalIssues, strconv.Itoa(d.Val.NumGenreNormalIssues))
-		NumGenreFailureIssues = append(NumGenreFailureIssues, strconv.Itoa(d.Val.NumGenreFailureIssues))
-		NumUrgencyHighIssues = append(NumUrgencyHighIssues, strconv.Itoa(d.Val.NumUrgencyHighIssues))
-		NumUrgencyLowIssues = append(NumUrgencyLowIssues, strconv.Itoa(d.Val.NumUrgencyLowIssues))
 		NumEscalationAllIssues = append(NumEscalationAllIssues, strconv.Itoa(d.Val.NumEscalationAllIssues))
-		if d.Val.NumEscalationAllIssues != 0 {
-			if d.Val.NumClosedIssues != 0 {
-				NumTeamAResolveAllPercentage = append(NumTeamAResolveAllPercentage, fmt.Sprintf("%.1f", (float64(d.Val.NumEscalationAllIssues)/float64(d.Val.NumClosedIssues)*100)))
-			} else {
-				NumTeamAResolveAllPercentage = append(NumTeamAResolveAllPercentage, "0")
-			}
-		} else {
-			NumTeamAResolveAllPercentage = append(NumTeamAResolveAllPercentage, "0")
-		}
+		NumTeamAResolveAllPercentage = append(NumTeamAResolveAllPercentage, escalationPercentage(d.Val.NumEscalationAllIssues, d.Val.NumClosedIssues))
 
-		NumEscalationNormalIssues = append(NumEscalationNormalIssues, strconv.Itoa(d.Val.NumEscalationNormalIssues))
-		if d.Val.NumEscalationNormalIssues != 0 {
-			if d.Val.NumClosedIssues != 0 {
-				NumTeamAResolveNormalPercentage = append(NumTeamAResolveNormalPercentage, fmt.Sprintf("%.1f", (float64(d.Val.NumEscalationNormalIssues)/float64(d.Val.NumClosedIssues)*100)))
-			} else {
-				NumTeamAResolveNormalPercentage = append(NumTeamAResolveNormalPercentage, "0")
-			}
-		} else {
-			NumTeamAResolveNormalPercentage = append(NumTeamAResolveNormalPercentage, "0")
+		for _, label := range scoreLabels {
+			NumScores[label] = append(NumScores[label], strconv.Itoa(d.Val.NumScores[label]))
 		}
-
-		NumScoreA = append(NumScoreA, strconv.Itoa(d.Val.NumScoreA))
-		NumScoreB = append(NumScoreB, strconv.Itoa(d.Val.NumScoreB))
-		NumScoreC = append(NumScoreC, strconv.Itoa(d.Val.NumScoreC))
-		NumScoreD = append(NumScoreD, strconv.Itoa(d.Val.NumScoreD))
-		NumScoreE = append(NumScoreE, strconv.Itoa(d.Val.NumScoreE))
-		NumScoreF = append(NumScoreF, strconv.Itoa(d.Val.NumScoreF))
 		NumTotalScore = append(NumTotalScore, strconv.FormatFloat(float64(d.Val.NumTotalScore), 'f', 2, 64))
+		FirstResponseMedian = append(FirstResponseMedian, strconv.Itoa(d.Val.FirstResponseMedian))
+		FirstResponseP90 = append(FirstResponseP90, strconv.Itoa(d.Val.FirstResponseP90))
+		NumNoResponseIssues = append(NumNoResponseIssues, strconv.Itoa(d.Val.NumNoResponseIssues))
 	}
 	sb.WriteString(fmt.Sprintf("## サマリー \n"))
 	sb.WriteString(fmt.Sprintf("|項目|"))
@@ -385,24 +501,69 @@ func (lts *LongTermStats) GenLongTermReport() string {
 	sb.WriteString(fmt.Sprintf("\n"))
 	sb.WriteString(fmt.Sprintf("|起票件数|%s|\n", strings.Join(NumCreatedIssues, "|")))
 	sb.WriteString(fmt.Sprintf("|クローズ件数|%s|\n", strings.Join(NumClosedIssues, "|")))
-	sb.WriteString(fmt.Sprintf("|緊急度：高・中|%s|\n", strings.Join(NumUrgencyHighIssues, "|")))
-	sb.WriteString(fmt.Sprintf("|緊急度：低|%s|\n", strings.Join(NumUrgencyLowIssues, "|")))
+	if repos := lts.repositories(); len(repos) > 1 {
+		for _, repo := range repos {
+			var created, closed []string
+			for _, d := range kvArrForSummary {
+				created = append(created, strconv.Itoa(d.Val.NumCreatedIssuesByRepo[repo]))
+				closed = append(closed, strconv.Itoa(d.Val.NumClosedIssuesByRepo[repo]))
+			}
+			sb.WriteString(fmt.Sprintf("|起票件数(%s)|%s|\n", repo, strings.Join(created, "|")))
+			sb.WriteString(fmt.Sprintf("|クローズ件数(%s)|%s|\n", repo, strings.Join(closed, "|")))
+		}
+	}
+	summaries := make([]*SummaryStats, 0, len(kvArrForSummary))
+	for _, d := range kvArrForSummary {
+		summaries = append(summaries, d.Val)
+	}
+	// counts of classes are written in rows headed by display names of the taxonomy
+	classRow := func(heading string, value func(ss *SummaryStats) string) {
+		values := make([]string, 0, len(summaries))
+		for _, ss := range summaries {
+			values = append(values, value(ss))
+		}
+		sb.WriteString(fmt.Sprintf("|%s|%s|\n", escapeMarkdownCell(heading), strings.Join(values, "|")))
+	}
+	genres, urgencies, teams := lts.classes()
+	// the first team resolves issues which are not escalated
+	team := ""
+	if len(teams) > 0 {
+		team = teams[0].Display
+	}
+	var urgencyCounts, genreCounts []map[string]int
+	for _, ss := range summaries {
+		urgencyCounts = append(urgencyCounts, ss.NumUrgencyIssues)
+		genreCounts = append(genreCounts, ss.NumGenreIssues, ss.NumEscalationIssues)
+	}
+	for _, c := range countedClasses(urgencies, urgencyCounts...) {
+		class := c.Class
+		classRow("緊急度："+c.Display, func(ss *SummaryStats) string { return strconv.Itoa(ss.NumUrgencyIssues[class]) })
+	}
 	sb.WriteString(fmt.Sprintf("|全体エスカレーション件数|%s|\n", strings.Join(NumEscalationAllIssues, "|")))
-	sb.WriteString(fmt.Sprintf("|全体CaaS-A完結率(％)|%s|\n", strings.Join(NumTeamAResolveAllPercentage, "|")))
-	sb.WriteString(fmt.Sprintf("|通常エスカレーション件数|%s|\n", strings.Join(NumEscalationNormalIssues, "|")))
-	sb.WriteString(fmt.Sprintf("|通常CaaS-A完結率(％)|%s|\n", strings.Join(NumTeamAResolveNormalPercentage, "|")))
-	sb.WriteString(fmt.Sprintf("|ジャンル:通常問合せ件数|%s|\n", strings.Join(NumGenreNormalIssues, "|")))
-	sb.WriteString(fmt.Sprintf("|ジャンル:要望件数|%s|\n", strings.Join(NumGenreRequestIssues, "|")))
-	sb.WriteString(fmt.Sprintf("|ジャンル:サービス障害件数|%s|\n", strings.Join(NumGenreFailureIssues, "|")))
+	sb.WriteString(fmt.Sprintf("|全体%s完結率(％)|%s|\n", escapeMarkdownCell(team), strings.Join(NumTeamAResolveAllPercentage, "|")))
+	genreClasses := countedClasses(genres, genreCounts...)
+	for _, c := range genreClasses {
+		class := c.Class
+		classRow(c.Display+"エスカレーション件数", func(ss *SummaryStats) string { return strconv.Itoa(ss.NumEscalationIssues[class]) })
+		classRow(c.Display+team+"完結率(％)", func(ss *SummaryStats) string {
+			return escalationPercentage(ss.NumEscalationIssues[class], ss.NumClosedIssues)
+		})
+	}
+	for _, c := range genreClasses {
+		class := c.Class
+		classRow("ジャンル:"+c.Display+"件数", func(ss *SummaryStats) string { return strconv.Itoa(ss.NumGenreIssues[class]) })
+	}
+	sb.WriteString(fmt.Sprintf("|初回応答時間 中央値(分)|%s|\n", strings.Join(FirstResponseMedian, "|")))
+	sb.WriteString(fmt.Sprintf("|初回応答時間 p90(分)|%s|\n", strings.Join(FirstResponseP90, "|")))
+	sb.WriteString(fmt.Sprintf("|未応答件数|%s|\n", strings.Join(NumNoResponseIssues, "|")))
 	sb.WriteString(fmt.Sprintf("|合計スコア|%s|\n", strings.Join(NumTotalScore, "|")))
-	sb.WriteString(fmt.Sprintf("|スコアA|%s|\n", strings.Join(NumScoreA, "|")))
-	sb.WriteString(fmt.Sprintf("|スコアB|%s|\n", strings.Join(NumScoreB, "|")))
-	sb.WriteString(fmt.Sprintf("|スコアC|%s|\n", strings.Join(NumScoreC, "|")))
-	sb.WriteString(fmt.Sprintf("|スコアD|%s|\n", strings.Join(NumScoreD, "|")))
-	sb.WriteString(fmt.Sprintf("|スコアE|%s|\n", strings.Join(NumScoreE, "|")))
-	sb.WriteString(fmt.Sprintf("|スコアF|%s|\n", strings.Join(NumScoreF, "|")))
+	for _, label := range scoreLabels {
+		sb.WriteString(fmt.Sprintf("|スコア%s|%s|\n", escapeMarkdownCell(label), strings.Join(NumScores[label], "|")))
+	}
 	sb.WriteString(fmt.Sprintf("\n"))
 
+	writeDistributions(&sb, summaries, genres, urgencies)
+
 	sb.WriteString(fmt.Sprintf("## 詳細 \n"))
 
 	for i := 0; i < len(kvArrForDetail); i++ {
@@ -412,7 +573,43 @@ func (lts *LongTermStats) GenLongTermReport() string {
 	return sb.String()
 }
 
-func (us *userSupport) GetAnalysisReportStats(since, until time.Time, state string) (*AnalysisStats, error) {
+// classes returns genre, urgency and team classes of the report
+// classes of the default taxonomy are used for stats which have none, such as ones made before classes were recorded
+func (lts *LongTermStats) classes() (genres, urgencies, teams []*ClassName) {
+	if len(lts.GenreClasses) == 0 && len(lts.UrgencyClasses) == 0 && len(lts.TeamClasses) == 0 {
+		tx := DefaultTaxonomy()
+		return tx.Classes(DimensionGenre), tx.Classes(DimensionUrgency), tx.Classes(DimensionTeam)
+	}
+	return lts.GenreClasses, lts.UrgencyClasses, lts.TeamClasses
+}
+
+// escalationPercentage formats percentage of escalated issues in closed issues
+func escalationPercentage(escalated, closed int) string {
+	if escalated == 0 || closed == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%.1f", float64(escalated)/float64(closed)*100)
+}
+
+// repositories returns sorted names of repositories which appear in summaries
+func (lts *LongTermStats) repositories() []string {
+	seen := make(map[string]bool)
+	var repos []string
+	for _, ss := range lts.SummaryStats {
+		for _, m := range []map[string]int{ss.NumCreatedIssuesByRepo, ss.NumClosedIssuesByRepo} {
+			for repo := range m {
+				if !seen[repo] {
+					seen[repo] = true
+					repos = append(repos, repo)
+				}
+			}
+		}
+	}
+	sort.Strings(repos)
+	return repos
+}
+
+func (us *userSupport) GetAnalysisReportStats(ctx context.Context, since, until time.Time, state string) (*AnalysisStats, error) {
 
 	AnalysisStats := &AnalysisStats{
 		DetailStats: make(map[int]*DetailStats),
@@ -420,24 +617,24 @@ func (us *userSupport) GetAnalysisReportStats(since, until time.Time, state stri
 
 	cnt := 0
 	startEnd := fmt.Sprintf("%s~%s", since.Format("2006-01-02"), until.Format("2006-01-02"))
-	var iss []*github.Issue
+	var iss []*Issue
 	var err error
 	if state == "created" {
-		iss, err = us.repo.GetCreatedSupportIssues(since, until)
+		iss, err = us.repo.GetCreatedSupportIssues(ctx, since, until)
 		if err != nil {
-			return nil, fmt.Errorf("get created issue : %s", err)
+			return nil, fmt.Errorf("get created issue : %w", err)
 		}
 	} else {
-		iss, err = us.repo.GetClosedSupportIssues(since, until)
+		iss, err = us.repo.GetClosedSupportIssues(ctx, since, until)
 		if err != nil {
-			return nil, fmt.Errorf("get closed issue : %s", err)
+			return nil, fmt.Errorf("get closed issue : %w", err)
 		}
 	}
 	for _, issue := range iss {
 		AnalysisStats.DetailStats[cnt] = &DetailStats{
 			Escalation: false,
 		}
-		AnalysisStats.DetailStats[cnt].writeDetailStats(issue, startEnd)
+		AnalysisStats.DetailStats[cnt].writeDetailStats(issue, us.tx().ClassifyIssue(issue), startEnd, us.openDuration(issue), us.location())
 		cnt++
 	}
 
@@ -446,37 +643,41 @@ func (us *userSupport) GetAnalysisReportStats(since, until time.Time, state stri
 
 // GenReport generate analysis report
 func (as *AnalysisStats) GenAnalysisReport() string {
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("期間,Title,起票日,クローズ日,ステータス,担当チーム,担当アサイン,緊急度,問い合わせ種別,エスカレ有無,コメント数,経過時間,Keywordラベル,URL\n"))
-
-	type kvDetail struct {
-		Key int
-		Val *DetailStats
+	records := [][]string{
+		{"期間", "リポジトリ", "Title", "起票日", "クローズ日", "ステータス", "担当チーム", "担当アサイン", "緊急度", "問い合わせ種別", "エスカレ有無", "コメント数", "経過時間", "Keywordラベル", "URL"},
 	}
-
-	var kvArrForDetail []kvDetail
-	for k, v := range as.DetailStats {
-		kvArrForDetail = append(kvArrForDetail, kvDetail{k, v})
-	}
-	sort.Slice(kvArrForDetail, func(i, j int) bool {
-		return kvArrForDetail[i].Val.CreatedAt < kvArrForDetail[j].Val.CreatedAt
+	details := as.DetailStats.List()
+	sort.SliceStable(details, func(i, j int) bool {
+		return details[i].CreatedAt < details[j].CreatedAt
 	})
-
-	for _, d := range kvArrForDetail {
-		sb.WriteString(fmt.Sprintf("%s,%s,%s,%s,%s,%s,%s,%s,%s,%t,%d,%d,%s,%s \n", d.Val.TargetSpan, d.Val.Title, d.Val.CreatedAt, d.Val.ClosedAt, d.Val.State, d.Val.TeamName, d.Val.Assignee, d.Val.Urgency, d.Val.Genre, d.Val.Escalation, d.Val.NumComments, d.Val.OpenDuration, d.Val.Labels, d.Val.HTMLURL))
+	for _, d := range details {
+		records = append(records, []string{d.TargetSpan, d.Repository, d.Title, d.CreatedAt, d.ClosedAt, d.State, d.TeamName, d.Assignee, d.Urgency, d.Genre, strconv.FormatBool(d.Escalation), strconv.Itoa(d.NumComments), strconv.Itoa(d.OpenDuration), d.Labels, d.HTMLURL})
 	}
-	return sb.String()
+	return genCSV(records)
 }
 
-func (us *userSupport) GetKeywordReportStats(since, until time.Time) (*KeywordStats, error) {
-	keywords, _ := us.repo.GetLabelsByQuery("keyword:")
+func (us *userSupport) GetKeywordReportStats(ctx context.Context, since, until time.Time) (*KeywordStats, error) {
+	var keywords []*Label
+	seen := make(map[string]bool)
+	for _, query := range us.tx().KeywordQueries() {
+		labels, err := us.repo.GetLabelsByQuery(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("get keyword labels : %w", err)
+		}
+		for _, label := range labels {
+			if us.tx().IsKeyword(label.Name) && !seen[label.Name] {
+				seen[label.Name] = true
+				keywords = append(keywords, label)
+			}
+		}
+	}
 	KeywordStats := &KeywordStats{
 		KeywordSummary: make(map[string]*KeywordSummary),
 	}
 	startEnd := fmt.Sprintf("%s~%s", since.Format("2006-01-02"), until.Format("2006-01-02"))
-	cli, err := us.repo.GetClosedSupportIssues(since, until)
+	cli, err := us.repo.GetClosedSupportIssues(ctx, since, until)
 	if err != nil {
-		return nil, fmt.Errorf("get closed issues : %s", err)
+		return nil, fmt.Errorf("get closed issues : %w", err)
 	}
 
 	KeywordStats.KeywordSummary[startEnd] = &KeywordSummary{
@@ -485,19 +686,19 @@ func (us *userSupport) GetKeywordReportStats(since, until time.Time) (*KeywordSt
 		KeywordCountAsEscalation: make(map[string]int, len(keywords)),
 	}
 	for _, label := range keywords {
-		if _, ok := KeywordStats.KeywordSummary[startEnd].KeywordCountAsAll[*label.Name]; !ok {
-			KeywordStats.KeywordSummary[startEnd].KeywordCountAsAll[*label.Name] = 0
-			KeywordStats.KeywordSummary[startEnd].KeywordCountAsEscalation[*label.Name] = 0
+		if _, ok := KeywordStats.KeywordSummary[startEnd].KeywordCountAsAll[label.Name]; !ok {
+			KeywordStats.KeywordSummary[startEnd].KeywordCountAsAll[label.Name] = 0
+			KeywordStats.KeywordSummary[startEnd].KeywordCountAsEscalation[label.Name] = 0
 		}
 
 		for _, issue := range cli {
-			if labelContains(issue.Labels, *label.Name) {
-				if val, ok := KeywordStats.KeywordSummary[startEnd].KeywordCountAsAll[*label.Name]; ok {
-					KeywordStats.KeywordSummary[startEnd].KeywordCountAsAll[*label.Name] = val + 1
+			if labelContains(issue.Labels, label.Name) {
+				if val, ok := KeywordStats.KeywordSummary[startEnd].KeywordCountAsAll[label.Name]; ok {
+					KeywordStats.KeywordSummary[startEnd].KeywordCountAsAll[label.Name] = val + 1
 				}
-				if labelContains(issue.Labels, "Escalation") {
-					if val, ok := KeywordStats.KeywordSummary[startEnd].KeywordCountAsEscalation[*label.Name]; ok {
-						KeywordStats.KeywordSummary[startEnd].KeywordCountAsEscalation[*label.Name] = val + 1
+				if us.tx().ClassifyIssue(issue).Escalation {
+					if val, ok := KeywordStats.KeywordSummary[startEnd].KeywordCountAsEscalation[label.Name]; ok {
+						KeywordStats.KeywordSummary[startEnd].KeywordCountAsEscalation[label.Name] = val + 1
 					}
 				}
 			}
@@ -613,11 +814,11 @@ func (ks *KeywordStats) GenKeywordReport() string {
 
 }
 
-func (us *userSupport) MethodTest(since, until time.Time) (*AnalysisStats, error) {
+func (us *userSupport) MethodTest(ctx context.Context, since, until time.Time) (*AnalysisStats, error) {
 	startEnd := fmt.Sprintf("%s~%s", since.Format("2006-01-02"), until.Format("2006-01-02"))
-	cli, err := us.repo.GetUpdatedSupportIssues(since, until)
+	cli, err := us.repo.GetUpdatedSupportIssues(ctx, since, until)
 	if err != nil {
-		return nil, fmt.Errorf("get updated issues : %s", err)
+		return nil, fmt.Errorf("get updated issues : %w", err)
 	}
 	AnalysisStats := &AnalysisStats{
 		DetailStats: make(map[int]*DetailStats, len(cli)),
@@ -626,68 +827,59 @@ func (us *userSupport) MethodTest(since, until time.Time) (*AnalysisStats, error
 		AnalysisStats.DetailStats[i] = &DetailStats{
 			Escalation: false,
 		}
-		AnalysisStats.DetailStats[i].writeDetailStats(issue, startEnd)
+		AnalysisStats.DetailStats[i].writeDetailStats(issue, us.tx().ClassifyIssue(issue), startEnd, us.openDuration(issue), us.location())
 	}
 	return AnalysisStats, nil
 }
 
-func (ds *DetailStats) writeDetailStats(issue *github.Issue, startEnd string) {
-	var labels []string
-	if issue.Labels != nil {
-		for _, label := range issue.Labels {
-			if strings.Contains(*label.Name, "keyword") {
-				labels = append(labels, strings.Replace(*label.Name, "keyword:", "", -1))
-			}
-			if strings.Contains(*label.Name, "緊急度") {
-				ds.Urgency = strings.Replace(*label.Name, "緊急度：", "", -1)
-			}
-			if strings.Contains(*label.Name, "CaaS-") {
-				ds.TeamName = strings.Replace(*label.Name, " 対応中", "", -1)
-			}
-			if strings.Contains(*label.Name, "Escalation") {
-				ds.Escalation = true
-			}
-			if strings.Contains(*label.Name, "genre") {
-				ds.Genre = strings.Replace(*label.Name, "genre:", "", -1)
-			}
-		}
-	}
+// writeDetailStats writes detail of the issue, openDuration is hours measured by userSupport
+// dates are formatted in loc
+func (ds *DetailStats) writeDetailStats(issue *Issue, lc *LabelClass, startEnd string, openDuration int, loc *time.Location) {
+	ds.Urgency = lc.Urgency
+	ds.TeamName = lc.Team
+	ds.Genre = lc.Genre
+	ds.Escalation = lc.Escalation
 	var assigns []string
-	if issue.Assignees != nil {
-		for _, assign := range issue.Assignees {
-			assigns = append(assigns, "@"+*assign.Login)
+	for _, assign := range issue.Assignees {
+		if assign.Login != "" {
+			assigns = append(assigns, "@"+assign.Login)
 		}
 	}
 
-	var totalTime int
-	if issue.State != nil && *issue.State == "closed" {
-		totalTime = int(issue.ClosedAt.Sub(*issue.CreatedAt).Hours())
-		ds.ClosedAt = issue.ClosedAt.In(jp).Format("2006-01-02")
-	} else {
-		totalTime = int(issue.UpdatedAt.Sub(*issue.CreatedAt).Hours())
+	if issue.IsClosed() {
+		ds.ClosedAt = formatDate(issue.ClosedAt, loc)
 	}
 
-	titleMatches := titlePattern.FindStringSubmatch(*issue.Title)
+	titleMatches := titlePattern.FindStringSubmatch(issue.Title)
 	if len(titleMatches) == 2 {
 		ds.ServiceID = "INC" + titleMatches[1]
 	}
 
+	ds.Repository = issue.Repository
 	ds.Assignee = strings.Join(assigns, " ")
-	ds.Title = *issue.Title
-	ds.HTMLURL = *issue.HTMLURL
-	ds.NumComments = *issue.Comments
-	ds.State = *issue.State
-	ds.CreatedAt = issue.CreatedAt.In(jp).Format("2006-01-02")
-	ds.OpenDuration = totalTime
+	ds.Title = issue.Title
+	ds.HTMLURL = issue.HTMLURL
+	ds.NumComments = issue.NumComments
+	ds.State = issue.State
+	ds.CreatedAt = formatDate(issue.CreatedAt, loc)
+	ds.OpenDuration = openDuration
 	ds.Assignee = strings.Join(assigns, " ")
-	ds.Labels = strings.Join(labels, " ")
+	ds.Labels = strings.Join(lc.Keywords, " ")
 	ds.TargetSpan = startEnd
 }
 
-//配列の中に特定の文字列が含まれるかを返す
-func labelContains(arr []github.Label, str string) bool {
+// formatDate formats the time as a date in loc, it is empty when the time is missing
+func formatDate(t time.Time, loc *time.Location) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(loc).Format("2006-01-02")
+}
+
+// 配列の中に特定の文字列が含まれるかを返す
+func labelContains(arr []Label, str string) bool {
 	for _, v := range arr {
-		if *v.Name == str {
+		if v.Name == str {
 			return true
 		}
 	}
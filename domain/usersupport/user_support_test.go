@@ -4,6 +4,7 @@
 package usersupport
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"strings"
@@ -11,7 +12,6 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
-	"github.com/google/go-github/github"
 )
 
 var (
@@ -25,91 +25,95 @@ var (
 	firstDayOfMonth = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
 	lastDayOfMonth  = firstDayOfMonth.AddDate(0, +1, -1)
 	startEnd        = fmt.Sprintf("%s~%s", firstDayOfMonth.Format("2006-01-02"), lastDayOfMonth.Format("2006-01-02"))
-	issuePatterns   = []*github.Issue{
+	issuePatterns   = []*Issue{
 		{
-			ID:        github.Int64(1),
-			Title:     github.String("issue 1"),
-			CreatedAt: &tenDayAgo,
-			ClosedAt:  &threeDayAgo,
-			State:     github.String("closed"),
-			Body:      github.String("test 1"),
-			Comments:  github.Int(1),
-			Labels: []github.Label{
-				{Name: github.String("PF_Support")},
-				{Name: github.String("緊急度：低")},
-				{Name: github.String("CaaS-A 対応中")},
-				{Name: github.String("Escalation")},
-				{Name: github.String("genre:通常問合せ")},
-				{Name: github.String("keyword:Kubernetes")},
+			ID:          1,
+			Title:       "issue 1",
+			CreatedAt:   tenDayAgo,
+			ClosedAt:    threeDayAgo,
+			State:       "closed",
+			Body:        "test 1",
+			NumComments: 1,
+			Labels: []Label{
+				{Name: "PF_Support"},
+				{Name: "緊急度：低"},
+				{Name: "CaaS-A 対応中"},
+				{Name: "Escalation"},
+				{Name: "genre:通常問合せ"},
+				{Name: "keyword:Kubernetes"},
 			},
-			HTMLURL: github.String("https://github.com/sataga/issue-warehouse/issues/1"),
+			HTMLURL:    "https://github.com/sataga/issue-warehouse/issues/1",
+			Repository: "sataga/issue-warehouse",
 		},
 		{
-			ID:        github.Int64(2),
-			Title:     github.String("issue 2"),
-			CreatedAt: &sevenDayAgo,
-			ClosedAt:  &threeDayAgo,
-			State:     github.String("closed"),
-			Body: github.String(`
+			ID:        2,
+			Title:     "issue 2",
+			CreatedAt: sevenDayAgo,
+			ClosedAt:  threeDayAgo,
+			State:     "closed",
+			Body: `
 				test2
 				hogehoge
-			`),
-			Comments: github.Int(2),
-			Labels: []github.Label{
-				{Name: github.String("PF_Support")},
-				{Name: github.String("緊急度：中")},
-				{Name: github.String("CaaS-A 対応中")},
-				{Name: github.String("genre:要望")},
-				{Name: github.String("keyword:Openstack")},
+			`,
+			NumComments: 2,
+			Labels: []Label{
+				{Name: "PF_Support"},
+				{Name: "緊急度：中"},
+				{Name: "CaaS-A 対応中"},
+				{Name: "genre:要望"},
+				{Name: "keyword:Openstack"},
 			},
-			HTMLURL: github.String("https://github.com/sataga/issue-warehouse/issues/2"),
+			HTMLURL:    "https://github.com/sataga/issue-warehouse/issues/2",
+			Repository: "sataga/issue-warehouse",
 		},
 		{
-			ID:        github.Int64(3),
-			Title:     github.String("issue 3"),
-			CreatedAt: &fiveDayAgo,
-			UpdatedAt: &oneHourAgo,
-			State:     github.String("open"),
-			Body:      github.String("test 3"),
-			Comments:  github.Int(3),
-			Labels: []github.Label{
-				{Name: github.String("PF_Support")},
-				{Name: github.String("緊急度：高")},
-				{Name: github.String("CaaS-A 対応中")},
-				{Name: github.String("genre:サービス障害")},
-				{Name: github.String("keyword:Network")},
+			ID:          3,
+			Title:       "issue 3",
+			CreatedAt:   fiveDayAgo,
+			UpdatedAt:   oneHourAgo,
+			State:       "open",
+			Body:        "test 3",
+			NumComments: 3,
+			Labels: []Label{
+				{Name: "PF_Support"},
+				{Name: "緊急度：高"},
+				{Name: "CaaS-A 対応中"},
+				{Name: "genre:サービス障害"},
+				{Name: "keyword:Network"},
 			},
-			HTMLURL: github.String("https://github.com/sataga/issue-warehouse/issues/3"),
+			HTMLURL:    "https://github.com/sataga/issue-warehouse/issues/3",
+			Repository: "sataga/issue-warehouse",
 		},
 		{
-			ID:        github.Int64(4),
-			Title:     github.String("issue 4"),
-			CreatedAt: &threeDayAgo,
-			UpdatedAt: &oneHourAgo,
-			State:     github.String("open"),
-			Body:      github.String("test 4"),
-			Comments:  github.Int(4),
-			Labels: []github.Label{
-				{Name: github.String("PF_Support")},
-				{Name: github.String("緊急度：低")},
-				{Name: github.String("CaaS-B 対応中")},
-				{Name: github.String("genre:通常問合せ")},
-				{Name: github.String("keyword:Kubernetes")},
+			ID:          4,
+			Title:       "issue 4",
+			CreatedAt:   threeDayAgo,
+			UpdatedAt:   oneHourAgo,
+			State:       "open",
+			Body:        "test 4",
+			NumComments: 4,
+			Labels: []Label{
+				{Name: "PF_Support"},
+				{Name: "緊急度：低"},
+				{Name: "CaaS-B 対応中"},
+				{Name: "genre:通常問合せ"},
+				{Name: "keyword:Kubernetes"},
 			},
-			HTMLURL: github.String("https://github.com/sataga/issue-warehouse/issues/4"),
+			HTMLURL:    "https://github.com/sataga/issue-warehouse/issues/4",
+			Repository: "sataga/issue-warehouse",
 		},
 	}
-	keywordPatterns = []*github.LabelResult{
-		{Name: github.String("keyword:Network")},
-		{Name: github.String("keyword:Openstack")},
-		{Name: github.String("keyword:Kubernetes")},
+	keywordPatterns = []*Label{
+		{Name: "keyword:Network"},
+		{Name: "keyword:Openstack"},
+		{Name: "keyword:Kubernetes"},
 	}
 )
 
 func Test_userSupport_GetDailyReportStats(t *testing.T) {
 	var c *gomock.Controller
 
-	updatedIssues := []*github.Issue{
+	updatedIssues := []*Issue{
 		issuePatterns[2],
 		issuePatterns[3],
 	}
@@ -137,18 +141,17 @@ func Test_userSupport_GetDailyReportStats(t *testing.T) {
 			},
 			want: &DailyStats{
 				NumNotUpdatedIssues: 2,
-				NumTeamAResponse:    1,
-				NumTeamBResponse:    1,
-				NumTeamAHighIssues:  0,
-				NumTeamBLowIssues:   0,
-				UrgencyHighIssues:   1,
-				UrgencyLowIssues:    1,
-				dayAgo:              5,
+				NumUrgencyIssues:    map[string]int{ClassUrgencyHigh: 1, ClassUrgencyMedium: 0, ClassUrgencyLow: 1},
+				NumTeamIssues:       map[string]int{ClassTeamA: 1, ClassTeamB: 1},
+				UrgencyClasses:      DefaultTaxonomy().Classes(DimensionUrgency),
+				TeamClasses:         DefaultTaxonomy().Classes(DimensionTeam),
+				DayAgo:              5,
 				DetailStats: map[int]*DetailStats{
 					0: {
+						Repository:   "sataga/issue-warehouse",
 						Title:        "issue 3",
 						HTMLURL:      "https://github.com/sataga/issue-warehouse/issues/3",
-						CreatedAt:    fiveDayAgo.Format("2006-01-02"),
+						CreatedAt:    fiveDayAgo.In(loc).Format("2006-01-02"),
 						State:        "open",
 						TargetSpan:   fiveDayAgo.Format("2006-01-02"),
 						TeamName:     "CaaS-A",
@@ -160,9 +163,10 @@ func Test_userSupport_GetDailyReportStats(t *testing.T) {
 						Escalation:   false,
 					},
 					1: {
+						Repository:   "sataga/issue-warehouse",
 						Title:        "issue 4",
 						HTMLURL:      "https://github.com/sataga/issue-warehouse/issues/4",
-						CreatedAt:    threeDayAgo.Format("2006-01-02"),
+						CreatedAt:    threeDayAgo.In(loc).Format("2006-01-02"),
 						State:        "open",
 						TargetSpan:   fiveDayAgo.Format("2006-01-02"),
 						TeamName:     "CaaS-B",
@@ -179,7 +183,7 @@ func Test_userSupport_GetDailyReportStats(t *testing.T) {
 			beforefunc: func(f *fields) {
 				c = gomock.NewController(t)
 				musr := NewMockRepository(c)
-				musr.EXPECT().GetCurrentOpenNotUpdatedSupportIssues(gomock.Any()).Return(updatedIssues, nil)
+				musr.EXPECT().GetCurrentOpenNotUpdatedSupportIssues(gomock.Any(), gomock.Any()).Return(updatedIssues, nil)
 				f.repo = musr
 			},
 			afterFunc: func() {
@@ -198,7 +202,7 @@ func Test_userSupport_GetDailyReportStats(t *testing.T) {
 			us := &userSupport{
 				repo: tt.fields.repo,
 			}
-			got, err := us.GetDailyReportStats(now, tt.args.dayAgo)
+			got, err := us.GetDailyReportStats(context.Background(), now, tt.args.dayAgo)
 			// fmt.Printf("got: %+v %+v\n ", got.DetailStats[0], got.DetailStats[1])
 			// fmt.Printf("want: %+v %+v\n ", tt.want.DetailStats[0], tt.want.DetailStats[1])
 			if (err != nil) != tt.wantErr {
@@ -216,12 +220,10 @@ func TestDailyStats_GetDailyReportStats(t *testing.T) {
 	type fields struct {
 		dayAgo              int
 		NumNotUpdatedIssues int
-		NumTeamAResponse    int
-		NumTeamBResponse    int
-		NumTeamAHighIssues  int
-		NumTeamBLowIssues   int
-		UrgencyHighIssues   int
-		UrgencyLowIssues    int
+		NumUrgencyIssues    map[string]int
+		NumTeamIssues       map[string]int
+		UrgencyClasses      []*ClassName
+		TeamClasses         []*ClassName
 		DetailStats         map[int]*DetailStats
 	}
 	tests := []struct {
@@ -234,18 +236,17 @@ func TestDailyStats_GetDailyReportStats(t *testing.T) {
 			name: "print daily-report",
 			fields: fields{
 				NumNotUpdatedIssues: 2,
-				NumTeamAResponse:    1,
-				NumTeamBResponse:    1,
-				NumTeamAHighIssues:  0,
-				NumTeamBLowIssues:   0,
-				UrgencyHighIssues:   1,
-				UrgencyLowIssues:    1,
+				NumUrgencyIssues:    map[string]int{ClassUrgencyHigh: 1, ClassUrgencyMedium: 0, ClassUrgencyLow: 1},
+				NumTeamIssues:       map[string]int{ClassTeamA: 1, ClassTeamB: 1},
+				UrgencyClasses:      DefaultTaxonomy().Classes(DimensionUrgency),
+				TeamClasses:         DefaultTaxonomy().Classes(DimensionTeam),
 				dayAgo:              5,
 				DetailStats: map[int]*DetailStats{
 					0: {
+						Repository:   "sataga/issue-warehouse",
 						Title:        "issue 3",
 						HTMLURL:      "https://github.com/sataga/issue-warehouse/issues/3",
-						CreatedAt:    fiveDayAgo.Format("2006-01-02"),
+						CreatedAt:    fiveDayAgo.In(loc).Format("2006-01-02"),
 						State:        "open",
 						TargetSpan:   fiveDayAgo.Format("2006-01-02"),
 						TeamName:     "CaaS-A",
@@ -256,9 +257,10 @@ func TestDailyStats_GetDailyReportStats(t *testing.T) {
 						Escalation:   false,
 					},
 					1: {
+						Repository:   "sataga/issue-warehouse",
 						Title:        "issue 4",
 						HTMLURL:      "https://github.com/sataga/issue-warehouse/issues/4",
-						CreatedAt:    threeDayAgo.Format("2006-01-02"),
+						CreatedAt:    threeDayAgo.In(loc).Format("2006-01-02"),
 						State:        "open",
 						TargetSpan:   fiveDayAgo.Format("2006-01-02"),
 						TeamName:     "CaaS-B",
@@ -273,8 +275,11 @@ func TestDailyStats_GetDailyReportStats(t *testing.T) {
 			want: `■ *5日間* 以上更新がなかったチケット一覧
 === サマリー ===
 総未更新チケット数: 2 件
-    緊急度：高・中: 1 件
+    緊急度：高: 1 件
+    緊急度：中: 0 件
     緊急度：低: 1 件
+    担当チーム：CaaS-A: 1 件
+    担当チーム：CaaS-B: 1 件
 === 詳細 ===
 - <https://github.com/sataga/issue-warehouse/issues/3|issue 3> 経過時間:4d23h 緊急度：高 
 - <https://github.com/sataga/issue-warehouse/issues/4|issue 4> 経過時間:2d23h 緊急度：低 
@@ -284,14 +289,12 @@ func TestDailyStats_GetDailyReportStats(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ds := &DailyStats{
-				dayAgo:              tt.fields.dayAgo,
+				DayAgo:              tt.fields.dayAgo,
 				NumNotUpdatedIssues: tt.fields.NumNotUpdatedIssues,
-				NumTeamAResponse:    tt.fields.NumTeamAResponse,
-				NumTeamBResponse:    tt.fields.NumTeamBResponse,
-				NumTeamAHighIssues:  tt.fields.NumTeamAHighIssues,
-				NumTeamBLowIssues:   tt.fields.NumTeamBLowIssues,
-				UrgencyHighIssues:   tt.fields.UrgencyHighIssues,
-				UrgencyLowIssues:    tt.fields.UrgencyLowIssues,
+				NumUrgencyIssues:    tt.fields.NumUrgencyIssues,
+				NumTeamIssues:       tt.fields.NumTeamIssues,
+				UrgencyClasses:      tt.fields.UrgencyClasses,
+				TeamClasses:         tt.fields.TeamClasses,
 				DetailStats:         tt.fields.DetailStats,
 			}
 			// fmt.Printf("got: %+v\n ", ds.GetDailyReportStats())
@@ -306,11 +309,22 @@ func TestDailyStats_GetDailyReportStats(t *testing.T) {
 func Test_userSupport_GetLongTermReportStats(t *testing.T) {
 	var c *gomock.Controller
 
-	closeIssues := []*github.Issue{
+	closeIssues := []*Issue{
 		issuePatterns[0],
 		issuePatterns[1],
 	}
-	openIssues := []*github.Issue{
+	comments := map[int][]*Comment{
+		// reply by the author is not a response
+		0: {
+			{User: User{Login: "customer"}, CreatedAt: tenDayAgo.Add(10 * time.Minute)},
+			{User: User{Login: "supporter"}, CreatedAt: tenDayAgo.Add(30 * time.Minute)},
+		},
+		1: {
+			{User: User{Login: "developer"}, CreatedAt: sevenDayAgo.Add(60 * time.Minute)},
+			{User: User{Login: "Supporter"}, CreatedAt: sevenDayAgo.Add(120 * time.Minute)},
+		},
+	}
+	openIssues := []*Issue{
 		issuePatterns[2],
 		issuePatterns[3],
 	}
@@ -338,59 +352,86 @@ func Test_userSupport_GetLongTermReportStats(t *testing.T) {
 				until: lastDayOfMonth,
 			},
 			want: &LongTermStats{
+				ScoreLabels:    []string{"A", "B", "C", "D", "E", "F"},
+				GenreClasses:   DefaultTaxonomy().Classes(DimensionGenre),
+				UrgencyClasses: DefaultTaxonomy().Classes(DimensionUrgency),
+				TeamClasses:    DefaultTaxonomy().Classes(DimensionTeam),
 				SummaryStats: map[string]*SummaryStats{
 					startEnd: {
-						Span:                       startEnd,
-						NumCreatedIssues:           2,
-						NumClosedIssues:            2,
-						NumGenreNormalIssues:       1,
-						NumGenreRequestIssues:      1,
-						NumGenreFailureIssues:      0,
-						NumEscalationAllIssues:     1,
-						NumEscalationNormalIssues:  1,
-						NumEscalationRequestIssues: 0,
-						NumEscalationFailureIssues: 0,
-						NumUrgencyHighIssues:       1,
-						NumUrgencyLowIssues:        1,
-						NumScoreA:                  0,
-						NumScoreB:                  1,
-						NumScoreC:                  1,
-						NumScoreD:                  0,
-						NumScoreE:                  0,
-						NumScoreF:                  0,
-						NumTotalScore:              2.5,
+						Span:                   startEnd,
+						NumCreatedIssues:       2,
+						NumClosedIssues:        2,
+						NumCreatedIssuesByRepo: map[string]int{"sataga/issue-warehouse": 2},
+						NumClosedIssuesByRepo:  map[string]int{"sataga/issue-warehouse": 2},
+						NumEscalationAllIssues: 1,
+						NumGenreIssues:         map[string]int{ClassGenreNormal: 1, ClassGenreRequest: 1, ClassGenreFailure: 0},
+						NumEscalationIssues:    map[string]int{ClassGenreNormal: 1, ClassGenreRequest: 0, ClassGenreFailure: 0},
+						NumUrgencyIssues:       map[string]int{ClassUrgencyHigh: 0, ClassUrgencyMedium: 1, ClassUrgencyLow: 1},
+						NumScores:              map[string]int{"A": 0, "B": 1, "C": 1, "D": 0, "E": 0, "F": 0},
+						NumTotalScore:          2.5,
+						FirstResponseMedian:    30,
+						FirstResponseP90:       120,
+						NumNoResponseIssues:    0,
+						ResolutionTime: &DistributionStats{
+							All: &Distribution{Count: 2, P50: 96, P75: 168, P90: 168, P95: 168, Max: 168},
+							ByGenre: map[string]*Distribution{
+								ClassGenreNormal:  {Count: 1, P50: 168, P75: 168, P90: 168, P95: 168, Max: 168},
+								ClassGenreRequest: {Count: 1, P50: 96, P75: 96, P90: 96, P95: 96, Max: 96},
+							},
+							ByUrgency: map[string]*Distribution{
+								ClassUrgencyLow:    {Count: 1, P50: 168, P75: 168, P90: 168, P95: 168, Max: 168},
+								ClassUrgencyMedium: {Count: 1, P50: 96, P75: 96, P90: 96, P95: 96, Max: 96},
+							},
+						},
+						FirstResponseTime: &DistributionStats{
+							All: &Distribution{Count: 2, P50: 30, P75: 120, P90: 120, P95: 120, Max: 120},
+							ByGenre: map[string]*Distribution{
+								ClassGenreNormal:  {Count: 1, P50: 30, P75: 30, P90: 30, P95: 30, Max: 30},
+								ClassGenreRequest: {Count: 1, P50: 120, P75: 120, P90: 120, P95: 120, Max: 120},
+							},
+							ByUrgency: map[string]*Distribution{
+								ClassUrgencyLow:    {Count: 1, P50: 30, P75: 30, P90: 30, P95: 30, Max: 30},
+								ClassUrgencyMedium: {Count: 1, P50: 120, P75: 120, P90: 120, P95: 120, Max: 120},
+							},
+						},
 					},
 				},
 				DetailStats: map[int]*DetailStats{
 					0: {
-						Title:        "issue 1",
-						HTMLURL:      "https://github.com/sataga/issue-warehouse/issues/1",
-						CreatedAt:    tenDayAgo.Format("2006-01-02"),
-						ClosedAt:     threeDayAgo.Format("2006-01-02"),
-						State:        "closed",
-						TargetSpan:   startEnd,
-						TeamName:     "CaaS-A",
-						Urgency:      "低",
-						Genre:        "通常問合せ",
-						Labels:       "Kubernetes",
-						NumComments:  1,
-						OpenDuration: 168,
-						Escalation:   true,
+						Repository:    "sataga/issue-warehouse",
+						Title:         "issue 1",
+						HTMLURL:       "https://github.com/sataga/issue-warehouse/issues/1",
+						CreatedAt:     tenDayAgo.In(loc).Format("2006-01-02"),
+						ClosedAt:      threeDayAgo.In(loc).Format("2006-01-02"),
+						State:         "closed",
+						TargetSpan:    startEnd,
+						TeamName:      "CaaS-A",
+						Urgency:       "低",
+						Genre:         "通常問合せ",
+						Labels:        "Kubernetes",
+						NumComments:   1,
+						OpenDuration:  168,
+						Escalation:    true,
+						FirstResponse: 30,
+						Responded:     true,
 					},
 					1: {
-						Title:        "issue 2",
-						HTMLURL:      "https://github.com/sataga/issue-warehouse/issues/2",
-						CreatedAt:    sevenDayAgo.Format("2006-01-02"),
-						ClosedAt:     threeDayAgo.Format("2006-01-02"),
-						State:        "closed",
-						TargetSpan:   startEnd,
-						TeamName:     "CaaS-A",
-						Urgency:      "中",
-						Genre:        "要望",
-						Labels:       "Openstack",
-						NumComments:  2,
-						OpenDuration: 96,
-						Escalation:   false,
+						Repository:    "sataga/issue-warehouse",
+						Title:         "issue 2",
+						HTMLURL:       "https://github.com/sataga/issue-warehouse/issues/2",
+						CreatedAt:     sevenDayAgo.In(loc).Format("2006-01-02"),
+						ClosedAt:      threeDayAgo.In(loc).Format("2006-01-02"),
+						State:         "closed",
+						TargetSpan:    startEnd,
+						TeamName:      "CaaS-A",
+						Urgency:       "中",
+						Genre:         "要望",
+						Labels:        "Openstack",
+						NumComments:   2,
+						OpenDuration:  96,
+						Escalation:    false,
+						FirstResponse: 120,
+						Responded:     true,
 					},
 				},
 			},
@@ -398,8 +439,10 @@ func Test_userSupport_GetLongTermReportStats(t *testing.T) {
 			beforefunc: func(f *fields) {
 				c = gomock.NewController(t)
 				musr := NewMockRepository(c)
-				musr.EXPECT().GetCreatedSupportIssues(gomock.Any(), gomock.Any()).Return(openIssues, nil)
-				musr.EXPECT().GetClosedSupportIssues(gomock.Any(), gomock.Any()).Return(closeIssues, nil)
+				musr.EXPECT().GetCreatedSupportIssues(gomock.Any(), gomock.Any(), gomock.Any()).Return(openIssues, nil)
+				musr.EXPECT().GetClosedSupportIssues(gomock.Any(), gomock.Any(), gomock.Any()).Return(closeIssues, nil)
+				musr.EXPECT().GetIssueComments(gomock.Any(), closeIssues[0]).Return(comments[0], nil)
+				musr.EXPECT().GetIssueComments(gomock.Any(), closeIssues[1]).Return(comments[1], nil)
 				f.repo = musr
 			},
 			afterfunc: func() {
@@ -415,10 +458,8 @@ func Test_userSupport_GetLongTermReportStats(t *testing.T) {
 			if tt.afterfunc != nil {
 				defer tt.afterfunc()
 			}
-			us := &userSupport{
-				repo: tt.fields.repo,
-			}
-			got, err := us.GetLongTermReportStats(tt.args.since, tt.args.until)
+			us := NewUserSupport(tt.fields.repo, &Config{TeamMembers: []string{"supporter"}})
+			got, err := us.GetLongTermReportStats(context.Background(), tt.args.since, tt.args.until)
 			// for k, v := range tt.want.SummaryStats {
 			// 	fmt.Println(k)
 			// 	fmt.Println(v)
@@ -442,6 +483,35 @@ func Test_userSupport_GetLongTermReportStats(t *testing.T) {
 	}
 }
 
+func Test_userSupport_issueComments(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	musr := NewMockRepository(ctrl)
+	issues := []*Issue{
+		{Number: 1, NumComments: 1},
+		// issue without comments is not requested
+		{Number: 2, NumComments: 0},
+		{Number: 3, NumComments: 2},
+	}
+	want := [][]*Comment{
+		{{Body: "first"}},
+		nil,
+		{{Body: "second"}, {Body: "third"}},
+	}
+	musr.EXPECT().GetIssueComments(gomock.Any(), issues[0]).Return(want[0], nil)
+	musr.EXPECT().GetIssueComments(gomock.Any(), issues[2]).Return(want[2], nil)
+
+	us := NewUserSupport(musr, &Config{Workers: 2}).(*userSupport)
+	got, err := us.issueComments(context.Background(), issues)
+	if err != nil {
+		t.Fatalf("userSupport.issueComments() error = %v", err)
+	}
+	// comments are in order of issues whichever is fetched first
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("userSupport.issueComments() = %v, want %v", got, want)
+	}
+}
+
 func TestLongTermStats_GenLongTermReport(t *testing.T) {
 	type fields struct {
 		SummaryStats map[string]*SummaryStats
@@ -458,33 +528,27 @@ func TestLongTermStats_GenLongTermReport(t *testing.T) {
 			fields: fields{
 				SummaryStats: map[string]*SummaryStats{
 					startEnd: {
-						Span:                       startEnd,
-						NumCreatedIssues:           2,
-						NumClosedIssues:            2,
-						NumGenreNormalIssues:       1,
-						NumGenreRequestIssues:      1,
-						NumGenreFailureIssues:      0,
-						NumEscalationAllIssues:     1,
-						NumEscalationNormalIssues:  1,
-						NumEscalationRequestIssues: 0,
-						NumEscalationFailureIssues: 0,
-						NumUrgencyHighIssues:       1,
-						NumUrgencyLowIssues:        1,
-						NumScoreA:                  0,
-						NumScoreB:                  1,
-						NumScoreC:                  1,
-						NumScoreD:                  0,
-						NumScoreE:                  0,
-						NumScoreF:                  0,
-						NumTotalScore:              2.5,
+						Span:                   startEnd,
+						NumCreatedIssues:       2,
+						NumClosedIssues:        2,
+						NumEscalationAllIssues: 1,
+						NumGenreIssues:         map[string]int{ClassGenreNormal: 1, ClassGenreRequest: 1, ClassGenreFailure: 0},
+						NumEscalationIssues:    map[string]int{ClassGenreNormal: 1, ClassGenreRequest: 0, ClassGenreFailure: 0},
+						NumUrgencyIssues:       map[string]int{ClassUrgencyHigh: 0, ClassUrgencyMedium: 1, ClassUrgencyLow: 1},
+						NumScores:              map[string]int{"A": 0, "B": 1, "C": 1, "D": 0, "E": 0, "F": 0},
+						NumTotalScore:          2.5,
+						FirstResponseMedian:    30,
+						FirstResponseP90:       120,
+						NumNoResponseIssues:    0,
 					},
 				},
 				DetailStats: map[int]*DetailStats{
 					0: {
+						Repository:   "sataga/issue-warehouse",
 						Title:        "issue 1",
 						HTMLURL:      "https://github.com/sataga/issue-warehouse/issues/1",
-						CreatedAt:    tenDayAgo.Format("2006-01-02"),
-						ClosedAt:     threeDayAgo.Format("2006-01-02"),
+						CreatedAt:    tenDayAgo.In(loc).Format("2006-01-02"),
+						ClosedAt:     threeDayAgo.In(loc).Format("2006-01-02"),
 						State:        "closed",
 						TargetSpan:   startEnd,
 						TeamName:     "CaaS-A",
@@ -496,10 +560,11 @@ func TestLongTermStats_GenLongTermReport(t *testing.T) {
 						Escalation:   true,
 					},
 					1: {
+						Repository:   "sataga/issue-warehouse",
 						Title:        "issue 2",
 						HTMLURL:      "https://github.com/sataga/issue-warehouse/issues/2",
-						CreatedAt:    sevenDayAgo.Format("2006-01-02"),
-						ClosedAt:     threeDayAgo.Format("2006-01-02"),
+						CreatedAt:    sevenDayAgo.In(loc).Format("2006-01-02"),
+						ClosedAt:     threeDayAgo.In(loc).Format("2006-01-02"),
 						State:        "closed",
 						TargetSpan:   startEnd,
 						TeamName:     "CaaS-A",
@@ -517,15 +582,23 @@ func TestLongTermStats_GenLongTermReport(t *testing.T) {
 |----|----|
 |起票件数|2|
 |クローズ件数|2|
-|緊急度：高・中|1|
+|緊急度：高|0|
+|緊急度：中|1|
 |緊急度：低|1|
 |全体エスカレーション件数|1|
 |全体CaaS-A完結率(％)|50.0|
-|通常エスカレーション件数|1|
-|通常CaaS-A完結率(％)|50.0|
+|通常問合せエスカレーション件数|1|
+|通常問合せCaaS-A完結率(％)|50.0|
+|要望エスカレーション件数|0|
+|要望CaaS-A完結率(％)|0|
+|サービス障害エスカレーション件数|0|
+|サービス障害CaaS-A完結率(％)|0|
 |ジャンル:通常問合せ件数|1|
 |ジャンル:要望件数|1|
 |ジャンル:サービス障害件数|0|
+|初回応答時間 中央値(分)|30|
+|初回応答時間 p90(分)|120|
+|未応答件数|0|
 |合計スコア|2.50|
 |スコアA|0|
 |スコアB|1|
@@ -537,6 +610,75 @@ func TestLongTermStats_GenLongTermReport(t *testing.T) {
 ## 詳細 
 - [issue 1](https://github.com/sataga/issue-warehouse/issues/1),低,通常問合せ,CaaS-A/,comment数:1,経過時間(hour):168,解決フラグ:true,(startEnd)
 - [issue 2](https://github.com/sataga/issue-warehouse/issues/2),中,要望,CaaS-A/,comment数:2,経過時間(hour):96,解決フラグ:false,(startEnd)
+`,
+		},
+		{
+			name: "print monthly-report of multiple repositories",
+			fields: fields{
+				SummaryStats: map[string]*SummaryStats{
+					startEnd: {
+						Span:                   startEnd,
+						NumCreatedIssues:       3,
+						NumClosedIssues:        1,
+						NumCreatedIssuesByRepo: map[string]int{"sataga/product-b": 1, "sataga/product-a": 2},
+						NumClosedIssuesByRepo:  map[string]int{"sataga/product-b": 1},
+						NumUrgencyIssues:       map[string]int{ClassUrgencyLow: 1},
+						NumScores:              map[string]int{"A": 1},
+						NumTotalScore:          1,
+						NumNoResponseIssues:    1,
+					},
+				},
+				DetailStats: map[int]*DetailStats{
+					0: {
+						Repository:   "sataga/product-b",
+						Title:        "issue 1",
+						HTMLURL:      "https://github.com/sataga/product-b/issues/1",
+						State:        "closed",
+						TargetSpan:   startEnd,
+						TeamName:     "CaaS-A",
+						Urgency:      "低",
+						Genre:        "通常問合せ",
+						NumComments:  1,
+						OpenDuration: 24,
+					},
+				},
+			},
+			want: `## サマリー 
+|項目|startEnd|
+|----|----|
+|起票件数|3|
+|クローズ件数|1|
+|起票件数(sataga/product-a)|2|
+|クローズ件数(sataga/product-a)|0|
+|起票件数(sataga/product-b)|1|
+|クローズ件数(sataga/product-b)|1|
+|緊急度：高|0|
+|緊急度：中|0|
+|緊急度：低|1|
+|全体エスカレーション件数|0|
+|全体CaaS-A完結率(％)|0|
+|通常問合せエスカレーション件数|0|
+|通常問合せCaaS-A完結率(％)|0|
+|要望エスカレーション件数|0|
+|要望CaaS-A完結率(％)|0|
+|サービス障害エスカレーション件数|0|
+|サービス障害CaaS-A完結率(％)|0|
+|ジャンル:通常問合せ件数|0|
+|ジャンル:要望件数|0|
+|ジャンル:サービス障害件数|0|
+|初回応答時間 中央値(分)|0|
+|初回応答時間 p90(分)|0|
+|未応答件数|1|
+|合計スコア|1.00|
+|スコアA|1|
+|スコアB|0|
+|スコアC|0|
+|スコアD|0|
+|スコアE|0|
+|スコアF|0|
+
+## 詳細 
+- [issue 1](https://github.com/sataga/product-b/issues/1),低,通常問合せ,CaaS-A/,comment数:1,経過時間(hour):24,解決フラグ:false,(startEnd)
 `,
 		},
 	}
@@ -554,10 +696,55 @@ func TestLongTermStats_GenLongTermReport(t *testing.T) {
 	}
 }
 
+func TestLongTermStats_GenLongTermReport_Taxonomy(t *testing.T) {
+	// three teams and a genre class which the default taxonomy does not have
+	tx := &Taxonomy{Rules: []LabelRule{
+		{Dimension: DimensionUrgency, Label: "priority:high", Display: "High", Class: "p1"},
+		{Dimension: DimensionTeam, Label: "team:first", Display: "First", Class: "first"},
+		{Dimension: DimensionTeam, Label: "team:second", Display: "Second", Class: "second"},
+		{Dimension: DimensionTeam, Label: "team:third", Display: "Third", Class: "third"},
+		{Dimension: DimensionGenre, Label: "type:security", Display: "Security", Class: "security"},
+	}}
+	lts := &LongTermStats{
+		SummaryStats: map[string]*SummaryStats{
+			startEnd: {
+				Span:                   startEnd,
+				NumClosedIssues:        2,
+				NumEscalationAllIssues: 1,
+				NumGenreIssues:         map[string]int{"security": 2},
+				NumEscalationIssues:    map[string]int{"security": 1},
+				NumUrgencyIssues:       map[string]int{"p1": 1, "unknown": 1},
+			},
+		},
+		ScoreLabels:    []string{"A"},
+		GenreClasses:   tx.Classes(DimensionGenre),
+		UrgencyClasses: tx.Classes(DimensionUrgency),
+		TeamClasses:    tx.Classes(DimensionTeam),
+	}
+	got := lts.GenLongTermReport()
+	for _, row := range []string{
+		"|緊急度：High|1|\n",
+		"|緊急度：unknown|1|\n",
+		"|全体First完結率(％)|50.0|\n",
+		"|Securityエスカレーション件数|1|\n",
+		"|SecurityFirst完結率(％)|50.0|\n",
+		"|ジャンル:Security件数|2|\n",
+	} {
+		if !strings.Contains(got, row) {
+			t.Errorf("LongTermStats.GenLongTermReport() has no row %q\n%s", row, got)
+		}
+	}
+	for _, heading := range []string{"CaaS", "高", "通常問合せ"} {
+		if strings.Contains(got, heading) {
+			t.Errorf("LongTermStats.GenLongTermReport() has heading of the default taxonomy %q\n%s", heading, got)
+		}
+	}
+}
+
 func Test_userSupport_GetAnalysisReportStats(t *testing.T) {
 	var c *gomock.Controller
 
-	testIssues := []*github.Issue{
+	testIssues := []*Issue{
 		issuePatterns[0],
 		issuePatterns[1],
 	}
@@ -590,10 +777,11 @@ func Test_userSupport_GetAnalysisReportStats(t *testing.T) {
 			want: &AnalysisStats{
 				DetailStats: map[int]*DetailStats{
 					0: {
+						Repository:   "sataga/issue-warehouse",
 						Title:        "issue 1",
 						HTMLURL:      "https://github.com/sataga/issue-warehouse/issues/1",
-						CreatedAt:    tenDayAgo.Format("2006-01-02"),
-						ClosedAt:     threeDayAgo.Format("2006-01-02"),
+						CreatedAt:    tenDayAgo.In(loc).Format("2006-01-02"),
+						ClosedAt:     threeDayAgo.In(loc).Format("2006-01-02"),
 						State:        "closed",
 						TargetSpan:   startEnd,
 						TeamName:     "CaaS-A",
@@ -605,10 +793,11 @@ func Test_userSupport_GetAnalysisReportStats(t *testing.T) {
 						Escalation:   true,
 					},
 					1: {
+						Repository:   "sataga/issue-warehouse",
 						Title:        "issue 2",
 						HTMLURL:      "https://github.com/sataga/issue-warehouse/issues/2",
-						CreatedAt:    sevenDayAgo.Format("2006-01-02"),
-						ClosedAt:     threeDayAgo.Format("2006-01-02"),
+						CreatedAt:    sevenDayAgo.In(loc).Format("2006-01-02"),
+						ClosedAt:     threeDayAgo.In(loc).Format("2006-01-02"),
 						State:        "closed",
 						TargetSpan:   startEnd,
 						TeamName:     "CaaS-A",
@@ -625,9 +814,9 @@ func Test_userSupport_GetAnalysisReportStats(t *testing.T) {
 				c = gomock.NewController(t)
 				musr := NewMockRepository(c)
 				if state == "created" {
-					musr.EXPECT().GetCreatedSupportIssues(gomock.Any(), gomock.Any()).Return(testIssues, nil)
+					musr.EXPECT().GetCreatedSupportIssues(gomock.Any(), gomock.Any(), gomock.Any()).Return(testIssues, nil)
 				} else {
-					musr.EXPECT().GetClosedSupportIssues(gomock.Any(), gomock.Any()).Return(testIssues, nil)
+					musr.EXPECT().GetClosedSupportIssues(gomock.Any(), gomock.Any(), gomock.Any()).Return(testIssues, nil)
 				}
 				f.repo = musr
 			},
@@ -647,7 +836,7 @@ func Test_userSupport_GetAnalysisReportStats(t *testing.T) {
 			us := &userSupport{
 				repo: tt.fields.repo,
 			}
-			got, err := us.GetAnalysisReportStats(tt.args.since, tt.args.until, tt.args.state)
+			got, err := us.GetAnalysisReportStats(context.Background(), tt.args.since, tt.args.until, tt.args.state)
 			// for k, v := range tt.want.DetailStats {
 			// 	fmt.Printf("want:%d", k)
 			// 	fmt.Println(v)
@@ -682,10 +871,11 @@ func TestAnalysisStats_GenAnalysisReport(t *testing.T) {
 			fields: fields{
 				DetailStats: map[int]*DetailStats{
 					0: {
+						Repository:   "sataga/issue-warehouse",
 						Title:        "issue 1",
 						HTMLURL:      "https://github.com/sataga/issue-warehouse/issues/1",
-						CreatedAt:    tenDayAgo.Format("2006-01-02"),
-						ClosedAt:     threeDayAgo.Format("2006-01-02"),
+						CreatedAt:    tenDayAgo.In(loc).Format("2006-01-02"),
+						ClosedAt:     threeDayAgo.In(loc).Format("2006-01-02"),
 						State:        "closed",
 						TargetSpan:   startEnd,
 						TeamName:     "CaaS-A",
@@ -697,10 +887,11 @@ func TestAnalysisStats_GenAnalysisReport(t *testing.T) {
 						Escalation:   true,
 					},
 					1: {
+						Repository:   "sataga/issue-warehouse",
 						Title:        "issue 2",
 						HTMLURL:      "https://github.com/sataga/issue-warehouse/issues/2",
-						CreatedAt:    sevenDayAgo.Format("2006-01-02"),
-						ClosedAt:     threeDayAgo.Format("2006-01-02"),
+						CreatedAt:    sevenDayAgo.In(loc).Format("2006-01-02"),
+						ClosedAt:     threeDayAgo.In(loc).Format("2006-01-02"),
 						State:        "closed",
 						TargetSpan:   startEnd,
 						TeamName:     "CaaS-A",
@@ -713,9 +904,31 @@ func TestAnalysisStats_GenAnalysisReport(t *testing.T) {
 					},
 				},
 			},
-			want: `期間,Title,起票日,クローズ日,ステータス,担当チーム,担当アサイン,緊急度,問い合わせ種別,エスカレ有無,コメント数,経過時間,Keywordラベル,URL
-startEnd,issue 1,tenDayAgo,threeDayAgo,closed,CaaS-A,,低,通常問合せ,true,1,168,Kubernetes,https://github.com/sataga/issue-warehouse/issues/1 
-startEnd,issue 2,sevenDayAgo,threeDayAgo,closed,CaaS-A,,中,要望,false,2,96,Openstack,https://github.com/sataga/issue-warehouse/issues/2 
+			want: `期間,リポジトリ,Title,起票日,クローズ日,ステータス,担当チーム,担当アサイン,緊急度,問い合わせ種別,エスカレ有無,コメント数,経過時間,Keywordラベル,URL
+startEnd,sataga/issue-warehouse,issue 1,tenDayAgo,threeDayAgo,closed,CaaS-A,,低,通常問合せ,true,1,168,Kubernetes,https://github.com/sataga/issue-warehouse/issues/1
+startEnd,sataga/issue-warehouse,issue 2,sevenDayAgo,threeDayAgo,closed,CaaS-A,,中,要望,false,2,96,Openstack,https://github.com/sataga/issue-warehouse/issues/2
+`,
+		},
+		{
+			name: "quote title containing comma, quote and newline",
+			fields: fields{
+				DetailStats: map[int]*DetailStats{
+					0: {
+						Repository:   "sataga/issue-warehouse",
+						Title:        "INC0000001, \"API\" error\nretry",
+						HTMLURL:      "https://github.com/sataga/issue-warehouse/issues/5",
+						CreatedAt:    tenDayAgo.In(loc).Format("2006-01-02"),
+						State:        "open",
+						TargetSpan:   startEnd,
+						Assignee:     "@a @b",
+						NumComments:  0,
+						OpenDuration: 24,
+					},
+				},
+			},
+			want: `期間,リポジトリ,Title,起票日,クローズ日,ステータス,担当チーム,担当アサイン,緊急度,問い合わせ種別,エスカレ有無,コメント数,経過時間,Keywordラベル,URL
+startEnd,sataga/issue-warehouse,"INC0000001, ""API"" error
+retry",tenDayAgo,,open,,@a @b,,,false,0,24,,https://github.com/sataga/issue-warehouse/issues/5
 `,
 		},
 	}
@@ -725,9 +938,10 @@ startEnd,issue 2,sevenDayAgo,threeDayAgo,closed,CaaS-A,,中,要望,false,2,96,Op
 				DetailStats: tt.fields.DetailStats,
 			}
 			tt.want = strings.Replace(tt.want, "startEnd", startEnd, -1)
-			tt.want = strings.Replace(tt.want, "threeDayAgo", threeDayAgo.Format("2006-01-02"), -1)
-			tt.want = strings.Replace(tt.want, "sevenDayAgo", sevenDayAgo.Format("2006-01-02"), -1)
-			tt.want = strings.Replace(tt.want, "tenDayAgo", tenDayAgo.Format("2006-01-02"), -1)
+			tt.want = strings.Replace(tt.want, "threeDayAgo", threeDayAgo.In(loc).Format("2006-01-02"), -1)
+			tt.want = strings.Replace(tt.want, "sevenDayAgo", sevenDayAgo.In(loc).Format("2006-01-02"), -1)
+			tt.want = strings.Replace(tt.want, "tenDayAgo", tenDayAgo.In(loc).Format("2006-01-02"), -1)
+			tt.want = strings.Replace(tt.want, "\n", "\r\n", -1)
 			if got := as.GenAnalysisReport(); got != tt.want {
 				t.Errorf("AnalysisStats.GenAnalysisReport() = %v, want %v", got, tt.want)
 			}
@@ -738,12 +952,12 @@ startEnd,issue 2,sevenDayAgo,threeDayAgo,closed,CaaS-A,,中,要望,false,2,96,Op
 func Test_userSupport_GetKeywordReportStats(t *testing.T) {
 	var c *gomock.Controller
 
-	closeIssues := []*github.Issue{
+	closeIssues := []*Issue{
 		issuePatterns[2],
 		issuePatterns[3],
 	}
 
-	keywords := []*github.LabelResult{
+	keywords := []*Label{
 		keywordPatterns[0],
 		keywordPatterns[1],
 		keywordPatterns[2],
@@ -792,8 +1006,8 @@ func Test_userSupport_GetKeywordReportStats(t *testing.T) {
 			beforefunc: func(f *fields) {
 				c = gomock.NewController(t)
 				musr := NewMockRepository(c)
-				musr.EXPECT().GetLabelsByQuery(gomock.Any()).Return(keywords, nil)
-				musr.EXPECT().GetClosedSupportIssues(gomock.Any(), gomock.Any()).Return(closeIssues, nil)
+				musr.EXPECT().GetLabelsByQuery(gomock.Any(), gomock.Any()).Return(keywords, nil)
+				musr.EXPECT().GetClosedSupportIssues(gomock.Any(), gomock.Any(), gomock.Any()).Return(closeIssues, nil)
 				f.repo = musr
 			},
 			afterfunc: func() {
@@ -812,7 +1026,7 @@ func Test_userSupport_GetKeywordReportStats(t *testing.T) {
 			us := &userSupport{
 				repo: tt.fields.repo,
 			}
-			got, err := us.GetKeywordReportStats(tt.args.since, tt.args.until)
+			got, err := us.GetKeywordReportStats(context.Background(), tt.args.since, tt.args.until)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("userSupport.GetKeywordReportStats() error = %v, wantErr %v", err, tt.wantErr)
 				return
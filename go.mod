@@ -8,10 +8,13 @@ require (
 	github.com/google/go-github v17.0.0+incompatible
 	github.com/google/go-querystring v1.0.0 // indirect
 	github.com/parnurzeal/gorequest v0.2.16 // indirect
+	github.com/prometheus/client_golang v1.9.0
 	github.com/prometheus/common v0.15.0
 	golang.org/x/oauth2 v0.0.0-20201109201403-9fd604954f58
+	golang.org/x/text v0.3.3
 	golang.org/x/tools v0.1.0
 	gopkg.in/src-d/go-git.v4 v4.13.1
+	gopkg.in/yaml.v2 v2.4.0
 	k8s.io/apimachinery v0.19.4
 	moul.io/http2curl v1.0.0 // indirect
 )
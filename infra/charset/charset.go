@@ -0,0 +1,46 @@
+// Package charset is a responsible for character encoding of output
+package charset
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+)
+
+// Encodings which output can be written in
+const (
+	UTF8     = "utf-8"
+	UTF8BOM  = "utf-8-bom"
+	ShiftJIS = "shift_jis"
+)
+
+var bom = []byte{0xEF, 0xBB, 0xBF}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// NewWriter creates writer which encodes UTF-8 text in the encoding
+// Close must be called to flush the encoded text
+func NewWriter(w io.Writer, name string) (io.WriteCloser, error) {
+	switch strings.ToLower(name) {
+	case "", UTF8, "utf8":
+		return nopCloser{w}, nil
+	case UTF8BOM, "utf8bom":
+		// Excel detects UTF-8 by BOM
+		if _, err := w.Write(bom); err != nil {
+			return nil, fmt.Errorf("write bom: %s", err)
+		}
+		return nopCloser{w}, nil
+	case ShiftJIS, "sjis", "cp932":
+		// characters which Shift_JIS does not have (e.g. emoji) are replaced instead of failing
+		return transform.NewWriter(w, encoding.ReplaceUnsupported(japanese.ShiftJIS.NewEncoder())), nil
+	}
+	return nil, fmt.Errorf("unknown encoding: %s (choose on utf-8, utf-8-bom, shift_jis)", name)
+}
@@ -0,0 +1,68 @@
+package charset
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewWriter(t *testing.T) {
+	tests := []struct {
+		name    string
+		enc     string
+		text    string
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name: "utf-8",
+			enc:  UTF8,
+			text: "期間,URL\r\n",
+			want: []byte("期間,URL\r\n"),
+		},
+		{
+			name: "utf-8 with bom",
+			enc:  UTF8BOM,
+			text: "期間\r\n",
+			want: append([]byte{0xEF, 0xBB, 0xBF}, []byte("期間\r\n")...),
+		},
+		{
+			name: "shift_jis",
+			enc:  ShiftJIS,
+			text: "期間,A\r\n",
+			want: []byte{0x8A, 0xFA, 0x8A, 0xD4, ',', 'A', '\r', '\n'},
+		},
+		{
+			name: "shift_jis replaces unsupported character",
+			enc:  ShiftJIS,
+			text: "A😀",
+			want: []byte{'A', 0x1A},
+		},
+		{
+			name:    "unknown encoding",
+			enc:     "euc-jp",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, tt.enc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewWriter() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if _, err := w.Write([]byte(tt.text)); err != nil {
+				t.Errorf("Write() error = %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+			if got := buf.Bytes(); !bytes.Equal(got, tt.want) {
+				t.Errorf("NewWriter() wrote % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,623 @@
+// Package fakegithub is an in-process fake of GitHub API serving issues, labels, search and repositories from fixtures
+package fakegithub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+const (
+	defaultPerPage = 30
+	maxPerPage     = 100
+)
+
+// Login is the user who creates comments through the fake
+const Login = "fakegithub"
+
+// Repository is a fixture of a repository
+// fixture file is <dir>/<owner>/<name>.json, and owner and name are taken from the path when repository is omitted
+type Repository struct {
+	Repository *github.Repository `json:"repository"`
+	Issues     []*github.Issue    `json:"issues"`
+	// Comments and Events are keyed by issue number
+	Comments map[int][]*github.IssueComment `json:"comments"`
+	Events   map[int][]*github.IssueEvent   `json:"events"`
+	Labels   []*github.Label                `json:"labels"`
+}
+
+// Server is a fake GitHub API, pass URL to github client as the base URL
+type Server struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	repos  map[string]*Repository
+	nextID int64
+	now    func() time.Time
+}
+
+// Load reads every fixture in dir
+func Load(dir string) ([]*Repository, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no fixture in %s", dir)
+	}
+	repos := make([]*Repository, 0, len(paths))
+	for _, p := range paths {
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("read fixture: %s", err)
+		}
+		var r Repository
+		if err := json.Unmarshal(b, &r); err != nil {
+			return nil, fmt.Errorf("decode fixture %s: %s", p, err)
+		}
+		if r.Repository == nil {
+			r.Repository = &github.Repository{}
+		}
+		if r.Repository.GetFullName() == "" {
+			owner := filepath.Base(filepath.Dir(p))
+			name := strings.TrimSuffix(filepath.Base(p), ".json")
+			r.Repository.FullName = github.String(owner + "/" + name)
+		}
+		repos = append(repos, &r)
+	}
+	return repos, nil
+}
+
+// NewServer starts a fake serving the repositories, close it after use
+func NewServer(repos ...*Repository) *Server {
+	s := &Server{
+		repos: make(map[string]*Repository, len(repos)),
+		now:   time.Now,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	// the server is already serving, and URLs of repositories need its address
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, r := range repos {
+		s.add(int64(i+1), r)
+	}
+	return s
+}
+
+// NewServerFromDir starts a fake serving fixtures in dir
+func NewServerFromDir(dir string) (*Server, error) {
+	repos, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	return NewServer(repos...), nil
+}
+
+// add fills fields which github fills, such as IDs and URLs, so fixtures can omit them
+func (s *Server) add(id int64, r *Repository) {
+	repo := r.Repository
+	fullName := repo.GetFullName()
+	parts := strings.SplitN(fullName, "/", 2)
+	if repo.ID == nil {
+		repo.ID = github.Int64(id)
+	}
+	if repo.Name == nil {
+		repo.Name = github.String(parts[len(parts)-1])
+	}
+	if repo.Owner == nil && len(parts) == 2 {
+		repo.Owner = &github.User{Login: github.String(parts[0])}
+	}
+	repo.URL = github.String(s.URL + "/repos/" + fullName)
+	if r.Comments == nil {
+		r.Comments = make(map[int][]*github.IssueComment)
+	}
+	if r.Events == nil {
+		r.Events = make(map[int][]*github.IssueEvent)
+	}
+	for _, is := range r.Issues {
+		is.RepositoryURL = repo.URL
+		if is.URL == nil {
+			is.URL = github.String(fmt.Sprintf("%s/issues/%d", repo.GetURL(), is.GetNumber()))
+		}
+		if is.HTMLURL == nil {
+			is.HTMLURL = github.String(fmt.Sprintf("https://github.com/%s/issues/%d", fullName, is.GetNumber()))
+		}
+		if is.Comments == nil {
+			is.Comments = github.Int(len(r.Comments[is.GetNumber()]))
+		}
+		for _, c := range r.Comments[is.GetNumber()] {
+			if c.GetID() > s.nextID {
+				s.nextID = c.GetID()
+			}
+		}
+	}
+	s.repos[fullName] = r
+}
+
+// Comments returns comments of the issue including those created through the fake
+func (s *Server) Comments(fullName string, number int) []*github.IssueComment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.repos[fullName]
+	if !ok {
+		return nil
+	}
+	return append([]*github.IssueComment(nil), r.Comments[number]...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// github enterprise serves the API under /api/v3
+	p := strings.Trim(strings.TrimPrefix(req.URL.Path, "/api/v3"), "/")
+	parts := strings.Split(p, "/")
+	switch {
+	case req.Method == http.MethodGet && p == "search/issues":
+		s.searchIssues(w, req)
+	case req.Method == http.MethodGet && p == "search/labels":
+		s.searchLabels(w, req)
+	case req.Method == http.MethodGet && len(parts) == 3 && parts[0] == "orgs" && parts[2] == "repos":
+		s.listOrgRepos(w, req, parts[1])
+	case len(parts) >= 3 && parts[0] == "repos":
+		r, ok := s.repos[parts[1]+"/"+parts[2]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		s.handleRepo(w, req, r, parts[3:])
+	default:
+		writeError(w, http.StatusNotFound, "Not Found")
+	}
+}
+
+func (s *Server) handleRepo(w http.ResponseWriter, req *http.Request, r *Repository, parts []string) {
+	if len(parts) == 0 && req.Method == http.MethodGet {
+		writeJSON(w, http.StatusOK, r.Repository)
+		return
+	}
+	if len(parts) == 1 && parts[0] == "labels" && req.Method == http.MethodGet {
+		labels := make([]interface{}, 0, len(r.Labels))
+		for _, l := range r.Labels {
+			labels = append(labels, l)
+		}
+		writePage(w, req, labels)
+		return
+	}
+	if len(parts) == 1 && parts[0] == "issues" && req.Method == http.MethodGet {
+		s.listIssues(w, req, r)
+		return
+	}
+	if len(parts) != 3 || parts[0] != "issues" {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	number, err := strconv.Atoi(parts[1])
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	var issue *github.Issue
+	for _, is := range r.Issues {
+		if is.GetNumber() == number {
+			issue = is
+		}
+	}
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	switch {
+	case parts[2] == "comments" && req.Method == http.MethodGet:
+		comments := make([]interface{}, 0, len(r.Comments[number]))
+		for _, c := range r.Comments[number] {
+			comments = append(comments, c)
+		}
+		writePage(w, req, comments)
+	case parts[2] == "comments" && req.Method == http.MethodPost:
+		var in github.IssueComment
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil || in.GetBody() == "" {
+			writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+			return
+		}
+		s.nextID++
+		now := s.now()
+		c := &github.IssueComment{
+			ID:        github.Int64(s.nextID),
+			Body:      in.Body,
+			User:      &github.User{Login: github.String(Login)},
+			CreatedAt: &now,
+			UpdatedAt: &now,
+			IssueURL:  issue.URL,
+		}
+		r.Comments[number] = append(r.Comments[number], c)
+		issue.Comments = github.Int(len(r.Comments[number]))
+		issue.UpdatedAt = &now
+		writeJSON(w, http.StatusCreated, c)
+	case parts[2] == "events" && req.Method == http.MethodGet:
+		events := make([]interface{}, 0, len(r.Events[number]))
+		for _, e := range r.Events[number] {
+			events = append(events, e)
+		}
+		writePage(w, req, events)
+	default:
+		writeError(w, http.StatusNotFound, "Not Found")
+	}
+}
+
+// listIssues filters issues by state, labels and since as GitHub does, newest first
+func (s *Server) listIssues(w http.ResponseWriter, req *http.Request, r *Repository) {
+	q := req.URL.Query()
+	state := q.Get("state")
+	if state == "" {
+		state = "open"
+	}
+	var labels []string
+	if q.Get("labels") != "" {
+		labels = strings.Split(q.Get("labels"), ",")
+	}
+	var since time.Time
+	if q.Get("since") != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, q.Get("since")); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+			return
+		}
+	}
+	issues := make([]*github.Issue, 0, len(r.Issues))
+	for _, is := range r.Issues {
+		if state != "all" && is.GetState() != state {
+			continue
+		}
+		if !hasLabels(is, labels) {
+			continue
+		}
+		if !since.IsZero() && is.GetUpdatedAt().Before(since) {
+			continue
+		}
+		issues = append(issues, is)
+	}
+	writePage(w, req, sortIssues(issues))
+}
+
+func (s *Server) listOrgRepos(w http.ResponseWriter, req *http.Request, org string) {
+	names := make([]string, 0, len(s.repos))
+	for name := range s.repos {
+		if strings.HasPrefix(name, org+"/") {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	sort.Strings(names)
+	repos := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		repos = append(repos, s.repos[name].Repository)
+	}
+	writePage(w, req, repos)
+}
+
+// searchIssues supports qualifiers which this tool uses: repo, org, user, is, state, label, created, updated and closed
+// other words match title or body
+func (s *Server) searchIssues(w http.ResponseWriter, req *http.Request) {
+	match, err := parseQuery(req.URL.Query().Get("q"))
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	issues := make([]*github.Issue, 0)
+	for name, r := range s.repos {
+		for _, is := range r.Issues {
+			if match(name, is) {
+				issues = append(issues, is)
+			}
+		}
+	}
+	items := sortIssues(issues)
+	page, perPage := pagination(req)
+	start, end := pageRange(len(items), page, perPage)
+	result := &github.IssuesSearchResult{
+		Total:             github.Int(len(items)),
+		IncompleteResults: github.Bool(false),
+		Issues:            make([]github.Issue, 0, end-start),
+	}
+	for _, is := range items[start:end] {
+		result.Issues = append(result.Issues, *is.(*github.Issue))
+	}
+	setLink(w, req, len(items), page, perPage)
+	writeJSON(w, http.StatusOK, result)
+}
+
+// searchLabels returns labels of repository_id whose name contains q
+func (s *Server) searchLabels(w http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+	id, err := strconv.ParseInt(q.Get("repository_id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	var repo *Repository
+	for _, r := range s.repos {
+		if r.Repository.GetID() == id {
+			repo = r
+		}
+	}
+	if repo == nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	word := strings.ToLower(q.Get("q"))
+	labels := make([]*github.LabelResult, 0)
+	for _, l := range repo.Labels {
+		if strings.Contains(strings.ToLower(l.GetName()), word) {
+			labels = append(labels, &github.LabelResult{
+				ID:          l.ID,
+				URL:         l.URL,
+				Name:        l.Name,
+				Color:       l.Color,
+				Default:     l.Default,
+				Description: l.Description,
+			})
+		}
+	}
+	page, perPage := pagination(req)
+	start, end := pageRange(len(labels), page, perPage)
+	setLink(w, req, len(labels), page, perPage)
+	writeJSON(w, http.StatusOK, &github.LabelsSearchResult{
+		Total:             github.Int(len(labels)),
+		IncompleteResults: github.Bool(false),
+		Labels:            labels[start:end],
+	})
+}
+
+// parseQuery parses search query into a matcher of issues of the repository
+func parseQuery(query string) (func(fullName string, is *github.Issue) bool, error) {
+	var matchers []func(fullName string, is *github.Issue) bool
+	var scopes []func(fullName string) bool
+	for _, term := range splitQuery(query) {
+		i := strings.Index(term, ":")
+		if i < 0 {
+			word := strings.ToLower(term)
+			matchers = append(matchers, func(_ string, is *github.Issue) bool {
+				return strings.Contains(strings.ToLower(is.GetTitle()), word) || strings.Contains(strings.ToLower(is.GetBody()), word)
+			})
+			continue
+		}
+		key, value := term[:i], strings.Trim(term[i+1:], `"`)
+		switch key {
+		case "repo":
+			scopes = append(scopes, func(fullName string) bool { return fullName == value })
+		case "org", "user":
+			scopes = append(scopes, func(fullName string) bool { return strings.HasPrefix(fullName, value+"/") })
+		case "is", "state":
+			switch value {
+			case "issue":
+			case "open", "closed":
+				matchers = append(matchers, func(_ string, is *github.Issue) bool { return is.GetState() == value })
+			default:
+				return nil, fmt.Errorf("unsupported qualifier: %s", term)
+			}
+		case "label":
+			matchers = append(matchers, func(_ string, is *github.Issue) bool { return hasLabels(is, []string{value}) })
+		case "created", "updated", "closed":
+			in, err := parseRange(value)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %s", term, err)
+			}
+			field := key
+			matchers = append(matchers, func(_ string, is *github.Issue) bool {
+				var t *time.Time
+				switch field {
+				case "created":
+					t = is.CreatedAt
+				case "updated":
+					t = is.UpdatedAt
+				case "closed":
+					t = is.ClosedAt
+				}
+				return t != nil && in(*t)
+			})
+		default:
+			return nil, fmt.Errorf("unsupported qualifier: %s", term)
+		}
+	}
+	return func(fullName string, is *github.Issue) bool {
+		if len(scopes) > 0 {
+			inScope := false
+			for _, scope := range scopes {
+				inScope = inScope || scope(fullName)
+			}
+			if !inScope {
+				return false
+			}
+		}
+		for _, m := range matchers {
+			if !m(fullName, is) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// splitQuery splits query by spaces out of double quotes
+func splitQuery(query string) []string {
+	var terms []string
+	var term strings.Builder
+	quoted := false
+	for _, c := range query {
+		switch {
+		case c == '"':
+			quoted = !quoted
+			term.WriteRune(c)
+		case c == ' ' && !quoted:
+			if term.Len() > 0 {
+				terms = append(terms, term.String())
+				term.Reset()
+			}
+		default:
+			term.WriteRune(c)
+		}
+	}
+	if term.Len() > 0 {
+		terms = append(terms, term.String())
+	}
+	return terms
+}
+
+// parseRange parses range of dates or times, such as 2020-10-01..2020-10-31, >=2020-10-01 and 2020-10-01
+// a date means the whole day in UTC
+func parseRange(value string) (func(t time.Time) bool, error) {
+	if i := strings.Index(value, ".."); i >= 0 {
+		from, _, err := parseTime(value[:i])
+		if err != nil {
+			return nil, err
+		}
+		_, to, err := parseTime(value[i+2:])
+		if err != nil {
+			return nil, err
+		}
+		return func(t time.Time) bool { return !t.Before(from) && t.Before(to) }, nil
+	}
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		if !strings.HasPrefix(value, op) {
+			continue
+		}
+		from, to, err := parseTime(strings.TrimPrefix(value, op))
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case ">=":
+			return func(t time.Time) bool { return !t.Before(from) }, nil
+		case "<=":
+			return func(t time.Time) bool { return t.Before(to) }, nil
+		case ">":
+			return func(t time.Time) bool { return !t.Before(to) }, nil
+		default:
+			return func(t time.Time) bool { return t.Before(from) }, nil
+		}
+	}
+	from, to, err := parseTime(value)
+	if err != nil {
+		return nil, err
+	}
+	return func(t time.Time) bool { return !t.Before(from) && t.Before(to) }, nil
+}
+
+// parseTime returns the beginning and the end (exclusive) of the date or the second
+func parseTime(value string) (time.Time, time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, t.AddDate(0, 0, 1), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("time must be date or RFC3339")
+	}
+	return t, t.Add(time.Second), nil
+}
+
+func hasLabels(is *github.Issue, labels []string) bool {
+	for _, want := range labels {
+		found := false
+		for _, l := range is.Labels {
+			if l.GetName() == want {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// sortIssues sorts issues by created time descending as GitHub does by default
+func sortIssues(issues []*github.Issue) []interface{} {
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].GetCreatedAt().After(issues[j].GetCreatedAt())
+	})
+	items := make([]interface{}, 0, len(issues))
+	for _, is := range issues {
+		items = append(items, is)
+	}
+	return items
+}
+
+// pagination returns page and per_page of the request
+func pagination(req *http.Request) (int, int) {
+	q := req.URL.Query()
+	page, err := strconv.Atoi(q.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err := strconv.Atoi(q.Get("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = defaultPerPage
+	}
+	if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+	return page, perPage
+}
+
+func pageRange(total, page, perPage int) (int, int) {
+	start := (page - 1) * perPage
+	if start > total {
+		start = total
+	}
+	end := start + perPage
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
+// setLink sets Link header of next and last pages as GitHub does, it is omitted on the last page
+func setLink(w http.ResponseWriter, req *http.Request, total, page, perPage int) {
+	last := (total + perPage - 1) / perPage
+	if page >= last {
+		return
+	}
+	link := func(p int, rel string) string {
+		u := url.URL{Scheme: "http", Host: req.Host, Path: req.URL.Path}
+		q := req.URL.Query()
+		q.Set("page", strconv.Itoa(p))
+		u.RawQuery = q.Encode()
+		return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+	}
+	w.Header().Set("Link", link(page+1, "next")+", "+link(last, "last"))
+}
+
+// writePage writes a page of items
+func writePage(w http.ResponseWriter, req *http.Request, items []interface{}) {
+	page, perPage := pagination(req)
+	start, end := pageRange(len(items), page, perPage)
+	setLink(w, req, len(items), page, perPage)
+	writeJSON(w, http.StatusOK, items[start:end])
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"message": msg})
+}
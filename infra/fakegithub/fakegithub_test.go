@@ -0,0 +1,210 @@
+package fakegithub
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+func newIssue(number int, state string, created time.Time, closed *time.Time, labels ...string) *github.Issue {
+	is := &github.Issue{
+		Number:    github.Int(number),
+		Title:     github.String("issue " + string(rune('A'+number-1))),
+		State:     github.String(state),
+		CreatedAt: &created,
+		UpdatedAt: &created,
+		ClosedAt:  closed,
+	}
+	for _, l := range labels {
+		is.Labels = append(is.Labels, github.Label{Name: github.String(l)})
+	}
+	return is
+}
+
+func at(day, hour int) time.Time {
+	return time.Date(2020, 10, day, hour, 0, 0, 0, time.UTC)
+}
+
+func TestSplitQuery(t *testing.T) {
+	got := splitQuery(`repo:sataga/issue-warehouse  label:"CaaS-A 対応中" is:issue`)
+	want := []string{"repo:sataga/issue-warehouse", `label:"CaaS-A 対応中"`, "is:issue"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitQuery() = %q, want %q", got, want)
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	closed := at(3, 23)
+	issues := map[string][]*github.Issue{
+		"sataga/issue-warehouse": {
+			newIssue(1, "closed", at(1, 0), &closed, "PF_Support", "CaaS-A 対応中"),
+			newIssue(2, "open", at(2, 12), nil, "PF_Support"),
+			newIssue(3, "open", at(4, 0), nil),
+		},
+		"sataga-support/product": {
+			newIssue(1, "open", at(2, 0), nil, "PF_Support"),
+		},
+	}
+	tests := []struct {
+		query string
+		// want is repository#number of matched issues
+		want []string
+	}{
+		{query: "repo:sataga/issue-warehouse", want: []string{"sataga/issue-warehouse#1", "sataga/issue-warehouse#2", "sataga/issue-warehouse#3"}},
+		{query: "org:sataga-support label:PF_Support", want: []string{"sataga-support/product#1"}},
+		{query: `repo:sataga/issue-warehouse label:"CaaS-A 対応中"`, want: []string{"sataga/issue-warehouse#1"}},
+		{query: "repo:sataga/issue-warehouse repo:sataga-support/product state:open label:PF_Support", want: []string{"sataga-support/product#1", "sataga/issue-warehouse#2"}},
+		// a date is the whole day in UTC, and both ends of range are inclusive
+		{query: "created:2020-10-02", want: []string{"sataga-support/product#1", "sataga/issue-warehouse#2"}},
+		{query: "repo:sataga/issue-warehouse created:2020-10-01..2020-10-02", want: []string{"sataga/issue-warehouse#1", "sataga/issue-warehouse#2"}},
+		{query: "repo:sataga/issue-warehouse created:>=2020-10-02", want: []string{"sataga/issue-warehouse#2", "sataga/issue-warehouse#3"}},
+		{query: "repo:sataga/issue-warehouse created:>2020-10-02", want: []string{"sataga/issue-warehouse#3"}},
+		{query: "repo:sataga/issue-warehouse created:<2020-10-02", want: []string{"sataga/issue-warehouse#1"}},
+		{query: "repo:sataga/issue-warehouse created:<=2020-10-02", want: []string{"sataga/issue-warehouse#1", "sataga/issue-warehouse#2"}},
+		{query: "created:2020-10-02T12:00:00Z", want: []string{"sataga/issue-warehouse#2"}},
+		{query: "closed:2020-10-03..2020-10-03", want: []string{"sataga/issue-warehouse#1"}},
+		{query: "closed:2020-10-01..2020-10-02"},
+		// issues without closed time never match closed
+		{query: "closed:>=2020-01-01", want: []string{"sataga/issue-warehouse#1"}},
+		{query: "repo:sataga/issue-warehouse issue c", want: []string{"sataga/issue-warehouse#3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			match, err := parseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("parseQuery() error = %v", err)
+			}
+			var got []string
+			for _, name := range []string{"sataga-support/product", "sataga/issue-warehouse"} {
+				for _, is := range issues[name] {
+					if match(name, is) {
+						got = append(got, name+"#"+string(rune('0'+is.GetNumber())))
+					}
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseQuery() matched %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, query := range []string{"is:pr", "assignee:sataga", "created:2020/10/01", "closed:2020-10-01..yesterday"} {
+		if _, err := parseQuery(query); err == nil {
+			t.Errorf("parseQuery(%s) error = nil", query)
+		}
+	}
+}
+
+func TestServer_Pagination(t *testing.T) {
+	fixture := &Repository{
+		Repository: &github.Repository{FullName: github.String("sataga/issue-warehouse")},
+	}
+	for i := 1; i <= 5; i++ {
+		fixture.Issues = append(fixture.Issues, newIssue(i, "open", at(i, 0), nil, "PF_Support"))
+	}
+	srv := NewServer(fixture)
+	defer srv.Close()
+
+	get := func(t *testing.T, path string, v interface{}) http.Header {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s = %d, want %d", path, resp.StatusCode, http.StatusOK)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Header
+	}
+	numbers := func(issues []*github.Issue) []int {
+		var n []int
+		for _, is := range issues {
+			n = append(n, is.GetNumber())
+		}
+		return n
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		search   bool
+		want     []int
+		wantNext string
+		wantLast string
+	}{
+		{
+			name:     "first page of list",
+			path:     "/repos/sataga/issue-warehouse/issues?labels=PF_Support&per_page=2",
+			want:     []int{5, 4},
+			wantNext: "page=2",
+			wantLast: "page=3",
+		},
+		{
+			name: "last page of list has no link",
+			path: "/repos/sataga/issue-warehouse/issues?labels=PF_Support&page=3&per_page=2",
+			want: []int{1},
+		},
+		{
+			name: "list since updated time",
+			path: "/repos/sataga/issue-warehouse/issues?since=2020-10-04T00:00:00Z",
+			want: []int{5, 4},
+		},
+		{
+			name:     "second page of search",
+			path:     "/search/issues?q=repo%3Asataga%2Fissue-warehouse+created%3A2020-10-02..2020-10-05&page=2&per_page=1",
+			search:   true,
+			want:     []int{4},
+			wantNext: "page=3",
+			wantLast: "page=4",
+		},
+		{
+			name:   "search under the API prefix of GitHub Enterprise",
+			path:   "/api/v3/search/issues?q=repo%3Asataga%2Fissue-warehouse+created%3A%3C2020-10-03",
+			search: true,
+			want:   []int{2, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []*github.Issue
+			var header http.Header
+			if tt.search {
+				var result github.IssuesSearchResult
+				header = get(t, tt.path, &result)
+				for i := range result.Issues {
+					got = append(got, &result.Issues[i])
+				}
+			} else {
+				header = get(t, tt.path, &got)
+			}
+			if !reflect.DeepEqual(numbers(got), tt.want) {
+				t.Errorf("issues = %v, want %v", numbers(got), tt.want)
+			}
+			link := header.Get("Link")
+			if tt.wantNext == "" {
+				if link != "" {
+					t.Errorf("Link = %s, want none", link)
+				}
+				return
+			}
+			rels := strings.Split(link, ", ")
+			if len(rels) != 2 || !strings.Contains(rels[0], tt.wantNext) || !strings.HasSuffix(rels[0], `rel="next"`) ||
+				!strings.Contains(rels[1], tt.wantLast) || !strings.HasSuffix(rels[1], `rel="last"`) {
+				t.Errorf("Link = %s, want next %s and last %s", link, tt.wantNext, tt.wantLast)
+			}
+			// links keep the other parameters
+			if !strings.Contains(rels[0], "per_page=") {
+				t.Errorf("Link = %s, want per_page kept", link)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package github
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/src-d/go-git.v4"
+)
+
+func TestCommit(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &ghclient{user: "sataga", mail: "sataga@example.com"}
+
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("README.md", "reports\n")
+	write("old.md", "old\n")
+	if err := c.Commit(ctx, repo, "initial"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if err := c.Branch(ctx, repo, "publish/report"); err != nil {
+		t.Fatalf("Branch() error = %v", err)
+	}
+	// new file in new directory, modified and deleted files are all committed
+	write("reports/longterm-report/2020-12-01.md", "## サマリー\n")
+	write("README.md", "reports of user support\n")
+	if err := os.Remove(filepath.Join(dir, "old.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Commit(ctx, repo, "add report"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := head.Name().Short(); got != "publish/report" {
+		t.Errorf("HEAD = %s, want publish/report", got)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit.Message != "add report" || commit.Author.Email != "sataga@example.com" {
+		t.Errorf("commit = %q by %s, want add report by sataga@example.com", commit.Message, commit.Author.Email)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.File("reports/longterm-report/2020-12-01.md"); err != nil {
+		t.Errorf("new file is not committed: %v", err)
+	}
+	if f, err := tree.File("README.md"); err != nil {
+		t.Errorf("README.md is not committed: %v", err)
+	} else if content, _ := f.Contents(); content != "reports of user support\n" {
+		t.Errorf("README.md = %q, want modified content", content)
+	}
+	if _, err := tree.File("old.md"); err == nil {
+		t.Error("deleted file is still committed")
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, err := w.Status(); err != nil || !status.IsClean() {
+		t.Errorf("worktree is not clean after Commit(): %v %v", status, err)
+	}
+}
@@ -8,39 +8,53 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	nethttp "net/http"
 	"path"
 	"time"
 
 	"github.com/google/go-github/github"
 	"golang.org/x/oauth2"
 	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
 	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
 )
 
 // Client is a interface that handle about github
 type Client interface {
-	Clone(repoURI string, dir string) (*git.Repository, error)
-	Commit(r *git.Repository, msg string) error
-	Push(r *git.Repository) error
-	PullRequest(owner, repo, title, head, body, baseBranch string) (string, error)
-	ListRepoIssuesSince(owner, repo string, since time.Time, state string, labels []string) ([]*github.Issue, error)
-	ListRepoIssues(owner, repo string, state string, labels []string) ([]*github.Issue, error)
-	GetRepoID(owner, repo string) (int64, error)
-	SearchLabelsByQuery(repoID int64, query string) ([]*github.LabelResult, error)
-	SearchIssuesByQuery(query string) ([]github.Issue, error)
+	Clone(ctx context.Context, repoURI string, dir string) (*git.Repository, error)
+	Branch(ctx context.Context, r *git.Repository, name string) error
+	Commit(ctx context.Context, r *git.Repository, msg string) error
+	Push(ctx context.Context, r *git.Repository) error
+	PullRequest(ctx context.Context, owner, repo, title, head, body, baseBranch string) (string, error)
+	ListRepoIssuesSince(ctx context.Context, owner, repo string, since time.Time, state string, labels []string) ([]*github.Issue, error)
+	ListRepoIssues(ctx context.Context, owner, repo string, state string, labels []string) ([]*github.Issue, error)
+	GetRepoID(ctx context.Context, owner, repo string) (int64, error)
+	ListOrgRepos(ctx context.Context, org string) ([]*github.Repository, error)
+	SearchLabelsByQuery(ctx context.Context, repoID int64, query string) ([]*github.LabelResult, error)
+	SearchIssuesByQuery(ctx context.Context, query string) ([]github.Issue, error)
+	ListIssueComments(ctx context.Context, owner, repo string, number int) ([]*github.IssueComment, error)
+	ListRepoLabels(ctx context.Context, owner, repo string) ([]*github.Label, error)
+	ListIssueEvents(ctx context.Context, owner, repo string, number int) ([]*github.IssueEvent, error)
+	CreateComment(ctx context.Context, owner, repo string, number int, body string) (*github.IssueComment, error)
 }
 
 type ghclient struct {
 	client *github.Client
-	ctx    context.Context
 	user   string
 	mail   string
 	token  string
+	retry  *retrier
 }
 
 // NewGitHubClient create GitHubClient implementation
 func NewGitHubClient(baseURL string, token string, user string, mail string) (Client, error) {
+	return NewGitHubClientWithTransport(baseURL, token, user, mail, nil)
+}
+
+// NewGitHubClientWithTransport creates GitHubClient which sends API requests by transport, such as record and replay transport
+// transport is http.DefaultTransport when nil
+func NewGitHubClientWithTransport(baseURL string, token string, user string, mail string, transport nethttp.RoundTripper) (Client, error) {
 	if baseURL == "" {
 		return nil, errors.New("need to set baseURL")
 	}
@@ -57,6 +71,9 @@ func NewGitHubClient(baseURL string, token string, user string, mail string) (Cl
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
+	if transport != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, &nethttp.Client{Transport: transport})
+	}
 	tc := oauth2.NewClient(ctx, ts)
 
 	cli, err := github.NewEnterpriseClient(baseURL, uploadURL, tc)
@@ -66,15 +83,15 @@ func NewGitHubClient(baseURL string, token string, user string, mail string) (Cl
 
 	c := &ghclient{
 		client: cli,
-		ctx:    ctx,
 		user:   user,
 		mail:   mail,
 		token:  token,
+		retry:  newRetrier(),
 	}
 	return c, nil
 }
 
-func (c *ghclient) Clone(repoURI string, dir string) (*git.Repository, error) {
+func (c *ghclient) Clone(ctx context.Context, repoURI string, dir string) (*git.Repository, error) {
 	o := &git.CloneOptions{
 		Auth: &http.BasicAuth{
 			Username: c.user,
@@ -82,14 +99,51 @@ func (c *ghclient) Clone(repoURI string, dir string) (*git.Repository, error) {
 		},
 		URL: repoURI,
 	}
-	return git.PlainClone(dir, false, o)
+	return git.PlainCloneContext(ctx, dir, false, o)
 }
 
-func (c *ghclient) Commit(repo *git.Repository, msg string) error {
+// Branch creates a branch at HEAD and checks it out
+// it works on the local worktree, so ctx is checked only before it starts
+func (c *ghclient) Branch(ctx context.Context, repo *git.Repository, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	w, err := repo.Worktree()
 	if err != nil {
 		return err
 	}
+	return w.Checkout(&git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName(name),
+		Create: true,
+	})
+}
+
+// Commit stages every change of the worktree including new and deleted files, and commits it
+func (c *ghclient) Commit(ctx context.Context, repo *git.Repository, msg string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	status, err := w.Status()
+	if err != nil {
+		return fmt.Errorf("worktree status: %s", err)
+	}
+	for file, s := range status {
+		if s.Worktree == git.Unmodified {
+			continue
+		}
+		if s.Worktree == git.Deleted {
+			_, err = w.Remove(file)
+		} else {
+			_, err = w.Add(file)
+		}
+		if err != nil {
+			return fmt.Errorf("stage %s: %s", file, err)
+		}
+	}
 	o := &git.CommitOptions{
 		Author: &object.Signature{
 			Name:  c.user,
@@ -101,150 +155,293 @@ func (c *ghclient) Commit(repo *git.Repository, msg string) error {
 	return err
 }
 
-func (c *ghclient) Push(repo *git.Repository) error {
+func (c *ghclient) Push(ctx context.Context, repo *git.Repository) error {
 	o := &git.PushOptions{
 		Auth: &http.BasicAuth{
 			Username: c.user,
 			Password: c.token,
 		},
 	}
-	return repo.Push(o)
+	return repo.PushContext(ctx, o)
 }
 
-func (c *ghclient) PullRequest(owner, repo, title, head, body, baseBranch string) (string, error) {
+func (c *ghclient) PullRequest(ctx context.Context, owner, repo, title, head, body, baseBranch string) (string, error) {
 	npr := github.NewPullRequest{
 		Title: &title,
 		Head:  &head,
 		Base:  &baseBranch,
 		Body:  &body,
 	}
-	pr, _, err := c.client.PullRequests.Create(c.ctx, owner, repo, &npr)
+	var pr *github.PullRequest
+	err := c.retry.doWrite(ctx, func() (resp *github.Response, err error) {
+		pr, resp, err = c.client.PullRequests.Create(ctx, owner, repo, &npr)
+		return resp, err
+	})
 	if err != nil {
-		return "", fmt.Errorf("creating PullRequest : %s", err)
+		return "", fmt.Errorf("creating PullRequest : %w", err)
 	}
 	prURL := pr.GetHTMLURL()
 	log.Printf("PullRequest created: %s", prURL)
 	return prURL, nil
 }
 
-func listRepoIssues(listFunc func(pageIdx int) ([]*github.Issue, *github.Response, error)) ([]*github.Issue, error) {
-	maxTry := 20 // limit requests for safety
+// paginate calls list for pages from the first one until the last one
+// list appends results of the page and returns its response
+func paginate(what string, list func(pageIdx int) (*github.Response, error)) error {
 	pageIdx := 1
-	issues := make([]*github.Issue, 0)
-	for ; maxTry > 0; maxTry-- {
-		iss, resp, err := listFunc(pageIdx)
+	for {
+		resp, err := list(pageIdx)
 		if err != nil {
-			return nil, fmt.Errorf("list issues from repo: %s, pageIdx %d, lastPageIdx %d", err, pageIdx, resp.LastPage)
+			return fmt.Errorf("list %s: %w, pageIdx %d", what, err, pageIdx)
 		}
-		issues = append(issues, iss...)
-		// last page index is 0 when no more pagination
-		if resp.LastPage == 0 {
-			break
+		// next page index is 0 on the last page, and it never goes back
+		if resp == nil || resp.NextPage <= pageIdx {
+			return nil
 		}
 		pageIdx = resp.NextPage
 	}
-	if maxTry == 0 {
-		return issues, fmt.Errorf("list issues reached to max try: %d", maxTry)
-	}
-	return issues, nil
 }
 
 // ListRepoIssues lists issues since
-func (c *ghclient) ListRepoIssuesSince(owner, repo string, since time.Time, state string, labels []string) ([]*github.Issue, error) {
-	return listRepoIssues(func(pageIdx int) ([]*github.Issue, *github.Response, error) {
-		return c.client.Issues.ListByRepo(c.ctx, owner, repo, &github.IssueListByRepoOptions{
-			State:  state,
-			Labels: labels,
-			Since:  since,
-			ListOptions: github.ListOptions{
-				Page:    pageIdx,
-				PerPage: 30,
-			},
+func (c *ghclient) ListRepoIssuesSince(ctx context.Context, owner, repo string, since time.Time, state string, labels []string) ([]*github.Issue, error) {
+	issues := make([]*github.Issue, 0)
+	err := paginate("issues of "+owner+"/"+repo, func(pageIdx int) (resp *github.Response, err error) {
+		var result []*github.Issue
+		err = c.retry.do(ctx, func() (*github.Response, error) {
+			result, resp, err = c.client.Issues.ListByRepo(ctx, owner, repo, &github.IssueListByRepoOptions{
+				State:  state,
+				Labels: labels,
+				Since:  since,
+				ListOptions: github.ListOptions{
+					Page:    pageIdx,
+					PerPage: 100,
+				},
+			})
+			return resp, err
 		})
+		issues = append(issues, result...)
+		return resp, err
 	})
+	if err != nil {
+		return nil, err
+	}
+	return issues, nil
 }
 
 // ListRepoIssues lists issues
-func (c *ghclient) ListRepoIssues(owner, repo string, state string, labels []string) ([]*github.Issue, error) {
-	return listRepoIssues(func(pageIdx int) ([]*github.Issue, *github.Response, error) {
-		return c.client.Issues.ListByRepo(c.ctx, owner, repo, &github.IssueListByRepoOptions{
-			State:  state,
-			Labels: labels,
-			ListOptions: github.ListOptions{
-				Page:    pageIdx,
-				PerPage: 30,
-			},
+func (c *ghclient) ListRepoIssues(ctx context.Context, owner, repo string, state string, labels []string) ([]*github.Issue, error) {
+	issues := make([]*github.Issue, 0)
+	err := paginate("issues of "+owner+"/"+repo, func(pageIdx int) (resp *github.Response, err error) {
+		var result []*github.Issue
+		err = c.retry.do(ctx, func() (*github.Response, error) {
+			result, resp, err = c.client.Issues.ListByRepo(ctx, owner, repo, &github.IssueListByRepoOptions{
+				State:  state,
+				Labels: labels,
+				ListOptions: github.ListOptions{
+					Page:    pageIdx,
+					PerPage: 100,
+				},
+			})
+			return resp, err
 		})
+		issues = append(issues, result...)
+		return resp, err
 	})
+	if err != nil {
+		return nil, err
+	}
+	return issues, nil
 }
 
-func (c *ghclient) GetRepoID(owner, repo string) (int64, error) {
-	repository, _, _ := c.client.Repositories.Get(c.ctx, owner, repo)
-	return *repository.ID, nil
+func (c *ghclient) GetRepoID(ctx context.Context, owner, repo string) (int64, error) {
+	var repository *github.Repository
+	err := c.retry.do(ctx, func() (resp *github.Response, err error) {
+		repository, resp, err = c.client.Repositories.Get(ctx, owner, repo)
+		return resp, err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("get repository %s/%s: %w", owner, repo, err)
+	}
+	return repository.GetID(), nil
 }
 
-func searchLabelsByQuery(listFunc func(pageIdx int) (*github.LabelsSearchResult, *github.Response, error)) ([]*github.LabelResult, error) {
-	maxTry := 20 // limit requests for safety
-	pageIdx := 1
+// ListOrgRepos lists repositories of the organization
+func (c *ghclient) ListOrgRepos(ctx context.Context, org string) ([]*github.Repository, error) {
+	repos := make([]*github.Repository, 0)
+	err := paginate("repos of "+org, func(pageIdx int) (resp *github.Response, err error) {
+		var result []*github.Repository
+		err = c.retry.do(ctx, func() (*github.Response, error) {
+			result, resp, err = c.client.Repositories.ListByOrg(ctx, org, &github.RepositoryListByOrgOptions{
+				ListOptions: github.ListOptions{
+					Page:    pageIdx,
+					PerPage: 100,
+				},
+			})
+			return resp, err
+		})
+		repos = append(repos, result...)
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return repos, nil
+}
+
+func (c *ghclient) SearchLabelsByQuery(ctx context.Context, repoID int64, query string) ([]*github.LabelResult, error) {
 	labels := make([]*github.LabelResult, 0)
-	for ; maxTry > 0; maxTry-- {
-		iss, resp, err := listFunc(pageIdx)
+	err := paginate("labels", func(pageIdx int) (resp *github.Response, err error) {
+		var result *github.LabelsSearchResult
+		err = c.retry.doSearch(ctx, func() (*github.Response, error) {
+			result, resp, err = c.client.Search.Labels(ctx, repoID, query, &github.SearchOptions{
+				ListOptions: github.ListOptions{
+					Page:    pageIdx,
+					PerPage: 100,
+				},
+			})
+			return resp, err
+		})
 		if err != nil {
-			return nil, fmt.Errorf("list labels from repo: %s, pageIdx %d, lastPageIdx %d", err, pageIdx, resp.LastPage)
-		}
-		labels = append(labels, iss.Labels...)
-		// last page index is 0 when no more pagination
-		if resp.LastPage == 0 {
-			break
+			return resp, err
 		}
-		pageIdx = resp.NextPage
-	}
-	if maxTry == 0 {
-		return labels, fmt.Errorf("list labels reached to max try: %d", maxTry)
+		labels = append(labels, result.Labels...)
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return labels, nil
 }
 
-func (c *ghclient) SearchLabelsByQuery(repoID int64, query string) ([]*github.LabelResult, error) {
-	return searchLabelsByQuery(func(pageIdx int) (*github.LabelsSearchResult, *github.Response, error) {
-		return c.client.Search.Labels(c.ctx, repoID, query, &github.SearchOptions{
-			ListOptions: github.ListOptions{
-				Page:    pageIdx,
-				PerPage: 30,
-			},
-		})
-	})
+// maxSearchResults is the number of results search API can return for a query
+const maxSearchResults = 1000
+
+// SearchLimitError is returned when search matches more results than search API can return
+// narrow the query and search again
+type SearchLimitError struct {
+	Total int
+	// Incomplete is true when search timed out before finding every result
+	Incomplete bool
 }
 
+func (e *SearchLimitError) Error() string {
+	if e.Incomplete {
+		return fmt.Sprintf("search results are incomplete (total %d)", e.Total)
+	}
+	return fmt.Sprintf("search matched %d results over the limit %d", e.Total, maxSearchResults)
+}
+
+// searchIssuesByQuery lists pages of search results, it fails with SearchLimitError when search API cannot return every result
 func searchIssuesByQuery(listFunc func(pageIdx int) (*github.IssuesSearchResult, *github.Response, error)) ([]github.Issue, error) {
-	maxTry := 20 // limit requests for safety
-	pageIdx := 1
 	issues := make([]github.Issue, 0)
-	for ; maxTry > 0; maxTry-- {
+	err := paginate("search results", func(pageIdx int) (*github.Response, error) {
 		iss, resp, err := listFunc(pageIdx)
 		if err != nil {
-			return nil, fmt.Errorf("list issues from repo: %s, pageIdx %d, lastPageIdx %d", err, pageIdx, resp.LastPage)
+			return resp, err
 		}
-		issues = append(issues, iss.Issues...)
-		// last page index is 0 when no more pagination
-		if resp.LastPage == 0 {
-			break
+		// search API returns only the first 1000 results
+		if iss.GetTotal() > maxSearchResults || iss.GetIncompleteResults() {
+			return resp, &SearchLimitError{Total: iss.GetTotal(), Incomplete: iss.GetIncompleteResults()}
 		}
-		pageIdx = resp.NextPage
-	}
-	if maxTry == 0 {
-		return issues, fmt.Errorf("list issues reached to max try: %d", maxTry)
+		issues = append(issues, iss.Issues...)
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return issues, nil
 }
 
-func (c *ghclient) SearchIssuesByQuery(query string) ([]github.Issue, error) {
-	return searchIssuesByQuery(func(pageIdx int) (*github.IssuesSearchResult, *github.Response, error) {
-		return c.client.Search.Issues(c.ctx, query, &github.SearchOptions{
-			ListOptions: github.ListOptions{
+// SearchIssuesByQuery searches issues, it fails with SearchLimitError when the query matches over 1000 issues
+func (c *ghclient) SearchIssuesByQuery(ctx context.Context, query string) ([]github.Issue, error) {
+	return searchIssuesByQuery(func(pageIdx int) (result *github.IssuesSearchResult, resp *github.Response, err error) {
+		err = c.retry.doSearch(ctx, func() (*github.Response, error) {
+			result, resp, err = c.client.Search.Issues(ctx, query, &github.SearchOptions{
+				ListOptions: github.ListOptions{
+					Page:    pageIdx,
+					PerPage: 100,
+				},
+			})
+			return resp, err
+		})
+		return result, resp, err
+	})
+}
+
+// ListIssueComments lists comments of the issue
+func (c *ghclient) ListIssueComments(ctx context.Context, owner, repo string, number int) ([]*github.IssueComment, error) {
+	comments := make([]*github.IssueComment, 0)
+	err := paginate(fmt.Sprintf("comments of %s/%s#%d", owner, repo, number), func(pageIdx int) (resp *github.Response, err error) {
+		var result []*github.IssueComment
+		err = c.retry.do(ctx, func() (*github.Response, error) {
+			result, resp, err = c.client.Issues.ListComments(ctx, owner, repo, number, &github.IssueListCommentsOptions{
+				ListOptions: github.ListOptions{
+					Page:    pageIdx,
+					PerPage: 100,
+				},
+			})
+			return resp, err
+		})
+		comments = append(comments, result...)
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// CreateComment posts a comment on the issue
+func (c *ghclient) CreateComment(ctx context.Context, owner, repo string, number int, body string) (*github.IssueComment, error) {
+	var comment *github.IssueComment
+	err := c.retry.doWrite(ctx, func() (resp *github.Response, err error) {
+		comment, resp, err = c.client.Issues.CreateComment(ctx, owner, repo, number, &github.IssueComment{Body: &body})
+		return resp, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating comment : %w", err)
+	}
+	return comment, nil
+}
+
+// ListRepoLabels lists every label of the repository
+func (c *ghclient) ListRepoLabels(ctx context.Context, owner, repo string) ([]*github.Label, error) {
+	labels := make([]*github.Label, 0)
+	err := paginate("labels of "+owner+"/"+repo, func(pageIdx int) (resp *github.Response, err error) {
+		var result []*github.Label
+		err = c.retry.do(ctx, func() (*github.Response, error) {
+			result, resp, err = c.client.Issues.ListLabels(ctx, owner, repo, &github.ListOptions{
 				Page:    pageIdx,
-				PerPage: 30,
-			},
+				PerPage: 100,
+			})
+			return resp, err
 		})
+		labels = append(labels, result...)
+		return resp, err
 	})
+	if err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// ListIssueEvents lists events of the issue such as labeled, unlabeled and closed
+func (c *ghclient) ListIssueEvents(ctx context.Context, owner, repo string, number int) ([]*github.IssueEvent, error) {
+	events := make([]*github.IssueEvent, 0)
+	err := paginate(fmt.Sprintf("events of %s/%s#%d", owner, repo, number), func(pageIdx int) (resp *github.Response, err error) {
+		var result []*github.IssueEvent
+		err = c.retry.do(ctx, func() (*github.Response, error) {
+			result, resp, err = c.client.Issues.ListIssueEvents(ctx, owner, repo, number, &github.ListOptions{
+				Page:    pageIdx,
+				PerPage: 100,
+			})
+			return resp, err
+		})
+		events = append(events, result...)
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
 }
@@ -0,0 +1,196 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/sataga/go-github-sample/infra/fakegithub"
+)
+
+func TestSearchIssuesByQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  *github.IssuesSearchResult
+		want    int
+		wantErr bool
+	}{
+		{
+			name:   "within limit",
+			result: &github.IssuesSearchResult{Total: github.Int(2), IncompleteResults: github.Bool(false), Issues: []github.Issue{{}, {}}},
+			want:   2,
+		},
+		{
+			name:    "over limit",
+			result:  &github.IssuesSearchResult{Total: github.Int(1001), IncompleteResults: github.Bool(false), Issues: []github.Issue{{}}},
+			wantErr: true,
+		},
+		{
+			name:    "incomplete results",
+			result:  &github.IssuesSearchResult{Total: github.Int(10), IncompleteResults: github.Bool(true), Issues: []github.Issue{{}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := searchIssuesByQuery(func(pageIdx int) (*github.IssuesSearchResult, *github.Response, error) {
+				return tt.result, &github.Response{}, nil
+			})
+			var limitErr *SearchLimitError
+			if errors.As(err, &limitErr) != tt.wantErr {
+				t.Fatalf("searchIssuesByQuery() error = %v, want SearchLimitError %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("searchIssuesByQuery() returned %d issues, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name      string
+		lastPage  int
+		wantPages int
+	}{
+		{name: "every page", lastPage: 25, wantPages: 25},
+		{name: "single page", lastPage: 1, wantPages: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := 0
+			err := paginate("issues", func(pageIdx int) (*github.Response, error) {
+				pages++
+				if pageIdx != pages {
+					t.Fatalf("listed page %d, want %d", pageIdx, pages)
+				}
+				resp := &github.Response{}
+				if pageIdx < tt.lastPage {
+					resp.NextPage, resp.LastPage = pageIdx+1, tt.lastPage
+				}
+				return resp, nil
+			})
+			if err != nil {
+				t.Errorf("paginate() error = %v", err)
+			}
+			if pages != tt.wantPages {
+				t.Errorf("paginate() listed %d pages, want %d", pages, tt.wantPages)
+			}
+		})
+	}
+	failed := errors.New("bad gateway")
+	err := paginate("issues", func(pageIdx int) (*github.Response, error) { return nil, failed })
+	if !errors.Is(err, failed) {
+		t.Errorf("paginate() error = %v, want %v", err, failed)
+	}
+}
+
+func fakeRepository(numIssues, numComments int) *fakegithub.Repository {
+	r := &fakegithub.Repository{
+		Repository: &github.Repository{FullName: github.String("sataga/issue-warehouse")},
+		Comments:   make(map[int][]*github.IssueComment),
+		Labels: []*github.Label{
+			{Name: github.String("PF_Support")},
+			{Name: github.String("keyword:Kubernetes")},
+			{Name: github.String("keyword:Network")},
+		},
+	}
+	base := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= numIssues; i++ {
+		created := base.Add(time.Duration(i) * time.Hour)
+		labels := []github.Label{{Name: github.String("PF_Support")}}
+		state := "open"
+		if i%2 == 0 {
+			state = "closed"
+		}
+		if i%5 == 0 {
+			labels = nil
+		}
+		r.Issues = append(r.Issues, &github.Issue{
+			Number:    github.Int(i),
+			Title:     github.String(fmt.Sprintf("issue %d", i)),
+			State:     github.String(state),
+			CreatedAt: &created,
+			UpdatedAt: &created,
+			Labels:    labels,
+		})
+	}
+	for i := 1; i <= numComments; i++ {
+		r.Comments[1] = append(r.Comments[1], &github.IssueComment{ID: github.Int64(int64(i)), Body: github.String("comment")})
+	}
+	return r
+}
+
+func TestGitHubClientWithFakeServer(t *testing.T) {
+	ctx := context.Background()
+	srv := fakegithub.NewServer(fakeRepository(75, 150))
+	defer srv.Close()
+	c, err := NewGitHubClient(srv.URL, "token", "sataga", "sataga@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 75 issues are listed in a page of 100
+	issues, err := c.ListRepoIssues(ctx, "sataga", "issue-warehouse", "all", nil)
+	if err != nil {
+		t.Fatalf("ListRepoIssues() error = %v", err)
+	}
+	if len(issues) != 75 || issues[0].GetNumber() != 75 {
+		t.Errorf("ListRepoIssues() returned %d issues from #%d, want 75 issues from #75", len(issues), issues[0].GetNumber())
+	}
+	// every fifth issue has no support label, and half of the rest are closed
+	issues, err = c.ListRepoIssuesSince(ctx, "sataga", "issue-warehouse", time.Date(2020, 10, 2, 0, 0, 0, 0, time.UTC), "closed", []string{"PF_Support"})
+	if err != nil {
+		t.Fatalf("ListRepoIssuesSince() error = %v", err)
+	}
+	if len(issues) != 21 {
+		t.Errorf("ListRepoIssuesSince() returned %d issues, want 21", len(issues))
+	}
+	comments, err := c.ListIssueComments(ctx, "sataga", "issue-warehouse", 1)
+	if err != nil {
+		t.Fatalf("ListIssueComments() error = %v", err)
+	}
+	if len(comments) != 150 {
+		t.Errorf("ListIssueComments() returned %d comments, want 150", len(comments))
+	}
+
+	found, err := c.SearchIssuesByQuery(ctx, `repo:sataga/issue-warehouse is:issue created:2020-10-01T00:00:00Z..2020-10-02T00:00:00Z label:"PF_Support"`)
+	if err != nil {
+		t.Fatalf("SearchIssuesByQuery() error = %v", err)
+	}
+	// issues 1 to 24 were created in the range, and issues 5, 10, 15 and 20 have no support label
+	if len(found) != 20 || found[0].GetRepositoryURL() != srv.URL+"/repos/sataga/issue-warehouse" {
+		t.Errorf("SearchIssuesByQuery() returned %d issues of %s, want 20", len(found), found[0].GetRepositoryURL())
+	}
+	id, err := c.GetRepoID(ctx, "sataga", "issue-warehouse")
+	if err != nil {
+		t.Fatalf("GetRepoID() error = %v", err)
+	}
+	labels, err := c.SearchLabelsByQuery(ctx, id, "keyword:")
+	if err != nil {
+		t.Fatalf("SearchLabelsByQuery() error = %v", err)
+	}
+	if len(labels) != 2 {
+		t.Errorf("SearchLabelsByQuery() returned %d labels, want 2", len(labels))
+	}
+	repos, err := c.ListOrgRepos(ctx, "sataga")
+	if err != nil {
+		t.Fatalf("ListOrgRepos() error = %v", err)
+	}
+	if len(repos) != 1 || repos[0].GetFullName() != "sataga/issue-warehouse" {
+		t.Errorf("ListOrgRepos() = %v, want sataga/issue-warehouse", repos)
+	}
+
+	if _, err := c.CreateComment(ctx, "sataga", "issue-warehouse", 2, "ping"); err != nil {
+		t.Fatalf("CreateComment() error = %v", err)
+	}
+	if got := srv.Comments("sataga/issue-warehouse", 2); len(got) != 1 || got[0].GetBody() != "ping" || got[0].GetUser().GetLogin() != fakegithub.Login {
+		t.Errorf("comments = %v, want the created comment", got)
+	}
+	if _, err := c.ListIssueEvents(ctx, "sataga", "issue-warehouse", 999); err == nil {
+		t.Error("ListIssueEvents() of missing issue succeeded, want error")
+	}
+}
@@ -5,6 +5,7 @@
 package github
 
 import (
+	context "context"
 	reflect "reflect"
 	time "time"
 
@@ -36,135 +37,224 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 	return m.recorder
 }
 
+// Branch mocks base method.
+func (m *MockClient) Branch(ctx context.Context, r *git.Repository, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Branch", ctx, r, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Branch indicates an expected call of Branch.
+func (mr *MockClientMockRecorder) Branch(ctx, r, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Branch", reflect.TypeOf((*MockClient)(nil).Branch), ctx, r, name)
+}
+
 // Clone mocks base method.
-func (m *MockClient) Clone(repoURI, dir string) (*git.Repository, error) {
+func (m *MockClient) Clone(ctx context.Context, repoURI, dir string) (*git.Repository, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Clone", repoURI, dir)
+	ret := m.ctrl.Call(m, "Clone", ctx, repoURI, dir)
 	ret0, _ := ret[0].(*git.Repository)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Clone indicates an expected call of Clone.
-func (mr *MockClientMockRecorder) Clone(repoURI, dir interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) Clone(ctx, repoURI, dir interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clone", reflect.TypeOf((*MockClient)(nil).Clone), repoURI, dir)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clone", reflect.TypeOf((*MockClient)(nil).Clone), ctx, repoURI, dir)
 }
 
 // Commit mocks base method.
-func (m *MockClient) Commit(r *git.Repository, msg string) error {
+func (m *MockClient) Commit(ctx context.Context, r *git.Repository, msg string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Commit", r, msg)
+	ret := m.ctrl.Call(m, "Commit", ctx, r, msg)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Commit indicates an expected call of Commit.
-func (mr *MockClientMockRecorder) Commit(r, msg interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) Commit(ctx, r, msg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockClient)(nil).Commit), ctx, r, msg)
+}
+
+// CreateComment mocks base method.
+func (m *MockClient) CreateComment(ctx context.Context, owner, repo string, number int, body string) (*github.IssueComment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateComment", ctx, owner, repo, number, body)
+	ret0, _ := ret[0].(*github.IssueComment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateComment indicates an expected call of CreateComment.
+func (mr *MockClientMockRecorder) CreateComment(ctx, owner, repo, number, body interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockClient)(nil).Commit), r, msg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateComment", reflect.TypeOf((*MockClient)(nil).CreateComment), ctx, owner, repo, number, body)
 }
 
 // GetRepoID mocks base method.
-func (m *MockClient) GetRepoID(owner, repo string) (int64, error) {
+func (m *MockClient) GetRepoID(ctx context.Context, owner, repo string) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetRepoID", owner, repo)
+	ret := m.ctrl.Call(m, "GetRepoID", ctx, owner, repo)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetRepoID indicates an expected call of GetRepoID.
-func (mr *MockClientMockRecorder) GetRepoID(owner, repo interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) GetRepoID(ctx, owner, repo interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRepoID", reflect.TypeOf((*MockClient)(nil).GetRepoID), ctx, owner, repo)
+}
+
+// ListIssueComments mocks base method.
+func (m *MockClient) ListIssueComments(ctx context.Context, owner, repo string, number int) ([]*github.IssueComment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListIssueComments", ctx, owner, repo, number)
+	ret0, _ := ret[0].([]*github.IssueComment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListIssueComments indicates an expected call of ListIssueComments.
+func (mr *MockClientMockRecorder) ListIssueComments(ctx, owner, repo, number interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRepoID", reflect.TypeOf((*MockClient)(nil).GetRepoID), owner, repo)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIssueComments", reflect.TypeOf((*MockClient)(nil).ListIssueComments), ctx, owner, repo, number)
+}
+
+// ListIssueEvents mocks base method.
+func (m *MockClient) ListIssueEvents(ctx context.Context, owner, repo string, number int) ([]*github.IssueEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListIssueEvents", ctx, owner, repo, number)
+	ret0, _ := ret[0].([]*github.IssueEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListIssueEvents indicates an expected call of ListIssueEvents.
+func (mr *MockClientMockRecorder) ListIssueEvents(ctx, owner, repo, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIssueEvents", reflect.TypeOf((*MockClient)(nil).ListIssueEvents), ctx, owner, repo, number)
+}
+
+// ListOrgRepos mocks base method.
+func (m *MockClient) ListOrgRepos(ctx context.Context, org string) ([]*github.Repository, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrgRepos", ctx, org)
+	ret0, _ := ret[0].([]*github.Repository)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrgRepos indicates an expected call of ListOrgRepos.
+func (mr *MockClientMockRecorder) ListOrgRepos(ctx, org interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrgRepos", reflect.TypeOf((*MockClient)(nil).ListOrgRepos), ctx, org)
 }
 
 // ListRepoIssues mocks base method.
-func (m *MockClient) ListRepoIssues(owner, repo, state string, labels []string) ([]*github.Issue, error) {
+func (m *MockClient) ListRepoIssues(ctx context.Context, owner, repo, state string, labels []string) ([]*github.Issue, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListRepoIssues", owner, repo, state, labels)
+	ret := m.ctrl.Call(m, "ListRepoIssues", ctx, owner, repo, state, labels)
 	ret0, _ := ret[0].([]*github.Issue)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListRepoIssues indicates an expected call of ListRepoIssues.
-func (mr *MockClientMockRecorder) ListRepoIssues(owner, repo, state, labels interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) ListRepoIssues(ctx, owner, repo, state, labels interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRepoIssues", reflect.TypeOf((*MockClient)(nil).ListRepoIssues), owner, repo, state, labels)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRepoIssues", reflect.TypeOf((*MockClient)(nil).ListRepoIssues), ctx, owner, repo, state, labels)
 }
 
 // ListRepoIssuesSince mocks base method.
-func (m *MockClient) ListRepoIssuesSince(owner, repo string, since time.Time, state string, labels []string) ([]*github.Issue, error) {
+func (m *MockClient) ListRepoIssuesSince(ctx context.Context, owner, repo string, since time.Time, state string, labels []string) ([]*github.Issue, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListRepoIssuesSince", owner, repo, since, state, labels)
+	ret := m.ctrl.Call(m, "ListRepoIssuesSince", ctx, owner, repo, since, state, labels)
 	ret0, _ := ret[0].([]*github.Issue)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListRepoIssuesSince indicates an expected call of ListRepoIssuesSince.
-func (mr *MockClientMockRecorder) ListRepoIssuesSince(owner, repo, since, state, labels interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) ListRepoIssuesSince(ctx, owner, repo, since, state, labels interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRepoIssuesSince", reflect.TypeOf((*MockClient)(nil).ListRepoIssuesSince), ctx, owner, repo, since, state, labels)
+}
+
+// ListRepoLabels mocks base method.
+func (m *MockClient) ListRepoLabels(ctx context.Context, owner, repo string) ([]*github.Label, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRepoLabels", ctx, owner, repo)
+	ret0, _ := ret[0].([]*github.Label)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRepoLabels indicates an expected call of ListRepoLabels.
+func (mr *MockClientMockRecorder) ListRepoLabels(ctx, owner, repo interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRepoIssuesSince", reflect.TypeOf((*MockClient)(nil).ListRepoIssuesSince), owner, repo, since, state, labels)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRepoLabels", reflect.TypeOf((*MockClient)(nil).ListRepoLabels), ctx, owner, repo)
 }
 
 // PullRequest mocks base method.
-func (m *MockClient) PullRequest(owner, repo, title, head, body, baseBranch string) (string, error) {
+func (m *MockClient) PullRequest(ctx context.Context, owner, repo, title, head, body, baseBranch string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PullRequest", owner, repo, title, head, body, baseBranch)
+	ret := m.ctrl.Call(m, "PullRequest", ctx, owner, repo, title, head, body, baseBranch)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // PullRequest indicates an expected call of PullRequest.
-func (mr *MockClientMockRecorder) PullRequest(owner, repo, title, head, body, baseBranch interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) PullRequest(ctx, owner, repo, title, head, body, baseBranch interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PullRequest", reflect.TypeOf((*MockClient)(nil).PullRequest), owner, repo, title, head, body, baseBranch)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PullRequest", reflect.TypeOf((*MockClient)(nil).PullRequest), ctx, owner, repo, title, head, body, baseBranch)
 }
 
 // Push mocks base method.
-func (m *MockClient) Push(r *git.Repository) error {
+func (m *MockClient) Push(ctx context.Context, r *git.Repository) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Push", r)
+	ret := m.ctrl.Call(m, "Push", ctx, r)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Push indicates an expected call of Push.
-func (mr *MockClientMockRecorder) Push(r interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) Push(ctx, r interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Push", reflect.TypeOf((*MockClient)(nil).Push), r)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Push", reflect.TypeOf((*MockClient)(nil).Push), ctx, r)
 }
 
 // SearchIssuesByQuery mocks base method.
-func (m *MockClient) SearchIssuesByQuery(query string) ([]github.Issue, error) {
+func (m *MockClient) SearchIssuesByQuery(ctx context.Context, query string) ([]github.Issue, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SearchIssuesByQuery", query)
+	ret := m.ctrl.Call(m, "SearchIssuesByQuery", ctx, query)
 	ret0, _ := ret[0].([]github.Issue)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SearchIssuesByQuery indicates an expected call of SearchIssuesByQuery.
-func (mr *MockClientMockRecorder) SearchIssuesByQuery(query interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) SearchIssuesByQuery(ctx, query interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchIssuesByQuery", reflect.TypeOf((*MockClient)(nil).SearchIssuesByQuery), query)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchIssuesByQuery", reflect.TypeOf((*MockClient)(nil).SearchIssuesByQuery), ctx, query)
 }
 
 // SearchLabelsByQuery mocks base method.
-func (m *MockClient) SearchLabelsByQuery(repoID int64, query string) ([]*github.LabelResult, error) {
+func (m *MockClient) SearchLabelsByQuery(ctx context.Context, repoID int64, query string) ([]*github.LabelResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SearchLabelsByQuery", repoID, query)
+	ret := m.ctrl.Call(m, "SearchLabelsByQuery", ctx, repoID, query)
 	ret0, _ := ret[0].([]*github.LabelResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SearchLabelsByQuery indicates an expected call of SearchLabelsByQuery.
-func (mr *MockClientMockRecorder) SearchLabelsByQuery(repoID, query interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) SearchLabelsByQuery(ctx, repoID, query interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchLabelsByQuery", reflect.TypeOf((*MockClient)(nil).SearchLabelsByQuery), repoID, query)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchLabelsByQuery", reflect.TypeOf((*MockClient)(nil).SearchLabelsByQuery), ctx, repoID, query)
 }
@@ -0,0 +1,198 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+const (
+	defaultMaxRetries  = 5
+	defaultBaseBackoff = 1 * time.Second
+	defaultMaxBackoff  = 1 * time.Minute
+	// defaultMaxWait is the longest wait until rate limit is reset, longer one fails with RateLimitError
+	defaultMaxWait = 15 * time.Minute
+	// searchInterval keeps search API under its limit of 30 requests per minute
+	searchInterval = 2 * time.Second
+)
+
+// RateLimitError is returned when rate limit is not reset in time
+type RateLimitError struct {
+	Reset time.Time
+	Err   error
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded until %s: %s", e.Reset.Format(time.RFC3339), e.Err)
+}
+
+func (e *RateLimitError) Unwrap() error { return e.Err }
+
+// RetryError is returned when request keeps failing after retries
+type RetryError struct {
+	Attempts int
+	// StatusCode is 0 when no response was returned
+	StatusCode int
+	Err        error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("failed after %d attempts (status %d): %s", e.Attempts, e.StatusCode, e.Err)
+}
+
+func (e *RetryError) Unwrap() error { return e.Err }
+
+// retrier retries GitHub API calls on rate limit and transient errors
+type retrier struct {
+	maxRetries  int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	maxWait     time.Duration
+	now         func() time.Time
+	// sleep returns error of ctx when it is done before d passes
+	sleep func(ctx context.Context, d time.Duration) error
+
+	// search API has secondary rate limit, so every search request shares the interval
+	searchMu   sync.Mutex
+	lastSearch time.Time
+}
+
+func newRetrier() *retrier {
+	return &retrier{
+		maxRetries:  defaultMaxRetries,
+		baseBackoff: defaultBaseBackoff,
+		maxBackoff:  defaultMaxBackoff,
+		maxWait:     defaultMaxWait,
+		now:         time.Now,
+		sleep:       sleepContext,
+	}
+}
+
+// sleepContext sleeps for d, and stops sleeping when ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// do calls the idempotent API until it succeeds, and waits when rate limit remains no more
+// it stops retrying and waiting when ctx is done
+func (r *retrier) do(ctx context.Context, call func() (*github.Response, error)) error {
+	return r.run(ctx, true, call)
+}
+
+// doWrite calls the non-idempotent API such as POST
+// it retries only on rate limits, which reject requests, since GitHub may have applied a request which failed by 5xx or network error
+func (r *retrier) doWrite(ctx context.Context, call func() (*github.Response, error)) error {
+	return r.run(ctx, false, call)
+}
+
+func (r *retrier) run(ctx context.Context, idempotent bool, call func() (*github.Response, error)) error {
+	for attempt := 1; ; attempt++ {
+		resp, err := call()
+		if err == nil {
+			return r.waitRateLimit(ctx, resp)
+		}
+		wait, retryable, err := r.backoff(attempt, resp, err, idempotent)
+		if !retryable {
+			return err
+		}
+		if attempt > r.maxRetries {
+			return &RetryError{Attempts: attempt, StatusCode: statusCode(resp), Err: err}
+		}
+		log.Printf("retry github api in %s (attempt %d): %s", wait, attempt, err)
+		if err := r.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// doSearch calls search API keeping the interval from the last search
+func (r *retrier) doSearch(ctx context.Context, call func() (*github.Response, error)) error {
+	return r.do(ctx, func() (*github.Response, error) {
+		r.searchMu.Lock()
+		if wait := r.lastSearch.Add(searchInterval).Sub(r.now()); wait > 0 {
+			if err := r.sleep(ctx, wait); err != nil {
+				r.searchMu.Unlock()
+				return nil, err
+			}
+		}
+		r.lastSearch = r.now()
+		r.searchMu.Unlock()
+		return call()
+	})
+}
+
+// backoff returns how long to wait before the next attempt, and whether the error is retryable
+// transient errors are retryable only when the request is idempotent
+func (r *retrier) backoff(attempt int, resp *github.Response, err error, idempotent bool) (time.Duration, bool, error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return 0, false, err
+	}
+	switch e := err.(type) {
+	case *github.RateLimitError:
+		wait := e.Rate.Reset.Time.Sub(r.now()) + time.Second
+		if wait > r.maxWait {
+			return 0, false, &RateLimitError{Reset: e.Rate.Reset.Time, Err: err}
+		}
+		return wait, true, err
+	case *github.AbuseRateLimitError:
+		if e.RetryAfter != nil {
+			if *e.RetryAfter > r.maxWait {
+				return 0, false, &RateLimitError{Reset: r.now().Add(*e.RetryAfter), Err: err}
+			}
+			return *e.RetryAfter, true, err
+		}
+		return r.jitter(attempt), true, err
+	}
+	if !idempotent {
+		return 0, false, err
+	}
+	switch code := statusCode(resp); {
+	case code == 0, code >= http.StatusInternalServerError:
+		// network error or transient server error
+		return r.jitter(attempt), true, err
+	}
+	return 0, false, err
+}
+
+// jitter returns exponential backoff with equal jitter, which waits half of the backoff and a random part of the other half
+func (r *retrier) jitter(attempt int) time.Duration {
+	d := r.baseBackoff << uint(attempt-1)
+	if d <= 0 || d > r.maxBackoff {
+		d = r.maxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// waitRateLimit sleeps until reset when no request remains, so the next request does not fail
+func (r *retrier) waitRateLimit(ctx context.Context, resp *github.Response) error {
+	if resp == nil || resp.Rate.Limit == 0 || resp.Rate.Remaining > 0 {
+		return nil
+	}
+	wait := resp.Rate.Reset.Time.Sub(r.now()) + time.Second
+	if wait <= 0 || wait > r.maxWait {
+		return nil
+	}
+	log.Printf("rate limit remains no more, wait %s until reset", wait)
+	return r.sleep(ctx, wait)
+}
+
+func statusCode(resp *github.Response) int {
+	if resp == nil || resp.Response == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
@@ -0,0 +1,220 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+func testResponse(code int) *github.Response {
+	u, _ := url.Parse("https://api.github.com/repos/sataga/issue-warehouse/issues")
+	return &github.Response{Response: &http.Response{
+		StatusCode: code,
+		Request:    &http.Request{Method: "GET", URL: u},
+	}}
+}
+
+func TestRetrierDo(t *testing.T) {
+	now := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	retryAfter := 30 * time.Second
+	reset := errors.New("connection reset")
+	tests := []struct {
+		name string
+		// write calls doWrite instead of do
+		write     bool
+		results   []error
+		responses []*github.Response
+		wantCalls int
+		wantSleep time.Duration
+		wantErr   interface{}
+	}{
+		{
+			name:      "success",
+			results:   []error{nil},
+			wantCalls: 1,
+		},
+		{
+			name: "retry on 5xx",
+			results: []error{
+				&github.ErrorResponse{Response: testResponse(502).Response},
+				nil,
+			},
+			responses: []*github.Response{testResponse(502), testResponse(200)},
+			wantCalls: 2,
+		},
+		{
+			name:      "retry on network error",
+			results:   []error{errors.New("connection reset"), nil},
+			wantCalls: 2,
+		},
+		{
+			name: "no retry on 404",
+			results: []error{
+				&github.ErrorResponse{Response: testResponse(404).Response},
+			},
+			responses: []*github.Response{testResponse(404)},
+			wantCalls: 1,
+			wantErr:   &github.ErrorResponse{},
+		},
+		{
+			name: "wait until rate limit is reset",
+			results: []error{
+				&github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: now.Add(time.Minute)}}, Response: testResponse(403).Response},
+				nil,
+			},
+			responses: []*github.Response{testResponse(403), testResponse(200)},
+			wantCalls: 2,
+			wantSleep: time.Minute + time.Second,
+		},
+		{
+			name: "rate limit reset too late",
+			results: []error{
+				&github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: now.Add(time.Hour)}}, Response: testResponse(403).Response},
+			},
+			responses: []*github.Response{testResponse(403)},
+			wantCalls: 1,
+			wantErr:   &RateLimitError{},
+		},
+		{
+			name: "wait retry after of abuse rate limit",
+			results: []error{
+				&github.AbuseRateLimitError{RetryAfter: &retryAfter, Response: testResponse(403).Response},
+				nil,
+			},
+			responses: []*github.Response{testResponse(403), testResponse(200)},
+			wantCalls: 2,
+			wantSleep: retryAfter,
+		},
+		{
+			name: "give up after max retries",
+			results: []error{
+				&github.ErrorResponse{Response: testResponse(500).Response},
+				&github.ErrorResponse{Response: testResponse(500).Response},
+				&github.ErrorResponse{Response: testResponse(500).Response},
+			},
+			responses: []*github.Response{testResponse(500), testResponse(500), testResponse(500)},
+			wantCalls: 3,
+			wantErr:   &RetryError{},
+		},
+		{
+			name:  "no retry of write on 5xx",
+			write: true,
+			results: []error{
+				&github.ErrorResponse{Response: testResponse(502).Response},
+			},
+			responses: []*github.Response{testResponse(502)},
+			wantCalls: 1,
+			wantErr:   &github.ErrorResponse{},
+		},
+		{
+			name:      "no retry of write on network error",
+			write:     true,
+			results:   []error{reset},
+			wantCalls: 1,
+			wantErr:   reset,
+		},
+		{
+			name:  "retry write on abuse rate limit",
+			write: true,
+			results: []error{
+				&github.AbuseRateLimitError{RetryAfter: &retryAfter, Response: testResponse(403).Response},
+				nil,
+			},
+			responses: []*github.Response{testResponse(403), testResponse(200)},
+			wantCalls: 2,
+			wantSleep: retryAfter,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var slept time.Duration
+			r := newRetrier()
+			r.maxRetries = 2
+			r.now = func() time.Time { return now }
+			r.sleep = func(ctx context.Context, d time.Duration) error { slept += d; return nil }
+			do := r.do
+			if tt.write {
+				do = r.doWrite
+			}
+			calls := 0
+			err := do(context.Background(), func() (*github.Response, error) {
+				var resp *github.Response
+				if calls < len(tt.responses) {
+					resp = tt.responses[calls]
+				}
+				err := tt.results[calls]
+				calls++
+				return resp, err
+			})
+			if calls != tt.wantCalls {
+				t.Errorf("do() called %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantSleep != 0 && slept != tt.wantSleep {
+				t.Errorf("do() slept %s, want %s", slept, tt.wantSleep)
+			}
+			switch want := tt.wantErr.(type) {
+			case nil:
+				if err != nil {
+					t.Errorf("do() error = %v, want nil", err)
+				}
+			case *github.ErrorResponse:
+				if !errors.As(err, &want) {
+					t.Errorf("do() error = %v, want %T", err, want)
+				}
+			case *RateLimitError:
+				if !errors.As(err, &want) {
+					t.Errorf("do() error = %v, want %T", err, want)
+				}
+			case *RetryError:
+				if !errors.As(err, &want) || want.Attempts != 3 || want.StatusCode != 500 {
+					t.Errorf("do() error = %v, want RetryError after 3 attempts", err)
+				}
+			case error:
+				if !errors.Is(err, want) {
+					t.Errorf("do() error = %v, want %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRetrierDoSearch(t *testing.T) {
+	now := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	var slept time.Duration
+	r := newRetrier()
+	r.now = func() time.Time { return now }
+	r.sleep = func(ctx context.Context, d time.Duration) error { slept += d; return nil }
+	for i := 0; i < 3; i++ {
+		if err := r.doSearch(context.Background(), func() (*github.Response, error) { return testResponse(200), nil }); err != nil {
+			t.Fatalf("doSearch() error = %v", err)
+		}
+	}
+	// clock does not move, so the 2nd and 3rd searches wait the interval
+	if want := 2 * searchInterval; slept != want {
+		t.Errorf("doSearch() slept %s, want %s", slept, want)
+	}
+}
+
+func TestRetrierDoCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := newRetrier()
+	r.baseBackoff = time.Hour
+	calls := 0
+	err := r.do(ctx, func() (*github.Response, error) {
+		calls++
+		// canceled while waiting for the next attempt
+		cancel()
+		return testResponse(502), &github.ErrorResponse{Response: testResponse(502).Response}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("do() error = %v, want %v", err, context.Canceled)
+	}
+	if calls != 1 {
+		t.Errorf("do() called %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,163 @@
+package github
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// recordedHeaders are response headers kept in fixtures, Link is needed for pagination
+var recordedHeaders = []string{"Content-Type", "Link"}
+
+// fixture is a recorded response of a request
+type fixture struct {
+	Method     string          `json:"method"`
+	URL        string          `json:"url"`
+	StatusCode int             `json:"status_code"`
+	Header     http.Header     `json:"header"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// fixtureName returns file name of the request, which does not depend on the host so fixtures are replayed against any base URL
+// query is hashed because search query can be long
+func fixtureName(req *http.Request) string {
+	name := strings.ToLower(req.Method) + strings.Replace(req.URL.Path, "/", "_", -1)
+	if req.URL.RawQuery != "" {
+		sum := sha1.Sum([]byte(req.URL.Query().Encode()))
+		name += "_" + hex.EncodeToString(sum[:4])
+	}
+	return name + ".json"
+}
+
+// requestURI returns path and query of the request, which is written in fixture for readers
+func requestURI(req *http.Request) string {
+	if req.URL.RawQuery == "" {
+		return req.URL.Path
+	}
+	return req.URL.Path + "?" + req.URL.Query().Encode()
+}
+
+type recordTransport struct {
+	dir  string
+	base http.RoundTripper
+}
+
+// NewRecordTransport creates transport which sends requests by base and writes every response to a fixture file in dir
+// base is http.DefaultTransport when nil
+func NewRecordTransport(dir string, base http.RoundTripper) (http.RoundTripper, error) {
+	if dir == "" {
+		return nil, errors.New("need to set record dir")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create record dir: %s", err)
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &recordTransport{dir: dir, base: base}, nil
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read response of %s %s: %s", req.Method, req.URL.Path, err)
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	f := &fixture{
+		Method:     req.Method,
+		URL:        requestURI(req),
+		StatusCode: resp.StatusCode,
+		Header:     make(http.Header),
+	}
+	for _, h := range recordedHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			f.Header.Set(h, v)
+		}
+	}
+	if len(body) > 0 {
+		if !json.Valid(body) {
+			return nil, fmt.Errorf("response of %s %s is not JSON", req.Method, req.URL.Path)
+		}
+		f.Body = body
+	}
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode fixture of %s %s: %s", req.Method, req.URL.Path, err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(t.dir, fixtureName(req)), b, 0644); err != nil {
+		return nil, fmt.Errorf("write fixture of %s %s: %s", req.Method, req.URL.Path, err)
+	}
+	return resp, nil
+}
+
+type replayTransport struct {
+	dir string
+}
+
+// NewReplayTransport creates transport which responds with fixtures written by record transport, without network
+// a request which was not recorded gets 404, so it fails without retry
+func NewReplayTransport(dir string) (http.RoundTripper, error) {
+	if dir == "" {
+		return nil, errors.New("need to set replay dir")
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("replay dir: %s", err)
+	}
+	return &replayTransport{dir: dir}, nil
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	b, err := ioutil.ReadFile(filepath.Join(t.dir, fixtureName(req)))
+	if os.IsNotExist(err) {
+		msg, _ := json.Marshal(map[string]string{
+			"message": fmt.Sprintf("no recorded response for %s %s", req.Method, requestURI(req)),
+		})
+		return newResponse(req, http.StatusNotFound, http.Header{"Content-Type": {"application/json"}}, msg), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read fixture of %s %s: %s", req.Method, req.URL.Path, err)
+	}
+	var f fixture
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("decode fixture of %s %s: %s", req.Method, req.URL.Path, err)
+	}
+	var body []byte
+	if len(f.Body) > 0 && string(f.Body) != "null" {
+		body = f.Body
+	}
+	return newResponse(req, f.StatusCode, f.Header, body), nil
+}
+
+func newResponse(req *http.Request, code int, header http.Header, body []byte) *http.Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode:    code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
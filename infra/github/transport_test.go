@@ -0,0 +1,73 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/sataga/go-github-sample/infra/fakegithub"
+)
+
+func TestRecordAndReplayTransport(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srv := fakegithub.NewServer(fakeRepository(145, 0))
+	rt, err := NewRecordTransport(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewGitHubClientWithTransport(srv.URL, "token", "sataga", "sataga@example.com", rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := c.ListRepoIssues(ctx, "sataga", "issue-warehouse", "all", nil)
+	if err != nil {
+		t.Fatalf("ListRepoIssues() error = %v", err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Errorf("recorded %d fixtures, want 2 pages", len(files))
+	}
+	// replay does not need the server
+	srv.Close()
+
+	rt, err = NewReplayTransport(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err = NewGitHubClientWithTransport("http://127.0.0.1:1", "token", "sataga", "sataga@example.com", rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := c.ListRepoIssues(ctx, "sataga", "issue-warehouse", "all", nil)
+	if err != nil {
+		t.Fatalf("ListRepoIssues() error = %v", err)
+	}
+	if len(replayed) != len(recorded) || len(replayed) != 145 {
+		t.Fatalf("replayed %d issues, want %d recorded ones", len(replayed), len(recorded))
+	}
+	for i := range recorded {
+		if replayed[i].GetNumber() != recorded[i].GetNumber() || replayed[i].GetTitle() != recorded[i].GetTitle() {
+			t.Errorf("replayed issue %d = #%d, want #%d", i, replayed[i].GetNumber(), recorded[i].GetNumber())
+		}
+	}
+
+	// request which was not recorded fails without retry
+	_, err = c.ListRepoIssues(ctx, "sataga", "issue-warehouse", "closed", nil)
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response.StatusCode != http.StatusNotFound {
+		t.Errorf("ListRepoIssues() of not recorded request error = %v, want 404", err)
+	}
+}
@@ -0,0 +1,123 @@
+// Package metrics exports stats of support issues as prometheus metrics
+package metrics
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dus "github.com/sataga/go-github-sample/domain/usersupport"
+)
+
+const namespace = "usersupport"
+
+// ResolutionBuckets are upper bounds of resolution time histogram in hours
+var ResolutionBuckets = []float64{1, 4, 8, 24, 48, 120, 240, 480, 720}
+
+var (
+	openIssuesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "open_issues"),
+		"Number of open support issues.",
+		[]string{"urgency", "team", "genre"}, nil,
+	)
+	staleIssuesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "stale_issues"),
+		"Number of open support issues which have not been updated for day_ago days.",
+		[]string{"day_ago"}, nil,
+	)
+	resolutionHoursDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "resolution_hours"),
+		"Hours from created to closed of support issues closed in the last window_days days.",
+		[]string{"urgency", "team", "genre", "window_days"}, nil,
+	)
+	lastRefreshDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_refresh_timestamp_seconds"),
+		"Unix time when the stats were refreshed successfully.",
+		nil, nil,
+	)
+)
+
+// Collector is prometheus collector of the latest stats
+// stats are refreshed in background, so scrapes never call GitHub API
+type Collector struct {
+	mu          sync.RWMutex
+	stats       *dus.MetricsStats
+	refreshedAt time.Time
+
+	refreshErrors prometheus.Counter
+}
+
+// NewCollector creates Collector which has no stats yet
+func NewCollector() *Collector {
+	return &Collector{
+		refreshErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "refresh_errors_total",
+			Help:      "Number of failures to refresh the stats.",
+		}),
+	}
+}
+
+// Update replaces the stats exported
+func (c *Collector) Update(stats *dus.MetricsStats, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats = stats
+	c.refreshedAt = at
+}
+
+// RecordError counts a failure to refresh, the previous stats are kept exported
+func (c *Collector) RecordError() {
+	c.refreshErrors.Inc()
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- openIssuesDesc
+	ch <- staleIssuesDesc
+	ch <- resolutionHoursDesc
+	ch <- lastRefreshDesc
+	c.refreshErrors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.refreshErrors.Collect(ch)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.stats == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(lastRefreshDesc, prometheus.GaugeValue, float64(c.refreshedAt.Unix()))
+	for ic, n := range c.stats.OpenIssues {
+		ch <- prometheus.MustNewConstMetric(openIssuesDesc, prometheus.GaugeValue, float64(n), ic.Urgency, ic.Team, ic.Genre)
+	}
+	ch <- prometheus.MustNewConstMetric(staleIssuesDesc, prometheus.GaugeValue, float64(c.stats.StaleIssues), strconv.Itoa(c.stats.DayAgo))
+	window := strconv.Itoa(c.stats.WindowDays)
+	for ic, hours := range c.stats.ResolutionHours {
+		count, sum, buckets := histogram(hours)
+		ch <- prometheus.MustNewConstHistogram(resolutionHoursDesc, count, sum, buckets, ic.Urgency, ic.Team, ic.Genre, window)
+	}
+}
+
+// histogram returns count, sum and cumulative counts of ResolutionBuckets
+func histogram(values []int) (uint64, float64, map[float64]uint64) {
+	sorted := make([]int, len(values))
+	copy(sorted, values)
+	sort.Ints(sorted)
+	var sum float64
+	for _, v := range sorted {
+		sum += float64(v)
+	}
+	buckets := make(map[float64]uint64, len(ResolutionBuckets))
+	i := 0
+	for _, le := range ResolutionBuckets {
+		for i < len(sorted) && float64(sorted[i]) <= le {
+			i++
+		}
+		buckets[le] = uint64(i)
+	}
+	return uint64(len(sorted)), sum, buckets
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dus "github.com/sataga/go-github-sample/domain/usersupport"
+)
+
+func TestCollector(t *testing.T) {
+	c := NewCollector()
+	if n := testutil.CollectAndCount(c); n != 1 {
+		t.Errorf("CollectAndCount() before update = %d, want only refresh errors", n)
+	}
+	high := dus.IssueClasses{Urgency: "high", Team: "team_a", Genre: "failure"}
+	low := dus.IssueClasses{Urgency: "low", Team: "team_b", Genre: "normal"}
+	c.Update(&dus.MetricsStats{
+		DayAgo:          7,
+		OpenIssues:      map[dus.IssueClasses]int{high: 2, low: 0},
+		StaleIssues:     1,
+		WindowDays:      30,
+		ResolutionHours: map[dus.IssueClasses][]int{high: {3, 30, 1000}},
+	}, time.Unix(1600000000, 0))
+	c.RecordError()
+
+	want := `
+# HELP usersupport_last_refresh_timestamp_seconds Unix time when the stats were refreshed successfully.
+# TYPE usersupport_last_refresh_timestamp_seconds gauge
+usersupport_last_refresh_timestamp_seconds 1.6e+09
+# HELP usersupport_open_issues Number of open support issues.
+# TYPE usersupport_open_issues gauge
+usersupport_open_issues{genre="failure",team="team_a",urgency="high"} 2
+usersupport_open_issues{genre="normal",team="team_b",urgency="low"} 0
+# HELP usersupport_refresh_errors_total Number of failures to refresh the stats.
+# TYPE usersupport_refresh_errors_total counter
+usersupport_refresh_errors_total 1
+# HELP usersupport_resolution_hours Hours from created to closed of support issues closed in the last window_days days.
+# TYPE usersupport_resolution_hours histogram
+usersupport_resolution_hours_bucket{genre="failure",team="team_a",urgency="high",window_days="30",le="1"} 0
+usersupport_resolution_hours_bucket{genre="failure",team="team_a",urgency="high",window_days="30",le="4"} 1
+usersupport_resolution_hours_bucket{genre="failure",team="team_a",urgency="high",window_days="30",le="8"} 1
+usersupport_resolution_hours_bucket{genre="failure",team="team_a",urgency="high",window_days="30",le="24"} 1
+usersupport_resolution_hours_bucket{genre="failure",team="team_a",urgency="high",window_days="30",le="48"} 2
+usersupport_resolution_hours_bucket{genre="failure",team="team_a",urgency="high",window_days="30",le="120"} 2
+usersupport_resolution_hours_bucket{genre="failure",team="team_a",urgency="high",window_days="30",le="240"} 2
+usersupport_resolution_hours_bucket{genre="failure",team="team_a",urgency="high",window_days="30",le="480"} 2
+usersupport_resolution_hours_bucket{genre="failure",team="team_a",urgency="high",window_days="30",le="720"} 2
+usersupport_resolution_hours_bucket{genre="failure",team="team_a",urgency="high",window_days="30",le="+Inf"} 3
+usersupport_resolution_hours_sum{genre="failure",team="team_a",urgency="high",window_days="30"} 1033
+usersupport_resolution_hours_count{genre="failure",team="team_a",urgency="high",window_days="30"} 3
+# HELP usersupport_stale_issues Number of open support issues which have not been updated for day_ago days.
+# TYPE usersupport_stale_issues gauge
+usersupport_stale_issues{day_ago="7"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
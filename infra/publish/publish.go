@@ -0,0 +1,144 @@
+// Package publish commits generated reports to a git repository and opens a pull request
+package publish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	igh "github.com/sataga/go-github-sample/infra/github"
+)
+
+// Config is a repository which reports are published to
+type Config struct {
+	// Repository is full name (owner/name) of the repository
+	Repository string `yaml:"repository"`
+	// CloneURL is URL to clone the repository, https://github.com/<Repository>.git when empty
+	CloneURL string `yaml:"clone_url"`
+	// BaseBranch is the branch which pull requests are opened against
+	BaseBranch string `yaml:"base_branch"`
+	// Dir is the directory in the repository, reports are written to <Dir>/<report>/<date>.md
+	Dir string `yaml:"dir"`
+}
+
+// Validate checks the repository is set
+func (c *Config) Validate() error {
+	if c.Repository == "" {
+		return nil
+	}
+	if parts := strings.Split(c.Repository, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("publish repository must be owner/name: %s", c.Repository)
+	}
+	if path.IsAbs(c.Dir) || strings.HasPrefix(path.Clean(c.Dir), "..") {
+		return fmt.Errorf("publish dir must be relative in the repository: %s", c.Dir)
+	}
+	return nil
+}
+
+// Report is a generated report to publish
+type Report struct {
+	// Name is name of the report such as longterm-report
+	Name string
+	// Date is used for the file name
+	Date    time.Time
+	Content string
+	// Summary is written in body of the pull request
+	Summary string
+}
+
+// Publisher publishes reports via pull request
+type Publisher struct {
+	client igh.Client
+	cfg    Config
+	now    func() time.Time
+}
+
+// NewPublisher creates Publisher
+func NewPublisher(client igh.Client, cfg Config) (*Publisher, error) {
+	if cfg.Repository == "" {
+		return nil, errors.New("need to set publish repository")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.CloneURL == "" {
+		cfg.CloneURL = "https://github.com/" + cfg.Repository + ".git"
+	}
+	if cfg.BaseBranch == "" {
+		cfg.BaseBranch = "master"
+	}
+	return &Publisher{
+		client: client,
+		cfg:    cfg,
+		now:    time.Now,
+	}, nil
+}
+
+// Path returns path of the report in the repository
+func (p *Publisher) Path(r *Report) string {
+	return path.Join(p.cfg.Dir, r.Name, r.Date.Format("2006-01-02")+".md")
+}
+
+// Publish clones the repository, commits the report on a new branch, pushes it and opens a pull request
+// it returns URL of the pull request
+func (p *Publisher) Publish(ctx context.Context, r *Report) (string, error) {
+	dir, err := ioutil.TempDir("", "publish")
+	if err != nil {
+		return "", fmt.Errorf("create work dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	repo, err := p.client.Clone(ctx, p.cfg.CloneURL, dir)
+	if err != nil {
+		return "", fmt.Errorf("clone %s: %s", p.cfg.Repository, err)
+	}
+	branch := fmt.Sprintf("report/%s-%s", r.Name, p.now().Format("20060102-150405"))
+	if err := p.client.Branch(ctx, repo, branch); err != nil {
+		return "", fmt.Errorf("create branch %s: %s", branch, err)
+	}
+	reportPath := p.Path(r)
+	file := filepath.Join(dir, filepath.FromSlash(reportPath))
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return "", fmt.Errorf("create report dir: %s", err)
+	}
+	if err := ioutil.WriteFile(file, []byte(r.Content), 0644); err != nil {
+		return "", fmt.Errorf("write report: %s", err)
+	}
+	title := fmt.Sprintf("Add %s of %s", r.Name, r.Date.Format("2006-01-02"))
+	if err := p.client.Commit(ctx, repo, title); err != nil {
+		return "", fmt.Errorf("commit report: %s", err)
+	}
+	if err := p.client.Push(ctx, repo); err != nil {
+		return "", fmt.Errorf("push %s: %s", branch, err)
+	}
+	owner, name := splitFullName(p.cfg.Repository)
+	url, err := p.client.PullRequest(ctx, owner, name, title, branch, p.body(r, reportPath), p.cfg.BaseBranch)
+	if err != nil {
+		return "", err
+	}
+	return url, nil
+}
+
+func (p *Publisher) body(r *Report, reportPath string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s を `%s` に追加します。\n", r.Name, reportPath))
+	if r.Summary != "" {
+		sb.WriteString("\n")
+		sb.WriteString(r.Summary)
+		if !strings.HasSuffix(r.Summary, "\n") {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+func splitFullName(fullName string) (string, string) {
+	parts := strings.SplitN(fullName, "/", 2)
+	return parts[0], parts[1]
+}
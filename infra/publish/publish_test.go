@@ -0,0 +1,81 @@
+package publish
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	igh "github.com/sataga/go-github-sample/infra/github"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+func TestPublisher_Publish(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	client := igh.NewMockClient(c)
+	p, err := NewPublisher(client, Config{Repository: "sataga/support-docs", Dir: "reports"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.now = func() time.Time { return time.Date(2020, 12, 1, 10, 30, 0, 0, time.UTC) }
+	report := &Report{
+		Name:    "longterm-report",
+		Date:    time.Date(2020, 11, 30, 0, 0, 0, 0, time.UTC),
+		Content: "## サマリー \n",
+		Summary: "- 2020-11-01~2020-11-30: 起票 3 件, クローズ 2 件, 合計スコア 2.50",
+	}
+	branch := "report/longterm-report-20201201-103000"
+	var workDir string
+	gomock.InOrder(
+		client.EXPECT().Clone(gomock.Any(), "https://github.com/sataga/support-docs.git", gomock.Any()).DoAndReturn(func(ctx context.Context, uri, dir string) (*git.Repository, error) {
+			workDir = dir
+			return git.PlainInit(dir, false)
+		}),
+		client.EXPECT().Branch(gomock.Any(), gomock.Any(), branch).Return(nil),
+		client.EXPECT().Commit(gomock.Any(), gomock.Any(), "Add longterm-report of 2020-11-30").DoAndReturn(func(ctx context.Context, r *git.Repository, msg string) error {
+			b, err := ioutil.ReadFile(filepath.Join(workDir, "reports", "longterm-report", "2020-11-30.md"))
+			if err != nil {
+				t.Errorf("report is not written before commit: %v", err)
+			} else if string(b) != report.Content {
+				t.Errorf("report = %q, want %q", b, report.Content)
+			}
+			return nil
+		}),
+		client.EXPECT().Push(gomock.Any(), gomock.Any()).Return(nil),
+		client.EXPECT().PullRequest(gomock.Any(), "sataga", "support-docs", "Add longterm-report of 2020-11-30", branch,
+			"longterm-report を `reports/longterm-report/2020-11-30.md` に追加します。\n\n- 2020-11-01~2020-11-30: 起票 3 件, クローズ 2 件, 合計スコア 2.50\n",
+			"master").Return("https://github.com/sataga/support-docs/pull/1", nil),
+	)
+
+	got, err := p.Publish(context.Background(), report)
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if got != "https://github.com/sataga/support-docs/pull/1" {
+		t.Errorf("Publish() = %s, want URL of the pull request", got)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "not configured", cfg: Config{}},
+		{name: "valid", cfg: Config{Repository: "sataga/support-docs", Dir: "reports/usersupport"}},
+		{name: "invalid repository", cfg: Config{Repository: "support-docs"}, wantErr: true},
+		{name: "outside of repository", cfg: Config{Repository: "sataga/support-docs", Dir: "../reports"}, wantErr: true},
+		{name: "absolute dir", cfg: Config{Repository: "sataga/support-docs", Dir: "/reports"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
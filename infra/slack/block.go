@@ -0,0 +1,116 @@
+package slack
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// limits of Block Kit
+// https://api.slack.com/reference/block-kit/blocks
+const (
+	maxBlocksPerMessage = 50
+	maxHeaderTextLength = 150
+	maxSectionTextLen   = 3000
+	maxFieldTextLength  = 2000
+	maxFieldsPerSection = 10
+	maxMessageTextLen   = 40000
+)
+
+// Block is a layout block of Block Kit
+type Block struct {
+	Type   string        `json:"type"`
+	Text   *TextObject   `json:"text,omitempty"`
+	Fields []*TextObject `json:"fields,omitempty"`
+}
+
+// TextObject is a text composition object of Block Kit
+type TextObject struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// HeaderBlock creates header block
+func HeaderBlock(text string) Block {
+	return Block{
+		Type: "header",
+		Text: &TextObject{Type: "plain_text", Text: truncate(text, maxHeaderTextLength)},
+	}
+}
+
+// SectionBlock creates section block of mrkdwn text
+func SectionBlock(text string) Block {
+	return Block{
+		Type: "section",
+		Text: &TextObject{Type: "mrkdwn", Text: truncateMrkdwn(text, maxSectionTextLen)},
+	}
+}
+
+// FieldsBlock creates section block of mrkdwn fields
+func FieldsBlock(fields ...string) Block {
+	b := Block{Type: "section"}
+	for i, f := range fields {
+		if i == maxFieldsPerSection {
+			break
+		}
+		b.Fields = append(b.Fields, &TextObject{Type: "mrkdwn", Text: truncateMrkdwn(f, maxFieldTextLength)})
+	}
+	return b
+}
+
+// DividerBlock creates divider block
+func DividerBlock() Block {
+	return Block{Type: "divider"}
+}
+
+// textLen returns number of characters in the block
+func (b Block) textLen() int {
+	n := 0
+	if b.Text != nil {
+		n += utf8.RuneCountInString(b.Text.Text)
+	}
+	for _, f := range b.Fields {
+		n += utf8.RuneCountInString(f.Text)
+	}
+	return n
+}
+
+// truncate cuts text to max characters
+func truncate(text string, max int) string {
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	r := []rune(text)
+	return string(r[:max-1]) + "…"
+}
+
+// truncateMrkdwn cuts escaped mrkdwn text to max characters
+// an entity such as &amp; cut in the middle is dropped, otherwise slack shows the rest of it as is
+func truncateMrkdwn(text string, max int) string {
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	cut := string([]rune(text)[:max-1])
+	if i := strings.LastIndexByte(cut, '&'); i >= 0 && len(cut)-i < len("&amp;") && !strings.Contains(cut[i:], ";") {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
+
+// splitBlocks splits blocks into chunks which fit in a message
+func splitBlocks(blocks []Block, maxBlocks, maxChars int) [][]Block {
+	var chunks [][]Block
+	var chunk []Block
+	chars := 0
+	for _, b := range blocks {
+		if len(chunk) > 0 && (len(chunk) == maxBlocks || chars+b.textLen() > maxChars) {
+			chunks = append(chunks, chunk)
+			chunk, chars = nil, 0
+		}
+		chunk = append(chunk, b)
+		chars += b.textLen()
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
@@ -0,0 +1,26 @@
+package slack
+
+import "testing"
+
+func Test_truncateMrkdwn(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		max  int
+		want string
+	}{
+		{name: "short text", text: "a &amp; b", max: 9, want: "a &amp; b"},
+		{name: "cut before entity", text: "ab &lt;c&gt;", max: 4, want: "ab …"},
+		{name: "entity cut in the middle", text: "ab &amp; c", max: 6, want: "ab …"},
+		{name: "entity cut at the semicolon", text: "ab &amp; c", max: 8, want: "ab …"},
+		{name: "whole entity", text: "ab &amp; c", max: 9, want: "ab &amp;…"},
+		{name: "multibyte text", text: "緊急度&gt;高", max: 5, want: "緊急度…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateMrkdwn(tt.text, tt.max); got != tt.want {
+				t.Errorf("truncateMrkdwn() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package slack
+
+import (
+	"fmt"
+	"sort"
+
+	dus "github.com/sataga/go-github-sample/domain/usersupport"
+)
+
+// DailyReportMessage renders daily report in Block Kit
+// blocks which exceed the limits of a message are posted as replies in the thread
+func DailyReportMessage(ds *dus.DailyStats) *Message {
+	return dailyReportMessage(ds, maxBlocksPerMessage, maxMessageTextLen)
+}
+
+func dailyReportMessage(ds *dus.DailyStats, maxBlocks, maxChars int) *Message {
+	details := make([]*dus.DetailStats, 0, len(ds.DetailStats))
+	for _, d := range ds.DetailStats {
+		details = append(details, d)
+	}
+	sort.Slice(details, func(i, j int) bool {
+		return details[i].CreatedAt < details[j].CreatedAt
+	})
+
+	title := fmt.Sprintf("%d日間以上更新がなかったチケット一覧", ds.DayAgo)
+	fields := []string{fmt.Sprintf("*総未更新チケット数*\n%d 件", ds.NumNotUpdatedIssues)}
+	for _, c := range ds.Urgencies() {
+		fields = append(fields, fmt.Sprintf("*緊急度：%s*\n%d 件", dus.EscapeMrkdwn(c.Display), ds.NumUrgencyIssues[c.Class]))
+	}
+	blocks := []Block{
+		HeaderBlock(title),
+		FieldsBlock(fields...),
+		DividerBlock(),
+	}
+	for _, d := range details {
+		assignee := d.Assignee
+		if assignee == "" {
+			assignee = "未アサイン"
+		}
+		blocks = append(blocks, SectionBlock(fmt.Sprintf("<%s|%s>\n経過時間: %dd%dh  緊急度: %s  担当: %s",
+			dus.EscapeMrkdwn(d.HTMLURL), dus.EscapeMrkdwn(d.Title), d.OpenDuration/24, d.OpenDuration%24, dus.EscapeMrkdwn(d.Urgency), dus.EscapeMrkdwn(assignee))))
+	}
+
+	chunks := splitBlocks(blocks, maxBlocks, maxChars)
+	msg := &Message{
+		Text:   fmt.Sprintf("%s: %d 件", title, ds.NumNotUpdatedIssues),
+		Blocks: chunks[0],
+	}
+	for i, chunk := range chunks[1:] {
+		msg.Replies = append(msg.Replies, &Message{
+			Text:   fmt.Sprintf("%s (%d/%d)", title, i+2, len(chunks)),
+			Blocks: chunk,
+		})
+	}
+	return msg
+}
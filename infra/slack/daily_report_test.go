@@ -0,0 +1,100 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	dus "github.com/sataga/go-github-sample/domain/usersupport"
+)
+
+func Test_dailyReportMessage(t *testing.T) {
+	newStats := func(n int) *dus.DailyStats {
+		ds := &dus.DailyStats{
+			NumNotUpdatedIssues: n,
+			NumUrgencyIssues:    map[string]int{dus.ClassUrgencyHigh: n, dus.ClassUrgencyLow: 0},
+			UrgencyClasses:      []*dus.ClassName{{Class: dus.ClassUrgencyHigh, Display: "高"}, {Class: dus.ClassUrgencyLow, Display: "低"}},
+			DetailStats:         make(map[int]*dus.DetailStats, n),
+		}
+		for i := 0; i < n; i++ {
+			ds.DetailStats[i] = &dus.DetailStats{
+				Title:        fmt.Sprintf("issue %02d", i),
+				HTMLURL:      fmt.Sprintf("https://github.com/sataga/issue-warehouse/issues/%d", i),
+				CreatedAt:    fmt.Sprintf("2020-01-%02d", i+1),
+				Urgency:      "高",
+				Assignee:     "@sataga",
+				OpenDuration: 50,
+			}
+		}
+		return ds
+	}
+	escaped := newStats(1)
+	escaped.DetailStats[0].Title = "<!channel> A & B > C"
+	tests := []struct {
+		name        string
+		stats       *dus.DailyStats
+		maxBlocks   int
+		maxChars    int
+		wantBlocks  []int
+		wantSection string
+	}{
+		{
+			name:        "fits in a message",
+			stats:       newStats(2),
+			maxBlocks:   50,
+			maxChars:    40000,
+			wantBlocks:  []int{5},
+			wantSection: "<https://github.com/sataga/issue-warehouse/issues/0|issue 00>\n経過時間: 2d2h  緊急度: 高  担当: @sataga",
+		},
+		{
+			name:        "overflow by number of blocks",
+			stats:       newStats(10),
+			maxBlocks:   5,
+			maxChars:    40000,
+			wantBlocks:  []int{5, 5, 3},
+			wantSection: "<https://github.com/sataga/issue-warehouse/issues/0|issue 00>\n経過時間: 2d2h  緊急度: 高  担当: @sataga",
+		},
+		{
+			name:        "overflow by characters",
+			stats:       newStats(3),
+			maxBlocks:   50,
+			maxChars:    200,
+			wantBlocks:  []int{4, 2},
+			wantSection: "<https://github.com/sataga/issue-warehouse/issues/0|issue 00>\n経過時間: 2d2h  緊急度: 高  担当: @sataga",
+		},
+		{
+			name:        "escapes title",
+			stats:       escaped,
+			maxBlocks:   50,
+			maxChars:    40000,
+			wantBlocks:  []int{4},
+			wantSection: "<https://github.com/sataga/issue-warehouse/issues/0|&lt;!channel&gt; A &amp; B &gt; C>\n経過時間: 2d2h  緊急度: 高  担当: @sataga",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dailyReportMessage(tt.stats, tt.maxBlocks, tt.maxChars)
+			gotBlocks := []int{len(got.Blocks)}
+			for _, r := range got.Replies {
+				gotBlocks = append(gotBlocks, len(r.Blocks))
+			}
+			if fmt.Sprint(gotBlocks) != fmt.Sprint(tt.wantBlocks) {
+				t.Errorf("dailyReportMessage() blocks = %v, want %v", gotBlocks, tt.wantBlocks)
+			}
+			if got.Blocks[0].Type != "header" {
+				t.Errorf("dailyReportMessage() first block = %s, want header", got.Blocks[0].Type)
+			}
+			if len(got.Blocks[1].Fields) != 3 || got.Blocks[1].Fields[1].Text != fmt.Sprintf("*緊急度：高*\n%d 件", len(tt.stats.DetailStats)) {
+				t.Errorf("dailyReportMessage() summary fields = %v, want total and 2 urgencies", got.Blocks[1].Fields)
+			}
+			if section := got.Blocks[3].Text.Text; section != tt.wantSection {
+				t.Errorf("dailyReportMessage() section = %q, want %q", section, tt.wantSection)
+			}
+			for _, r := range got.Replies {
+				if !strings.Contains(r.Text, "日間以上更新がなかったチケット一覧") {
+					t.Errorf("dailyReportMessage() reply text = %s", r.Text)
+				}
+			}
+		})
+	}
+}
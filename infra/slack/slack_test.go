@@ -0,0 +1,232 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordServer records requests of slack and answers them by respond
+type recordServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	messages []*Message
+	auths    []string
+}
+
+func newRecordServer(t *testing.T, respond func(w http.ResponseWriter, n int)) *recordServer {
+	s := &recordServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			t.Errorf("request = %s %s, want POST of JSON", r.Method, r.Header.Get("Content-Type"))
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m Message
+		if err := json.Unmarshal(b, &m); err != nil {
+			t.Errorf("decode message: %s", err)
+		}
+		s.mu.Lock()
+		s.messages = append(s.messages, &m)
+		s.auths = append(s.auths, r.Header.Get("Authorization"))
+		n := len(s.messages)
+		s.mu.Unlock()
+		respond(w, n)
+	}))
+	return s
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		respond func(w http.ResponseWriter, n int)
+		wantErr string
+	}{
+		{
+			name:    "ok",
+			respond: func(w http.ResponseWriter, n int) { w.Write([]byte("ok")) },
+		},
+		{
+			name: "error body",
+			respond: func(w http.ResponseWriter, n int) {
+				w.Write([]byte("invalid_payload"))
+			},
+			wantErr: "invalid_payload",
+		},
+		{
+			name: "non-2xx",
+			respond: func(w http.ResponseWriter, n int) {
+				http.Error(w, "no_service", http.StatusNotFound)
+			},
+			wantErr: "status 404",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newRecordServer(t, tt.respond)
+			defer srv.Close()
+			n, err := NewWebhookNotifier(srv.URL, "#support", "bot")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = n.Notify(ctx, &Message{Text: "report", Replies: []*Message{{Text: "more", Username: "other"}}})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Notify() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Notify() error = %v", err)
+			}
+			// replies are posted as following messages, with channel and username of the notifier by default
+			want := []*Message{
+				{Channel: "#support", Username: "bot", Text: "report"},
+				{Channel: "#support", Username: "other", Text: "more"},
+			}
+			if len(srv.messages) != len(want) {
+				t.Fatalf("posted %d messages, want %d", len(srv.messages), len(want))
+			}
+			for i, m := range srv.messages {
+				if !reflect.DeepEqual(m, want[i]) {
+					t.Errorf("message %d = %+v, want %+v", i, m, want[i])
+				}
+				if srv.auths[i] != "" {
+					t.Errorf("message %d has Authorization %q, want none", i, srv.auths[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWebAPINotifier(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		respond func(w http.ResponseWriter, n int)
+		wantErr string
+	}{
+		{
+			name: "ok",
+			respond: func(w http.ResponseWriter, n int) {
+				w.Write([]byte(`{"ok":true,"ts":"1600000000.000100"}`))
+			},
+		},
+		{
+			name: "ok false",
+			respond: func(w http.ResponseWriter, n int) {
+				w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+			},
+			wantErr: "channel_not_found",
+		},
+		{
+			name: "non-2xx",
+			respond: func(w http.ResponseWriter, n int) {
+				http.Error(w, "ratelimited", http.StatusTooManyRequests)
+			},
+			wantErr: "status 429",
+		},
+		{
+			name: "broken body",
+			respond: func(w http.ResponseWriter, n int) {
+				w.Write([]byte("<html>"))
+			},
+			wantErr: "decode response",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newRecordServer(t, tt.respond)
+			defer srv.Close()
+			n, err := NewWebAPINotifier("xoxb-token", "#support", "bot")
+			if err != nil {
+				t.Fatal(err)
+			}
+			n.(*webAPINotifier).url = srv.URL
+			err = n.Notify(ctx, &Message{Text: "report", Replies: []*Message{{Text: "more"}}})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Notify() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Notify() error = %v", err)
+			}
+			// replies are posted in the thread of the first message
+			want := []*Message{
+				{Channel: "#support", Username: "bot", Text: "report"},
+				{Channel: "#support", Username: "bot", Text: "more", ThreadTS: "1600000000.000100"},
+			}
+			if len(srv.messages) != len(want) {
+				t.Fatalf("posted %d messages, want %d", len(srv.messages), len(want))
+			}
+			for i, m := range srv.messages {
+				if !reflect.DeepEqual(m, want[i]) {
+					t.Errorf("message %d = %+v, want %+v", i, m, want[i])
+				}
+				if srv.auths[i] != "Bearer xoxb-token" {
+					t.Errorf("message %d has Authorization %q, want Bearer token", i, srv.auths[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNotifyCanceled(t *testing.T) {
+	srv := newRecordServer(t, func(w http.ResponseWriter, n int) { w.Write([]byte("ok")) })
+	defer srv.Close()
+	n, err := NewWebhookNotifier(srv.URL, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := n.Notify(ctx, &Message{Text: "report"}); err == nil {
+		t.Error("Notify() with canceled context should fail")
+	}
+	if len(srv.messages) != 0 {
+		t.Errorf("posted %d messages, want none", len(srv.messages))
+	}
+}
+
+func TestNewNotifier(t *testing.T) {
+	tests := []struct {
+		name                       string
+		webhookURL, token, channel string
+		want                       interface{}
+		wantErr                    bool
+	}{
+		{name: "web api", webhookURL: "https://hooks.slack.com/x", token: "xoxb", channel: "#support", want: &webAPINotifier{}},
+		{name: "incoming webhook", webhookURL: "https://hooks.slack.com/x", want: &webhookNotifier{}},
+		{name: "web api needs channel", token: "xoxb", wantErr: true},
+		{name: "nothing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewNotifier(tt.webhookURL, tt.token, tt.channel, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewNotifier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			switch tt.want.(type) {
+			case *webAPINotifier:
+				if _, ok := got.(*webAPINotifier); !ok {
+					t.Errorf("NewNotifier() = %T, want web api notifier", got)
+				}
+			case *webhookNotifier:
+				if _, ok := got.(*webhookNotifier); !ok {
+					t.Errorf("NewNotifier() = %T, want incoming webhook notifier", got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,342 @@
+// Package store is a local on-disk store of issues synced from github
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+const (
+	issuesFile   = "issues.jsonl"
+	commentsFile = "comments.jsonl"
+	labelsFile   = "labels.jsonl"
+	eventsFile   = "events.jsonl"
+	cursorFile   = "cursor.json"
+	// lockFileName is locked while reading or writing, github owner never starts with dot
+	lockFileName = ".lock"
+)
+
+// Store is a directory of JSONL files, which has a sub directory per repository (dir/owner/name)
+// sync, serve and webhook share the store, so reads and writes are serialized by flock of the lock file
+type Store struct {
+	dir string
+	// mu serializes goroutines of the process, where flock of another descriptor may not block on every platform
+	mu sync.RWMutex
+}
+
+// Repository is issues, comments and labels of a repository
+type Repository struct {
+	FullName string
+	// Cursor is the time when the last sync started, issues updated since it are fetched on next sync
+	Cursor time.Time
+	// Issues are keyed by issue number
+	Issues map[int]*github.Issue
+	// Comments are keyed by issue number
+	Comments map[int][]*github.IssueComment
+	// Events are keyed by issue number
+	Events map[int][]*github.IssueEvent
+	Labels []*github.Label
+}
+
+type cursor struct {
+	SyncedAt time.Time `json:"synced_at"`
+}
+
+// commentRecord is a line of comments.jsonl
+type commentRecord struct {
+	IssueNumber int                  `json:"issue_number"`
+	Comment     *github.IssueComment `json:"comment"`
+}
+
+// eventRecord is a line of events.jsonl
+type eventRecord struct {
+	IssueNumber int                `json:"issue_number"`
+	Event       *github.IssueEvent `json:"event"`
+}
+
+// New creates Store in the directory
+func New(dir string) (*Store, error) {
+	if dir == "" {
+		return nil, errors.New("need to set store dir")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create store dir: %s", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+func (s *Store) repoDir(fullName string) (string, error) {
+	parts := strings.Split(fullName, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || parts[0] == ".." || parts[1] == ".." {
+		return "", fmt.Errorf("repository must be owner/name: %s", fullName)
+	}
+	return filepath.Join(s.dir, parts[0], parts[1]), nil
+}
+
+// lock locks the store for reading or writing, and returns the function which unlocks it
+func (s *Store) lock(exclusive bool) (func(), error) {
+	lockMu, unlockMu := s.mu.RLock, s.mu.RUnlock
+	if exclusive {
+		lockMu, unlockMu = s.mu.Lock, s.mu.Unlock
+	}
+	lockMu()
+	f, err := os.OpenFile(filepath.Join(s.dir, lockFileName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		unlockMu()
+		return nil, fmt.Errorf("open lock file: %s", err)
+	}
+	if err := lockFile(f, exclusive); err != nil {
+		f.Close()
+		unlockMu()
+		return nil, fmt.Errorf("lock store: %s", err)
+	}
+	return func() {
+		// closing the file releases flock
+		f.Close()
+		unlockMu()
+	}, nil
+}
+
+// Load reads data of the repository
+// it returns empty Repository whose Cursor is zero when the repository has never been synced
+func (s *Store) Load(fullName string) (*Repository, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return s.load(fullName)
+}
+
+// Save writes data of the repository, use Update to modify data which is loaded
+func (s *Store) Save(r *Repository) error {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.save(r)
+}
+
+// Update loads data of the repository, modifies it by fn and saves it while the store is locked,
+// so updates of other processes are not lost between load and save
+// fn returns false when it changes nothing, then data is not saved
+func (s *Store) Update(fullName string, fn func(r *Repository) (bool, error)) (*Repository, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	r, err := s.load(fullName)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := fn(r)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return r, nil
+	}
+	if err := s.save(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (s *Store) load(fullName string) (*Repository, error) {
+	dir, err := s.repoDir(fullName)
+	if err != nil {
+		return nil, err
+	}
+	r := &Repository{
+		FullName: fullName,
+		Issues:   make(map[int]*github.Issue),
+		Comments: make(map[int][]*github.IssueComment),
+		Events:   make(map[int][]*github.IssueEvent),
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, cursorFile))
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read cursor of %s: %s", fullName, err)
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("decode cursor of %s: %s", fullName, err)
+	}
+	r.Cursor = c.SyncedAt
+
+	if err := readLines(filepath.Join(dir, issuesFile), func(line []byte) error {
+		var is github.Issue
+		if err := json.Unmarshal(line, &is); err != nil {
+			return err
+		}
+		r.Issues[is.GetNumber()] = &is
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("read issues of %s: %s", fullName, err)
+	}
+	if err := readLines(filepath.Join(dir, commentsFile), func(line []byte) error {
+		var c commentRecord
+		if err := json.Unmarshal(line, &c); err != nil {
+			return err
+		}
+		r.Comments[c.IssueNumber] = append(r.Comments[c.IssueNumber], c.Comment)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("read comments of %s: %s", fullName, err)
+	}
+	if err := readLines(filepath.Join(dir, eventsFile), func(line []byte) error {
+		var e eventRecord
+		if err := json.Unmarshal(line, &e); err != nil {
+			return err
+		}
+		r.Events[e.IssueNumber] = append(r.Events[e.IssueNumber], e.Event)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("read events of %s: %s", fullName, err)
+	}
+	if err := readLines(filepath.Join(dir, labelsFile), func(line []byte) error {
+		var l github.Label
+		if err := json.Unmarshal(line, &l); err != nil {
+			return err
+		}
+		r.Labels = append(r.Labels, &l)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("read labels of %s: %s", fullName, err)
+	}
+	return r, nil
+}
+
+// save writes data of the repository
+// cursor is written last, so the repository is synced again from the previous cursor when saving fails halfway
+func (s *Store) save(r *Repository) error {
+	dir, err := s.repoDir(r.FullName)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create repository dir: %s", err)
+	}
+
+	numbers := make([]int, 0, len(r.Issues))
+	for n := range r.Issues {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	issues := make([]interface{}, 0, len(numbers))
+	comments := make([]interface{}, 0)
+	events := make([]interface{}, 0)
+	for _, n := range numbers {
+		issues = append(issues, r.Issues[n])
+		for _, c := range r.Comments[n] {
+			comments = append(comments, &commentRecord{IssueNumber: n, Comment: c})
+		}
+		for _, e := range r.Events[n] {
+			events = append(events, &eventRecord{IssueNumber: n, Event: e})
+		}
+	}
+	labels := make([]interface{}, 0, len(r.Labels))
+	for _, l := range r.Labels {
+		labels = append(labels, l)
+	}
+
+	if err := writeLines(filepath.Join(dir, issuesFile), issues); err != nil {
+		return fmt.Errorf("write issues of %s: %s", r.FullName, err)
+	}
+	if err := writeLines(filepath.Join(dir, commentsFile), comments); err != nil {
+		return fmt.Errorf("write comments of %s: %s", r.FullName, err)
+	}
+	if err := writeLines(filepath.Join(dir, eventsFile), events); err != nil {
+		return fmt.Errorf("write events of %s: %s", r.FullName, err)
+	}
+	if err := writeLines(filepath.Join(dir, labelsFile), labels); err != nil {
+		return fmt.Errorf("write labels of %s: %s", r.FullName, err)
+	}
+	b, err := json.Marshal(&cursor{SyncedAt: r.Cursor})
+	if err != nil {
+		return fmt.Errorf("encode cursor of %s: %s", r.FullName, err)
+	}
+	if err := writeFile(filepath.Join(dir, cursorFile), func(w io.Writer) error {
+		_, err := w.Write(b)
+		return err
+	}); err != nil {
+		return fmt.Errorf("write cursor of %s: %s", r.FullName, err)
+	}
+	return nil
+}
+
+// readLines calls fn with every line of JSONL file, missing file is regarded as empty
+func readLines(path string, fn func(line []byte) error) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024) // issue body can be long
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		if err := fn(sc.Bytes()); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// writeLines writes values as JSONL file
+func writeLines(path string, values []interface{}) error {
+	return writeFile(path, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		for _, v := range values {
+			if err := enc.Encode(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// writeFile writes to temporary file and renames it, so a reader never sees partially written file
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := write(w); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Load("sataga/issue-warehouse")
+	if err != nil {
+		t.Fatalf("Load() of not synced repository error = %v", err)
+	}
+	if !got.Cursor.IsZero() || len(got.Issues) != 0 {
+		t.Errorf("Load() of not synced repository = %+v, want empty", got)
+	}
+
+	cursor := time.Date(2020, 10, 21, 10, 0, 0, 0, time.UTC)
+	want := &Repository{
+		FullName: "sataga/issue-warehouse",
+		Cursor:   cursor,
+		Issues: map[int]*github.Issue{
+			1: {Number: github.Int(1), Title: github.String("test issue 1"), Body: github.String("line1\nline2")},
+			2: {Number: github.Int(2), Title: github.String("test issue 2")},
+		},
+		Comments: map[int][]*github.IssueComment{
+			1: {
+				{ID: github.Int64(10), Body: github.String("comment 1")},
+				{ID: github.Int64(11), Body: github.String("comment 2")},
+			},
+		},
+		Events: map[int][]*github.IssueEvent{
+			2: {
+				{Event: github.String("labeled"), Label: &github.Label{Name: github.String("Escalation")}},
+			},
+		},
+		Labels: []*github.Label{
+			{Name: github.String("PF_Support")},
+		},
+	}
+	if err := s.Save(want); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err = s.Load("sataga/issue-warehouse")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !got.Cursor.Equal(want.Cursor) {
+		t.Errorf("Load() cursor = %v, want %v", got.Cursor, want.Cursor)
+	}
+	got.Cursor = want.Cursor
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+
+	if _, err := s.Load("../etc"); err == nil {
+		t.Errorf("Load() of invalid repository name error = nil")
+	}
+}
+
+func TestStore_Update(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// stores of the same dir stand for processes, like sync and webhook
+	var stores []*Store
+	for i := 0; i < 2; i++ {
+		s, err := New(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stores = append(stores, s)
+	}
+
+	if _, err := stores[0].Update("sataga/issue-warehouse", func(r *Repository) (bool, error) {
+		return false, nil
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sataga", "issue-warehouse")); !os.IsNotExist(err) {
+		t.Errorf("Update() without change saved the repository, stat error = %v", err)
+	}
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 1; i <= n; i++ {
+		wg.Add(1)
+		go func(number int) {
+			defer wg.Done()
+			_, err := stores[number%len(stores)].Update("sataga/issue-warehouse", func(r *Repository) (bool, error) {
+				r.Issues[number] = &github.Issue{Number: github.Int(number)}
+				return true, nil
+			})
+			if err != nil {
+				t.Errorf("Update() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	got, err := stores[0].Load("sataga/issue-warehouse")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(got.Issues) != n {
+		t.Errorf("Load() = %d issues, want %d updated concurrently", len(got.Issues), n)
+	}
+}
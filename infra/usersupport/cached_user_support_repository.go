@@ -0,0 +1,299 @@
+package usersupport
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/github"
+	dus "github.com/sataga/go-github-sample/domain/usersupport"
+	igh "github.com/sataga/go-github-sample/infra/github"
+	"github.com/sataga/go-github-sample/infra/store"
+)
+
+// CachedRepository is a Repository which reads support issues from the local store
+type CachedRepository interface {
+	dus.Repository
+	// Sync fetches issues updated since the last sync into the store
+	Sync(ctx context.Context) error
+}
+
+type cachedUserSupportRepository struct {
+	remote *userSupportRepository
+	store  *store.Store
+	now    func() time.Time
+	// workers bounds how many issues are fetched with their events and comments at once
+	workers int
+
+	syncOnce sync.Once
+	synced   []*store.Repository
+	syncErr  error
+}
+
+// NewCachedUserSupportRepository creates CachedRepository implementation
+// the store is synced once before the first read, fetching events and comments of at most workers issues at once
+func NewCachedUserSupportRepository(ghClient igh.Client, st *store.Store, repos, orgs []string, supportLabel string, workers int) CachedRepository {
+	return &cachedUserSupportRepository{
+		remote: &userSupportRepository{
+			ghClient:     ghClient,
+			repos:        repos,
+			orgs:         orgs,
+			supportLabel: supportLabel,
+		},
+		store:   st,
+		now:     time.Now,
+		workers: workers,
+	}
+}
+
+func (r *cachedUserSupportRepository) Sync(ctx context.Context) error {
+	r.syncOnce.Do(func() {
+		targets, err := r.remote.targets(ctx)
+		if err != nil {
+			r.syncErr = err
+			return
+		}
+		for _, fullName := range targets {
+			data, err := r.syncRepository(ctx, fullName)
+			if err != nil {
+				r.syncErr = fmt.Errorf("sync %s: %w", fullName, err)
+				return
+			}
+			r.synced = append(r.synced, data)
+		}
+	})
+	return r.syncErr
+}
+
+// fetchedIssue is an issue fetched by sync with its events and comments
+type fetchedIssue struct {
+	issue    *github.Issue
+	events   []*github.IssueEvent
+	comments []*github.IssueComment
+}
+
+// syncRepository fetches issues updated since the cursor with their events and comments, and saves them with labels
+// the store is locked only while saving, so webhook can update it during the fetch
+// events and comments are fetched in the pool of workers, and nothing is saved on the first failure so the next sync retries from the cursor
+func (r *cachedUserSupportRepository) syncRepository(ctx context.Context, fullName string) (*store.Repository, error) {
+	prev, err := r.store.Load(fullName)
+	if err != nil {
+		return nil, err
+	}
+	owner, repo := splitFullName(fullName)
+	started := r.now()
+	var issues []*github.Issue
+	if prev.Cursor.IsZero() {
+		issues, err = r.remote.ghClient.ListRepoIssues(ctx, owner, repo, "all", []string{r.remote.supportLabel})
+	} else {
+		// support label may have been removed since the last sync, so updated issues are fetched regardless of labels
+		issues, err = r.remote.ghClient.ListRepoIssuesSince(ctx, owner, repo, prev.Cursor, "all", nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	fetched := make([]*fetchedIssue, len(issues))
+	err = dus.ForEach(ctx, len(issues), r.workers, func(ctx context.Context, i int) error {
+		is := issues[i]
+		f := &fetchedIssue{issue: is}
+		fetched[i] = f
+		if !labelContains(is.Labels, r.remote.supportLabel) {
+			return nil
+		}
+		number := is.GetNumber()
+		var err error
+		if f.events, err = r.remote.ghClient.ListIssueEvents(ctx, owner, repo, number); err != nil {
+			return fmt.Errorf("list events of #%d: %w", number, err)
+		}
+		if is.GetComments() == 0 {
+			return nil
+		}
+		if f.comments, err = r.remote.ghClient.ListIssueComments(ctx, owner, repo, number); err != nil {
+			return fmt.Errorf("list comments of #%d: %w", number, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	labels, err := r.remote.ghClient.ListRepoLabels(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	return r.store.Update(fullName, func(data *store.Repository) (bool, error) {
+		for _, f := range fetched {
+			number := f.issue.GetNumber()
+			// webhook may have saved a newer delivery of the issue during the fetch
+			if stored, ok := data.Issues[number]; ok && stored.GetUpdatedAt().After(f.issue.GetUpdatedAt()) {
+				continue
+			}
+			if !labelContains(f.issue.Labels, r.remote.supportLabel) {
+				delete(data.Issues, number)
+				delete(data.Comments, number)
+				delete(data.Events, number)
+				continue
+			}
+			data.Issues[number] = f.issue
+			data.Events[number] = f.events
+			if len(f.comments) == 0 {
+				delete(data.Comments, number)
+			} else {
+				data.Comments[number] = f.comments
+			}
+		}
+		data.Labels = labels
+		data.Cursor = started
+		return true, nil
+	})
+}
+
+// filterIssues returns synced issues which match, tagged with the repository
+func (r *cachedUserSupportRepository) filterIssues(ctx context.Context, match func(is *github.Issue) bool) ([]*dus.Issue, error) {
+	if err := r.Sync(ctx); err != nil {
+		return nil, err
+	}
+	iss := make([]*dus.Issue, 0)
+	for _, data := range r.synced {
+		numbers := make([]int, 0, len(data.Issues))
+		for n := range data.Issues {
+			numbers = append(numbers, n)
+		}
+		// newest first, same as the order of API
+		sort.Sort(sort.Reverse(sort.IntSlice(numbers)))
+		for _, n := range numbers {
+			is := data.Issues[n]
+			if !match(is) {
+				continue
+			}
+			issue := toIssue(is)
+			issue.Repository = data.FullName
+			iss = append(iss, issue)
+		}
+	}
+	return iss, nil
+}
+
+func (r *cachedUserSupportRepository) GetUpdatedSupportIssues(ctx context.Context, since, until time.Time) ([]*dus.Issue, error) {
+	return r.filterIssues(ctx, func(is *github.Issue) bool {
+		return is.GetUpdatedAt().After(since) && is.GetUpdatedAt().Before(until)
+	})
+}
+
+func (r *cachedUserSupportRepository) GetClosedSupportIssues(ctx context.Context, since, until time.Time) ([]*dus.Issue, error) {
+	return r.filterIssues(ctx, func(is *github.Issue) bool {
+		return is.GetState() == "closed" && is.GetClosedAt().After(since) && is.GetClosedAt().Before(until)
+	})
+}
+
+func (r *cachedUserSupportRepository) GetCurrentOpenNotUpdatedSupportIssues(ctx context.Context, until time.Time) ([]*dus.Issue, error) {
+	return r.filterIssues(ctx, func(is *github.Issue) bool {
+		return is.GetState() == "open" && is.GetUpdatedAt().Before(until)
+	})
+}
+
+func (r *cachedUserSupportRepository) GetCurrentOpenSupportIssues(ctx context.Context) ([]*dus.Issue, error) {
+	return r.filterIssues(ctx, func(is *github.Issue) bool {
+		return is.GetState() == "open"
+	})
+}
+
+// GetCreatedSupportIssues matches times in the same way as created qualifier of search query
+func (r *cachedUserSupportRepository) GetCreatedSupportIssues(ctx context.Context, since, until time.Time) ([]*dus.Issue, error) {
+	from, to := createdSpan(since, until)
+	return r.filterIssues(ctx, func(is *github.Issue) bool {
+		created := is.GetCreatedAt()
+		return !is.IsPullRequest() && !created.Before(from) && created.Before(to.Add(time.Second))
+	})
+}
+
+// GetLabelsByQuery returns labels whose name contains the query
+func (r *cachedUserSupportRepository) GetLabelsByQuery(ctx context.Context, query string) ([]*dus.Label, error) {
+	if err := r.Sync(ctx); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	labels := make([]*dus.Label, 0)
+	for _, data := range r.synced {
+		for _, l := range data.Labels {
+			if seen[l.GetName()] || !strings.Contains(strings.ToLower(l.GetName()), strings.ToLower(query)) {
+				continue
+			}
+			seen[l.GetName()] = true
+			labels = append(labels, &dus.Label{Name: l.GetName()})
+		}
+	}
+	return labels, nil
+}
+
+func (r *cachedUserSupportRepository) GetIssueComments(ctx context.Context, issue *dus.Issue) ([]*dus.Comment, error) {
+	if err := r.Sync(ctx); err != nil {
+		return nil, err
+	}
+	for _, data := range r.synced {
+		if data.FullName == issue.Repository {
+			return toComments(data.Comments[issue.Number]), nil
+		}
+	}
+	return []*dus.Comment{}, nil
+}
+
+func (r *cachedUserSupportRepository) GetIssueEvents(ctx context.Context, issue *dus.Issue) ([]*dus.Event, error) {
+	if err := r.Sync(ctx); err != nil {
+		return nil, err
+	}
+	for _, data := range r.synced {
+		if data.FullName == issue.Repository {
+			return toEvents(data.Events[issue.Number]), nil
+		}
+	}
+	return []*dus.Event{}, nil
+}
+
+// CreateIssueComment posts the comment and saves it in the store, so it is read before the next sync
+func (r *cachedUserSupportRepository) CreateIssueComment(ctx context.Context, issue *dus.Issue, body string) (*dus.Comment, error) {
+	if err := r.Sync(ctx); err != nil {
+		return nil, err
+	}
+	comment, err := r.remote.createComment(ctx, issue, body)
+	if err != nil {
+		return nil, err
+	}
+	for i, data := range r.synced {
+		if data.FullName != issue.Repository {
+			continue
+		}
+		number := issue.Number
+		updated, err := r.store.Update(data.FullName, func(data *store.Repository) (bool, error) {
+			// webhook may have saved the comment already
+			for _, c := range data.Comments[number] {
+				if c.GetID() == comment.GetID() {
+					return false, nil
+				}
+			}
+			data.Comments[number] = append(data.Comments[number], comment)
+			if is, ok := data.Issues[number]; ok {
+				is.Comments = github.Int(is.GetComments() + 1)
+				is.UpdatedAt = comment.CreatedAt
+			}
+			return true, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		r.synced[i] = updated
+	}
+	return toComment(comment), nil
+}
+
+// labelContains checks whether labels have the name
+func labelContains(labels []github.Label, name string) bool {
+	for _, l := range labels {
+		if l.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
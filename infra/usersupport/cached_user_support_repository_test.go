@@ -0,0 +1,348 @@
+package usersupport
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/sataga/go-github-sample/infra/fakegithub"
+	igh "github.com/sataga/go-github-sample/infra/github"
+	"github.com/sataga/go-github-sample/infra/store"
+)
+
+func TestCachedUserSupportRepository(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	st, err := store.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	firstSync := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	secondSync := time.Date(2020, 10, 2, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2020, 9, 30, 10, 0, 0, 0, time.UTC)
+	updated := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	supportLabels := []github.Label{{Name: github.String("PF_Support")}}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := igh.NewMockClient(ctrl)
+	gomock.InOrder(
+		// first sync fetches every support issue
+		m.EXPECT().ListRepoIssues(gomock.Any(), "sataga", "issue-warehouse", "all", []string{"PF_Support"}).Return([]*github.Issue{
+			{Number: github.Int(2), State: github.String("open"), CreatedAt: &created, UpdatedAt: &created, Labels: supportLabels},
+			{Number: github.Int(1), State: github.String("open"), CreatedAt: &created, UpdatedAt: &created, Labels: supportLabels, Comments: github.Int(1)},
+		}, nil),
+		m.EXPECT().ListIssueEvents(gomock.Any(), "sataga", "issue-warehouse", 2).Return([]*github.IssueEvent{}, nil),
+		m.EXPECT().ListIssueEvents(gomock.Any(), "sataga", "issue-warehouse", 1).Return([]*github.IssueEvent{
+			{Event: github.String("labeled"), Label: &github.Label{Name: github.String("PF_Support")}},
+		}, nil),
+		m.EXPECT().ListIssueComments(gomock.Any(), "sataga", "issue-warehouse", 1).Return([]*github.IssueComment{
+			{ID: github.Int64(10), Body: github.String("comment")},
+		}, nil),
+		m.EXPECT().ListRepoLabels(gomock.Any(), "sataga", "issue-warehouse").Return([]*github.Label{
+			{Name: github.String("PF_Support")},
+			{Name: github.String("keyword:Kubernetes")},
+		}, nil),
+		// second sync fetches only issues updated since the first sync
+		m.EXPECT().ListRepoIssuesSince(gomock.Any(), "sataga", "issue-warehouse", firstSync, "all", nil).Return([]*github.Issue{
+			{Number: github.Int(2), State: github.String("closed"), CreatedAt: &created, UpdatedAt: &updated, ClosedAt: &updated, Labels: supportLabels},
+			{Number: github.Int(1), State: github.String("open"), CreatedAt: &created, UpdatedAt: &updated},
+		}, nil),
+		m.EXPECT().ListIssueEvents(gomock.Any(), "sataga", "issue-warehouse", 2).Return([]*github.IssueEvent{
+			{Event: github.String("closed")},
+		}, nil),
+		m.EXPECT().ListRepoLabels(gomock.Any(), "sataga", "issue-warehouse").Return([]*github.Label{
+			{Name: github.String("PF_Support")},
+		}, nil),
+	)
+
+	r := NewCachedUserSupportRepository(m, st, []string{"sataga/issue-warehouse"}, nil, "PF_Support", 1).(*cachedUserSupportRepository)
+	r.now = func() time.Time { return firstSync }
+	open, err := r.GetCurrentOpenSupportIssues(ctx)
+	if err != nil {
+		t.Fatalf("GetCurrentOpenSupportIssues() error = %v", err)
+	}
+	if len(open) != 2 || open[0].Repository != "sataga/issue-warehouse" {
+		t.Errorf("GetCurrentOpenSupportIssues() = %v, want 2 issues of sataga/issue-warehouse", open)
+	}
+	labels, err := r.GetLabelsByQuery(ctx, "keyword:")
+	if err != nil {
+		t.Fatalf("GetLabelsByQuery() error = %v", err)
+	}
+	if len(labels) != 1 || labels[0].Name != "keyword:Kubernetes" {
+		t.Errorf("GetLabelsByQuery() = %v, want keyword:Kubernetes", labels)
+	}
+
+	// new process reads the store and syncs incrementally
+	r = NewCachedUserSupportRepository(m, st, []string{"sataga/issue-warehouse"}, nil, "PF_Support", 1).(*cachedUserSupportRepository)
+	r.now = func() time.Time { return secondSync }
+	closed, err := r.GetClosedSupportIssues(ctx, firstSync, secondSync)
+	if err != nil {
+		t.Fatalf("GetClosedSupportIssues() error = %v", err)
+	}
+	if len(closed) != 1 || closed[0].Number != 2 {
+		t.Errorf("GetClosedSupportIssues() = %v, want issue 2", closed)
+	}
+	createdIssues, err := r.GetCreatedSupportIssues(ctx, created, created)
+	if err != nil {
+		t.Fatalf("GetCreatedSupportIssues() error = %v", err)
+	}
+	// issue 1 is removed because its support label was removed
+	if len(createdIssues) != 1 || createdIssues[0].Number != 2 {
+		t.Errorf("GetCreatedSupportIssues() = %v, want issue 2", createdIssues)
+	}
+	// dates begin in the time zone of the span, where the issue is created on the day before
+	westDay := time.Date(2020, 9, 30, 0, 0, 0, 0, time.FixedZone("UTC-11", -11*60*60))
+	if createdIssues, err = r.GetCreatedSupportIssues(ctx, westDay, westDay); err != nil || len(createdIssues) != 0 {
+		t.Errorf("GetCreatedSupportIssues() = %v, %v, want no issue", createdIssues, err)
+	}
+	if createdIssues, err = r.GetCreatedSupportIssues(ctx, westDay.AddDate(0, 0, -1), westDay.AddDate(0, 0, -1)); err != nil || len(createdIssues) != 1 {
+		t.Errorf("GetCreatedSupportIssues() = %v, %v, want issue 2", createdIssues, err)
+	}
+
+	data, err := st.Load("sataga/issue-warehouse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !data.Cursor.Equal(secondSync) || len(data.Issues) != 1 || len(data.Comments) != 0 || len(data.Events[2]) != 1 {
+		t.Errorf("store = %+v, want cursor %v with issue 2 only", data, secondSync)
+	}
+}
+
+func TestCachedUserSupportRepository_CreateIssueComment(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	st, err := store.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := time.Date(2020, 9, 30, 10, 0, 0, 0, time.UTC)
+	commented := time.Date(2020, 10, 8, 10, 0, 0, 0, time.UTC)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := igh.NewMockClient(ctrl)
+	gomock.InOrder(
+		m.EXPECT().ListRepoIssues(gomock.Any(), "sataga", "issue-warehouse", "all", []string{"PF_Support"}).Return([]*github.Issue{
+			{Number: github.Int(1), State: github.String("open"), CreatedAt: &created, UpdatedAt: &created, Labels: []github.Label{{Name: github.String("PF_Support")}}},
+		}, nil),
+		m.EXPECT().ListIssueEvents(gomock.Any(), "sataga", "issue-warehouse", 1).Return([]*github.IssueEvent{}, nil),
+		m.EXPECT().ListRepoLabels(gomock.Any(), "sataga", "issue-warehouse").Return([]*github.Label{}, nil),
+		m.EXPECT().CreateComment(gomock.Any(), "sataga", "issue-warehouse", 1, "ping").Return(&github.IssueComment{
+			ID: github.Int64(10), Body: github.String("ping"), CreatedAt: &commented,
+		}, nil),
+	)
+
+	r := NewCachedUserSupportRepository(m, st, []string{"sataga/issue-warehouse"}, nil, "PF_Support", 1)
+	open, err := r.GetCurrentOpenSupportIssues(ctx)
+	if err != nil {
+		t.Fatalf("GetCurrentOpenSupportIssues() error = %v", err)
+	}
+	if _, err := r.CreateIssueComment(ctx, open[0], "ping"); err != nil {
+		t.Fatalf("CreateIssueComment() error = %v", err)
+	}
+	comments, err := r.GetIssueComments(ctx, open[0])
+	if err != nil {
+		t.Fatalf("GetIssueComments() error = %v", err)
+	}
+	if len(comments) != 1 || comments[0].Body != "ping" {
+		t.Errorf("GetIssueComments() = %v, want the created comment", comments)
+	}
+	data, err := st.Load("sataga/issue-warehouse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Comments[1]) != 1 || data.Issues[1].GetComments() != 1 || !data.Issues[1].GetUpdatedAt().Equal(commented) {
+		t.Errorf("store = %+v, want the created comment saved", data)
+	}
+}
+
+func TestCachedUserSupportRepository_SyncManyPages(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	st, err := store.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// more issues than 20 pages of 100, which list used to stop at
+	const numIssues = 2150
+	fixture := &fakegithub.Repository{
+		Repository: &github.Repository{FullName: github.String("sataga/issue-warehouse")},
+	}
+	created := time.Date(2020, 9, 30, 10, 0, 0, 0, time.UTC)
+	for i := 1; i <= numIssues; i++ {
+		fixture.Issues = append(fixture.Issues, &github.Issue{
+			Number:    github.Int(i),
+			State:     github.String("open"),
+			CreatedAt: &created,
+			UpdatedAt: &created,
+			Labels:    []github.Label{{Name: github.String("PF_Support")}},
+		})
+	}
+	srv := fakegithub.NewServer(fixture)
+	defer srv.Close()
+	c, err := igh.NewGitHubClient(srv.URL, "token", "sataga", "sataga@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewCachedUserSupportRepository(c, st, []string{"sataga/issue-warehouse"}, nil, "PF_Support", 1)
+	if err := r.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	open, err := r.GetCurrentOpenSupportIssues(ctx)
+	if err != nil {
+		t.Fatalf("GetCurrentOpenSupportIssues() error = %v", err)
+	}
+	if len(open) != numIssues {
+		t.Errorf("GetCurrentOpenSupportIssues() returned %d issues, want %d", len(open), numIssues)
+	}
+}
+
+func TestCachedUserSupportRepository_SyncKeepsConcurrentUpdates(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	st, err := store.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := time.Date(2020, 9, 30, 10, 0, 0, 0, time.UTC)
+	delivered := time.Date(2020, 10, 1, 10, 0, 0, 0, time.UTC)
+	supportLabels := []github.Label{{Name: github.String("PF_Support")}}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := igh.NewMockClient(ctrl)
+	gomock.InOrder(
+		m.EXPECT().ListRepoIssues(gomock.Any(), "sataga", "issue-warehouse", "all", []string{"PF_Support"}).DoAndReturn(
+			func(ctx context.Context, owner, repo, state string, labels []string) ([]*github.Issue, error) {
+				// webhook saves deliveries while sync is fetching
+				if _, err := st.Update("sataga/issue-warehouse", func(data *store.Repository) (bool, error) {
+					data.Issues[1] = &github.Issue{Number: github.Int(1), State: github.String("closed"), CreatedAt: &created, UpdatedAt: &delivered, Labels: supportLabels}
+					data.Issues[2] = &github.Issue{Number: github.Int(2), State: github.String("open"), CreatedAt: &delivered, UpdatedAt: &delivered, Labels: supportLabels}
+					return true, nil
+				}); err != nil {
+					t.Fatal(err)
+				}
+				return []*github.Issue{
+					{Number: github.Int(1), State: github.String("open"), CreatedAt: &created, UpdatedAt: &created, Labels: supportLabels},
+				}, nil
+			}),
+		m.EXPECT().ListIssueEvents(gomock.Any(), "sataga", "issue-warehouse", 1).Return([]*github.IssueEvent{}, nil),
+		m.EXPECT().ListRepoLabels(gomock.Any(), "sataga", "issue-warehouse").Return([]*github.Label{}, nil),
+	)
+
+	r := NewCachedUserSupportRepository(m, st, []string{"sataga/issue-warehouse"}, nil, "PF_Support", 1)
+	if err := r.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	data, err := st.Load("sataga/issue-warehouse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the delivery of issue 1 is newer than the fetched one, and issue 2 is not overwritten
+	if len(data.Issues) != 2 || data.Issues[1].GetState() != "closed" || data.Issues[2] == nil {
+		t.Errorf("store = %+v, want issues 1 and 2 saved by webhook", data.Issues)
+	}
+}
+
+func TestCachedUserSupportRepository_SyncConcurrently(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2020, 9, 30, 10, 0, 0, 0, time.UTC)
+	supportLabels := []github.Label{{Name: github.String("PF_Support")}}
+	var issues []*github.Issue
+	for i := 1; i <= 10; i++ {
+		issues = append(issues, &github.Issue{Number: github.Int(i), State: github.String("open"), Comments: github.Int(1), CreatedAt: &created, UpdatedAt: &created, Labels: supportLabels})
+	}
+	events := func(ctx context.Context, owner, repo string, number int) ([]*github.IssueEvent, error) {
+		return []*github.IssueEvent{{ID: github.Int64(int64(number))}}, nil
+	}
+	comments := func(ctx context.Context, owner, repo string, number int) ([]*github.IssueComment, error) {
+		return []*github.IssueComment{{ID: github.Int64(int64(number))}}, nil
+	}
+
+	tests := []struct {
+		name string
+		// fail is the number of the issue whose events can not be fetched, 0 fetches every issue
+		fail int
+	}{
+		{name: "saves events and comments of every issue"},
+		{name: "saves nothing on a failure", fail: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "store")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			st, err := store.New(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := igh.NewMockClient(ctrl)
+			m.EXPECT().ListRepoIssues(gomock.Any(), "sataga", "issue-warehouse", "all", []string{"PF_Support"}).Return(issues, nil)
+			// issues after a failure may not be requested as the rest are canceled
+			m.EXPECT().ListIssueEvents(gomock.Any(), "sataga", "issue-warehouse", gomock.Any()).DoAndReturn(
+				func(ctx context.Context, owner, repo string, number int) ([]*github.IssueEvent, error) {
+					if number == tt.fail {
+						return nil, errors.New("server error")
+					}
+					return events(ctx, owner, repo, number)
+				}).MinTimes(1).MaxTimes(len(issues))
+			m.EXPECT().ListIssueComments(gomock.Any(), "sataga", "issue-warehouse", gomock.Any()).DoAndReturn(comments).MaxTimes(len(issues))
+			if tt.fail == 0 {
+				m.EXPECT().ListRepoLabels(gomock.Any(), "sataga", "issue-warehouse").Return([]*github.Label{}, nil)
+			}
+
+			r := NewCachedUserSupportRepository(m, st, []string{"sataga/issue-warehouse"}, nil, "PF_Support", 4)
+			err = r.Sync(ctx)
+			data, lerr := st.Load("sataga/issue-warehouse")
+			if lerr != nil {
+				t.Fatal(lerr)
+			}
+			if tt.fail != 0 {
+				if err == nil {
+					t.Fatal("Sync() should fail")
+				}
+				if len(data.Issues) != 0 || !data.Cursor.IsZero() {
+					t.Errorf("store = %+v, want nothing saved to retry from the cursor", data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Sync() error = %v", err)
+			}
+			for _, is := range issues {
+				n := is.GetNumber()
+				if len(data.Events[n]) != 1 || data.Events[n][0].GetID() != int64(n) || len(data.Comments[n]) != 1 || data.Comments[n][0].GetID() != int64(n) {
+					t.Errorf("store of issue %d = %v events, %v comments, want its own", n, data.Events[n], data.Comments[n])
+				}
+			}
+		})
+	}
+}
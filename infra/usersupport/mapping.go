@@ -0,0 +1,90 @@
+package usersupport
+
+import (
+	"github.com/google/go-github/github"
+	dus "github.com/sataga/go-github-sample/domain/usersupport"
+)
+
+// toIssue maps github issue to usersupport issue
+// repository is taken from the API URL when the issue is not tagged with it, such as issues of search result
+func toIssue(is *github.Issue) *dus.Issue {
+	fullName := is.GetRepository().GetFullName()
+	if fullName == "" {
+		fullName = repositoryFromURL(is.GetRepositoryURL())
+	}
+	issue := &dus.Issue{
+		ID:          is.GetID(),
+		Repository:  fullName,
+		Number:      is.GetNumber(),
+		Title:       is.GetTitle(),
+		Body:        is.GetBody(),
+		State:       is.GetState(),
+		HTMLURL:     is.GetHTMLURL(),
+		User:        toUser(is.GetUser()),
+		NumComments: is.GetComments(),
+		CreatedAt:   is.GetCreatedAt(),
+		UpdatedAt:   is.GetUpdatedAt(),
+		ClosedAt:    is.GetClosedAt(),
+	}
+	for _, a := range is.Assignees {
+		if a.GetLogin() != "" {
+			issue.Assignees = append(issue.Assignees, toUser(a))
+		}
+	}
+	// old API responses only have the single assignee
+	if len(issue.Assignees) == 0 && is.GetAssignee().GetLogin() != "" {
+		issue.Assignees = append(issue.Assignees, toUser(is.GetAssignee()))
+	}
+	for _, l := range is.Labels {
+		issue.Labels = append(issue.Labels, dus.Label{Name: l.GetName()})
+	}
+	return issue
+}
+
+func toIssues(iss []*github.Issue) []*dus.Issue {
+	issues := make([]*dus.Issue, 0, len(iss))
+	for _, is := range iss {
+		if is != nil {
+			issues = append(issues, toIssue(is))
+		}
+	}
+	return issues
+}
+
+func toUser(u *github.User) dus.User {
+	return dus.User{Login: u.GetLogin()}
+}
+
+func toComment(c *github.IssueComment) *dus.Comment {
+	return &dus.Comment{
+		ID:        c.GetID(),
+		Body:      c.GetBody(),
+		User:      toUser(c.GetUser()),
+		CreatedAt: c.GetCreatedAt(),
+	}
+}
+
+func toComments(cs []*github.IssueComment) []*dus.Comment {
+	comments := make([]*dus.Comment, 0, len(cs))
+	for _, c := range cs {
+		if c != nil {
+			comments = append(comments, toComment(c))
+		}
+	}
+	return comments
+}
+
+func toEvents(es []*github.IssueEvent) []*dus.Event {
+	events := make([]*dus.Event, 0, len(es))
+	for _, e := range es {
+		if e == nil {
+			continue
+		}
+		events = append(events, &dus.Event{
+			Event:     e.GetEvent(),
+			Label:     dus.Label{Name: e.GetLabel().GetName()},
+			CreatedAt: e.GetCreatedAt(),
+		})
+	}
+	return events
+}
@@ -0,0 +1,67 @@
+package usersupport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+func Test_toIssue(t *testing.T) {
+	created := time.Date(2020, 10, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		issue          *github.Issue
+		wantRepository string
+		wantAssignees  []string
+		wantClosed     bool
+	}{
+		{
+			name:  "missing fields",
+			issue: &github.Issue{},
+		},
+		{
+			name: "search result",
+			issue: &github.Issue{
+				Number:        github.Int(1),
+				State:         github.String("closed"),
+				CreatedAt:     &created,
+				ClosedAt:      &created,
+				RepositoryURL: github.String("https://api.github.com/repos/sataga/issue-warehouse"),
+				Assignee:      &github.User{Login: github.String("alice")},
+			},
+			wantRepository: "sataga/issue-warehouse",
+			wantAssignees:  []string{"alice"},
+			wantClosed:     true,
+		},
+		{
+			name: "repository and assignees",
+			issue: &github.Issue{
+				Repository: &github.Repository{FullName: github.String("sataga/other")},
+				Assignee:   &github.User{Login: github.String("alice")},
+				Assignees:  []*github.User{{Login: github.String("alice")}, {}, {Login: github.String("bob")}},
+			},
+			wantRepository: "sataga/other",
+			wantAssignees:  []string{"alice", "bob"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := toIssue(tt.issue)
+			if got.Repository != tt.wantRepository {
+				t.Errorf("toIssue().Repository = %s, want %s", got.Repository, tt.wantRepository)
+			}
+			if len(got.Assignees) != len(tt.wantAssignees) {
+				t.Fatalf("toIssue().Assignees = %v, want %v", got.Assignees, tt.wantAssignees)
+			}
+			for i, a := range got.Assignees {
+				if a.Login != tt.wantAssignees[i] {
+					t.Errorf("toIssue().Assignees[%d] = %s, want %s", i, a.Login, tt.wantAssignees[i])
+				}
+			}
+			if got.IsClosed() != tt.wantClosed {
+				t.Errorf("toIssue().IsClosed() = %v, want %v", got.IsClosed(), tt.wantClosed)
+			}
+		})
+	}
+}
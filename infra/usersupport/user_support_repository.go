@@ -1,7 +1,11 @@
 package usersupport
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/github"
@@ -10,50 +14,122 @@ import (
 )
 
 type userSupportRepository struct {
-	ghClient igh.Client
+	ghClient     igh.Client
+	repos        []string
+	orgs         []string
+	supportLabel string
+
+	// mu guards resolved, which is cached only when every org is listed
+	mu       sync.Mutex
+	resolved []string
 }
 
-// NewUsersupportRepository creates UsersupportRepository implementation
-func NewUserSupportRepository(ghClient igh.Client) dus.Repository {
+// NewUserSupportRepository creates UsersupportRepository implementation
+// repos are full names (owner/name) of repositories and every repository of orgs is aggregated as well
+func NewUserSupportRepository(ghClient igh.Client, repos, orgs []string, supportLabel string) dus.Repository {
 	return &userSupportRepository{
-		ghClient: ghClient,
+		ghClient:     ghClient,
+		repos:        repos,
+		orgs:         orgs,
+		supportLabel: supportLabel,
+	}
+}
+
+// targets returns full names of repositories including those of orgs
+// a failure is not cached, so the next call lists orgs again with its own ctx
+func (r *userSupportRepository) targets(ctx context.Context) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.resolved != nil {
+		return r.resolved, nil
+	}
+	seen := make(map[string]bool)
+	resolved := make([]string, 0, len(r.repos))
+	for _, repo := range r.repos {
+		if !seen[repo] {
+			seen[repo] = true
+			resolved = append(resolved, repo)
+		}
 	}
+	for _, org := range r.orgs {
+		repos, err := r.ghClient.ListOrgRepos(ctx, org)
+		if err != nil {
+			return nil, fmt.Errorf("list org repos: %w", err)
+		}
+		for _, repo := range repos {
+			if !seen[repo.GetFullName()] {
+				seen[repo.GetFullName()] = true
+				resolved = append(resolved, repo.GetFullName())
+			}
+		}
+	}
+	r.resolved = resolved
+	return resolved, nil
 }
 
-func (r *userSupportRepository) GetUpdatedSupportIssues(since, until time.Time) ([]*github.Issue, error) {
-	issues, err := r.ghClient.ListRepoIssuesSince("sataga", "issue-warehouse", since, "all", []string{"PF_Support"})
+// listIssues lists issues over every target repository and tags them with the repository
+func (r *userSupportRepository) listIssues(ctx context.Context, listFunc func(owner, repo string) ([]*github.Issue, error)) ([]*github.Issue, error) {
+	targets, err := r.targets(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("list repo issues: %s", err)
+		return nil, err
+	}
+	issues := make([]*github.Issue, 0)
+	for _, fullName := range targets {
+		owner, repo := splitFullName(fullName)
+		iss, err := listFunc(owner, repo)
+		if err != nil {
+			return nil, fmt.Errorf("list repo issues of %s: %w", fullName, err)
+		}
+		for _, is := range iss {
+			is.Repository = &github.Repository{
+				FullName: github.String(fullName),
+				Name:     github.String(repo),
+				Owner:    &github.User{Login: github.String(owner)},
+			}
+		}
+		issues = append(issues, iss...)
+	}
+	return issues, nil
+}
+
+func (r *userSupportRepository) GetUpdatedSupportIssues(ctx context.Context, since, until time.Time) ([]*dus.Issue, error) {
+	issues, err := r.listIssues(ctx, func(owner, repo string) ([]*github.Issue, error) {
+		return r.ghClient.ListRepoIssuesSince(ctx, owner, repo, since, "all", []string{r.supportLabel})
+	})
+	if err != nil {
+		return nil, err
 	}
 	iss := make([]*github.Issue, 0, len(issues))
 	for _, is := range issues {
-		if is.UpdatedAt.After(since) && is.UpdatedAt.Before(until) {
+		if is.GetUpdatedAt().After(since) && is.GetUpdatedAt().Before(until) {
 			iss = append(iss, is)
 		}
 	}
-	return iss, nil
+	return toIssues(iss), nil
 }
 
-func (r *userSupportRepository) GetClosedSupportIssues(since, until time.Time) ([]*github.Issue, error) {
-	issues, err := r.ghClient.ListRepoIssuesSince("sataga", "issue-warehouse", since, "closed", []string{"PF_Support"})
+func (r *userSupportRepository) GetClosedSupportIssues(ctx context.Context, since, until time.Time) ([]*dus.Issue, error) {
+	issues, err := r.listIssues(ctx, func(owner, repo string) ([]*github.Issue, error) {
+		return r.ghClient.ListRepoIssuesSince(ctx, owner, repo, since, "closed", []string{r.supportLabel})
+	})
 	if err != nil {
-		return nil, fmt.Errorf("list repo issues: %s", err)
+		return nil, err
 	}
 	iss := make([]*github.Issue, 0, len(issues))
 	for _, is := range issues {
-		if is.ClosedAt.After(since) && is.ClosedAt.Before(until) {
+		if is.GetClosedAt().After(since) && is.GetClosedAt().Before(until) {
 			iss = append(iss, is)
 		}
 	}
-	return iss, nil
+	return toIssues(iss), nil
 }
 
-func (r *userSupportRepository) GetCurrentOpenNotUpdatedSupportIssues(until time.Time) ([]*github.Issue, error) {
-	issues, err := r.ghClient.ListRepoIssues("sataga", "issue-warehouse", "open", []string{"PF_Support"})
+func (r *userSupportRepository) GetCurrentOpenNotUpdatedSupportIssues(ctx context.Context, until time.Time) ([]*dus.Issue, error) {
+	issues, err := r.GetCurrentOpenSupportIssues(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("list repo issues: %s", err)
+		return nil, err
 	}
-	iss := make([]*github.Issue, 0, len(issues))
+	iss := make([]*dus.Issue, 0, len(issues))
 	for _, is := range issues {
 		if is.UpdatedAt.Before(until) {
 			iss = append(iss, is)
@@ -62,32 +138,181 @@ func (r *userSupportRepository) GetCurrentOpenNotUpdatedSupportIssues(until time
 	return iss, nil
 }
 
-func (r *userSupportRepository) GetCurrentOpenSupportIssues() ([]*github.Issue, error) {
-	return r.ghClient.ListRepoIssues("sataga", "issue-warehouse", "open", []string{"PF_Support"})
-}
-
-func (r *userSupportRepository) GetCreatedSupportIssues(since, until time.Time) ([]*github.Issue, error) {
-	query := fmt.Sprintf("repo:sataga/issue-warehouse is:issue created:%s..%s label:PF_Support", since.Format("2006-01-02"), until.Format("2006-01-02"))
-	result, _ := r.ghClient.SearchIssuesByQuery(query)
-	iss := make([]*github.Issue, 0, len(result))
-	for _, is := range result {
-		iss = append(iss, &github.Issue{
-			Title:     is.Title,
-			CreatedAt: is.CreatedAt,
-			UpdatedAt: is.UpdatedAt,
-			ClosedAt:  is.ClosedAt,
-			State:     is.State,
-			Assignees: is.Assignees,
-			Labels:    is.Labels,
-			HTMLURL:   is.HTMLURL,
-			Body:      is.Body,
-			Comments:  is.Comments,
-		})
+func (r *userSupportRepository) GetCurrentOpenSupportIssues(ctx context.Context) ([]*dus.Issue, error) {
+	issues, err := r.listIssues(ctx, func(owner, repo string) ([]*github.Issue, error) {
+		return r.ghClient.ListRepoIssues(ctx, owner, repo, "open", []string{r.supportLabel})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toIssues(issues), nil
+}
+
+// GetCreatedSupportIssues searches issues created from the date of since to the date of until, in their time zone
+// search API returns only 1000 results, so the range is bisected until every sub-range fits
+func (r *userSupportRepository) GetCreatedSupportIssues(ctx context.Context, since, until time.Time) ([]*dus.Issue, error) {
+	from, to := createdSpan(since, until)
+	result, err := r.searchCreated(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[int64]bool, len(result))
+	iss := make([]*dus.Issue, 0, len(result))
+	for i := range result {
+		if seen[result[i].GetID()] {
+			continue
+		}
+		seen[result[i].GetID()] = true
+		// issues of search result only have API URL of the repository, which toIssue reads
+		iss = append(iss, toIssue(&result[i]))
 	}
 	return iss, nil
 }
 
-func (r *userSupportRepository) GetLabelsByQuery(query string) ([]*github.LabelResult, error) {
-	repoID, _ := r.ghClient.GetRepoID("sataga", "issue-warehouse")
-	return r.ghClient.SearchLabelsByQuery(repoID, query)
+// searchCreated searches issues created between from and to (both inclusive)
+// when the range matches too many issues, it is split in halves, by days while it spans days, otherwise by seconds
+func (r *userSupportRepository) searchCreated(ctx context.Context, from, to time.Time) ([]github.Issue, error) {
+	query := fmt.Sprintf("%s is:issue created:%s label:%q", r.searchScope(), createdRange(from, to), r.supportLabel)
+	result, err := r.ghClient.SearchIssuesByQuery(ctx, query)
+	var limitErr *igh.SearchLimitError
+	if !errors.As(err, &limitErr) {
+		if err != nil {
+			return nil, fmt.Errorf("search issues: %w", err)
+		}
+		return result, nil
+	}
+	var mid time.Time
+	if days := int(to.Sub(from).Hours()/24) + 1; days > 1 && isWholeDays(from, to) {
+		mid = from.AddDate(0, 0, days/2).Add(-time.Second)
+	} else if to.Sub(from) >= time.Second {
+		mid = from.Add(to.Sub(from) / 2).Truncate(time.Second)
+	} else {
+		return nil, fmt.Errorf("search issues created at %s: %w", from.Format(time.RFC3339), err)
+	}
+	first, err := r.searchCreated(ctx, from, mid)
+	if err != nil {
+		return nil, err
+	}
+	second, err := r.searchCreated(ctx, mid.Add(time.Second), to)
+	if err != nil {
+		return nil, err
+	}
+	return append(first, second...), nil
+}
+
+// createdSpan returns the beginning of the date of since and the last second of the date of until
+// dates begin in the time zone of since and until, the same as closed issues are counted in
+func createdSpan(since, until time.Time) (time.Time, time.Time) {
+	return startOfDay(since), startOfDay(until).AddDate(0, 0, 1).Add(-time.Second)
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// isWholeDays checks whether from and to are the beginning and the end of days
+func isWholeDays(from, to time.Time) bool {
+	return from.Equal(startOfDay(from)) && to.Add(time.Second).Equal(startOfDay(to.Add(time.Second)))
+}
+
+// createdRange returns range of created qualifier in full times of UTC, since search API regards dates as UTC
+// positive offsets such as +09:00 are not used, because go-github leaves + of the query unescaped and it reads as a space
+func createdRange(from, to time.Time) string {
+	return fmt.Sprintf("%s..%s", from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339))
+}
+
+func (r *userSupportRepository) GetLabelsByQuery(ctx context.Context, query string) ([]*dus.Label, error) {
+	targets, err := r.targets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	labels := make([]*dus.Label, 0)
+	for _, fullName := range targets {
+		owner, repo := splitFullName(fullName)
+		repoID, err := r.ghClient.GetRepoID(ctx, owner, repo)
+		if err != nil {
+			return nil, err
+		}
+		ls, err := r.ghClient.SearchLabelsByQuery(ctx, repoID, query)
+		if err != nil {
+			return nil, fmt.Errorf("search labels of %s: %w", fullName, err)
+		}
+		for _, l := range ls {
+			if !seen[l.GetName()] {
+				seen[l.GetName()] = true
+				labels = append(labels, &dus.Label{Name: l.GetName()})
+			}
+		}
+	}
+	return labels, nil
+}
+
+func (r *userSupportRepository) GetIssueComments(ctx context.Context, issue *dus.Issue) ([]*dus.Comment, error) {
+	if issue.NumComments == 0 {
+		return []*dus.Comment{}, nil
+	}
+	owner, repo := splitFullName(issue.Repository)
+	comments, err := r.ghClient.ListIssueComments(ctx, owner, repo, issue.Number)
+	if err != nil {
+		return nil, fmt.Errorf("list comments of %s#%d: %w", issue.Repository, issue.Number, err)
+	}
+	return toComments(comments), nil
+}
+
+func (r *userSupportRepository) CreateIssueComment(ctx context.Context, issue *dus.Issue, body string) (*dus.Comment, error) {
+	comment, err := r.createComment(ctx, issue, body)
+	if err != nil {
+		return nil, err
+	}
+	return toComment(comment), nil
+}
+
+// createComment posts the comment and returns it as github returns
+func (r *userSupportRepository) createComment(ctx context.Context, issue *dus.Issue, body string) (*github.IssueComment, error) {
+	owner, repo := splitFullName(issue.Repository)
+	comment, err := r.ghClient.CreateComment(ctx, owner, repo, issue.Number, body)
+	if err != nil {
+		return nil, fmt.Errorf("comment on %s#%d: %w", issue.Repository, issue.Number, err)
+	}
+	return comment, nil
+}
+
+func (r *userSupportRepository) GetIssueEvents(ctx context.Context, issue *dus.Issue) ([]*dus.Event, error) {
+	owner, repo := splitFullName(issue.Repository)
+	events, err := r.ghClient.ListIssueEvents(ctx, owner, repo, issue.Number)
+	if err != nil {
+		return nil, fmt.Errorf("list events of %s#%d: %w", issue.Repository, issue.Number, err)
+	}
+	return toEvents(events), nil
+}
+
+// searchScope returns repo and org qualifiers of search query
+func (r *userSupportRepository) searchScope() string {
+	qualifiers := make([]string, 0, len(r.repos)+len(r.orgs))
+	for _, repo := range r.repos {
+		qualifiers = append(qualifiers, "repo:"+repo)
+	}
+	for _, org := range r.orgs {
+		qualifiers = append(qualifiers, "org:"+org)
+	}
+	return strings.Join(qualifiers, " ")
+}
+
+// splitFullName splits "owner/name" into owner and name
+func splitFullName(fullName string) (string, string) {
+	i := strings.Index(fullName, "/")
+	if i < 0 {
+		return "", fullName
+	}
+	return fullName[:i], fullName[i+1:]
+}
+
+// repositoryFromURL returns "owner/name" from API URL like https://api.github.com/repos/owner/name
+func repositoryFromURL(url string) string {
+	parts := strings.Split(strings.TrimSuffix(url, "/"), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	return strings.Join(parts[len(parts)-2:], "/")
 }
@@ -0,0 +1,119 @@
+package usersupport
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	igh "github.com/sataga/go-github-sample/infra/github"
+)
+
+func TestGetCreatedSupportIssues(t *testing.T) {
+	ctx := context.Background()
+	// dates begin in the time zone of the span, and the range is searched in times of UTC
+	jst := time.FixedZone("Asia/Tokyo", 9*60*60)
+	since := time.Date(2020, 10, 1, 0, 0, 0, 0, jst)
+	until := time.Date(2020, 10, 4, 0, 0, 0, 0, jst)
+	query := func(created string) string {
+		return `repo:sataga/issue-warehouse is:issue created:` + created + ` label:"PF_Support"`
+	}
+	issue := func(id int64) github.Issue {
+		return github.Issue{
+			ID:            github.Int64(id),
+			RepositoryURL: github.String("https://api.github.com/repos/sataga/issue-warehouse"),
+		}
+	}
+	tooMany := &igh.SearchLimitError{Total: 1200}
+
+	tests := []struct {
+		name    string
+		setup   func(m *igh.MockClient)
+		wantIDs []int64
+		wantErr bool
+	}{
+		{
+			name: "single query",
+			setup: func(m *igh.MockClient) {
+				m.EXPECT().SearchIssuesByQuery(gomock.Any(), query("2020-09-30T15:00:00Z..2020-10-04T14:59:59Z")).Return([]github.Issue{issue(1), issue(2)}, nil)
+			},
+			wantIDs: []int64{1, 2},
+		},
+		{
+			name: "bisect by days and seconds",
+			setup: func(m *igh.MockClient) {
+				gomock.InOrder(
+					m.EXPECT().SearchIssuesByQuery(gomock.Any(), query("2020-09-30T15:00:00Z..2020-10-04T14:59:59Z")).Return(nil, tooMany),
+					m.EXPECT().SearchIssuesByQuery(gomock.Any(), query("2020-09-30T15:00:00Z..2020-10-02T14:59:59Z")).Return(nil, tooMany),
+					m.EXPECT().SearchIssuesByQuery(gomock.Any(), query("2020-09-30T15:00:00Z..2020-10-01T14:59:59Z")).Return(nil, tooMany),
+					m.EXPECT().SearchIssuesByQuery(gomock.Any(), query("2020-09-30T15:00:00Z..2020-10-01T02:59:59Z")).Return([]github.Issue{issue(1)}, nil),
+					m.EXPECT().SearchIssuesByQuery(gomock.Any(), query("2020-10-01T03:00:00Z..2020-10-01T14:59:59Z")).Return([]github.Issue{issue(2), issue(1)}, nil),
+					m.EXPECT().SearchIssuesByQuery(gomock.Any(), query("2020-10-01T15:00:00Z..2020-10-02T14:59:59Z")).Return([]github.Issue{issue(3)}, nil),
+					m.EXPECT().SearchIssuesByQuery(gomock.Any(), query("2020-10-02T15:00:00Z..2020-10-04T14:59:59Z")).Return([]github.Issue{issue(4)}, nil),
+				)
+			},
+			wantIDs: []int64{1, 2, 3, 4},
+		},
+		{
+			name: "error propagates",
+			setup: func(m *igh.MockClient) {
+				m.EXPECT().SearchIssuesByQuery(gomock.Any(), query("2020-09-30T15:00:00Z..2020-10-04T14:59:59Z")).Return(nil, errors.New("bad credentials"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := igh.NewMockClient(ctrl)
+			tt.setup(m)
+			r := NewUserSupportRepository(m, []string{"sataga/issue-warehouse"}, nil, "PF_Support")
+			got, err := r.GetCreatedSupportIssues(ctx, since, until)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetCreatedSupportIssues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.wantIDs) {
+				t.Fatalf("GetCreatedSupportIssues() returned %d issues, want %d", len(got), len(tt.wantIDs))
+			}
+			for i, is := range got {
+				if is.ID != tt.wantIDs[i] || is.Repository != "sataga/issue-warehouse" {
+					t.Errorf("GetCreatedSupportIssues()[%d] = %d of %s, want %d", i, is.ID, is.Repository, tt.wantIDs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTargets(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := igh.NewMockClient(ctrl)
+	gomock.InOrder(
+		m.EXPECT().ListOrgRepos(gomock.Any(), "sataga-support").Return(nil, errors.New("502 Bad Gateway")),
+		// the failure is not cached, and the success is
+		m.EXPECT().ListOrgRepos(gomock.Any(), "sataga-support").Return([]*github.Repository{
+			{FullName: github.String("sataga-support/product")},
+			{FullName: github.String("sataga/issue-warehouse")},
+		}, nil),
+	)
+
+	r := &userSupportRepository{ghClient: m, repos: []string{"sataga/issue-warehouse"}, orgs: []string{"sataga-support"}}
+	if _, err := r.targets(ctx); err == nil {
+		t.Fatal("targets() error = nil, want the failure of listing org repos")
+	}
+	want := []string{"sataga/issue-warehouse", "sataga-support/product"}
+	for i := 0; i < 2; i++ {
+		got, err := r.targets(ctx)
+		if err != nil {
+			t.Fatalf("targets() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("targets() = %v, want %v", got, want)
+		}
+	}
+}
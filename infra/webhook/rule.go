@@ -0,0 +1,161 @@
+package webhook
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"text/template"
+
+	"github.com/google/go-github/github"
+	dus "github.com/sataga/go-github-sample/domain/usersupport"
+)
+
+const defaultMessage = `[{{.Repository}}] {{.Issue.GetTitle}} ({{.Event}} {{.Action}}{{with .Label}} {{.GetName}}{{end}}) {{.Issue.GetHTMLURL}}`
+
+// Rule posts a message to slack when a delivery of support issue matches
+type Rule struct {
+	// Event is issues or issue_comment
+	Event string `yaml:"event"`
+	// Actions are actions of the event such as opened, labeled and created, every action matches when empty
+	Actions []string `yaml:"actions"`
+	// Label is the label added by labeled action, or a label which the issue has for other actions
+	Label string `yaml:"label"`
+	// Message is text/template executed with Delivery, repository, title, event and URL of the issue are posted when empty
+	// title, label names and comment body are escaped for slack mrkdwn
+	Message string `yaml:"message"`
+}
+
+// Delivery is a webhook delivery of support issue, which is passed to templates of rules
+type Delivery struct {
+	Event string
+	// Action is action of the event
+	Action string
+	// Repository is full name (owner/name) of the repository
+	Repository string
+	Issue      *github.Issue
+	// Comment is set for issue_comment event
+	Comment *github.IssueComment
+	// Label is set for labeled and unlabeled actions
+	Label  *github.Label
+	Sender *github.User
+}
+
+type compiledRule struct {
+	Rule
+	tmpl *template.Template
+}
+
+func compileRules(rules []Rule) ([]*compiledRule, error) {
+	compiled := make([]*compiledRule, 0, len(rules))
+	for i, r := range rules {
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf("webhook rule %d: %s", i, err)
+		}
+		msg := r.Message
+		if msg == "" {
+			msg = defaultMessage
+		}
+		tmpl, err := template.New(fmt.Sprintf("rule%d", i)).Parse(msg)
+		if err != nil {
+			return nil, fmt.Errorf("webhook rule %d: parse message: %s", i, err)
+		}
+		compiled = append(compiled, &compiledRule{Rule: r, tmpl: tmpl})
+	}
+	return compiled, nil
+}
+
+// Validate checks the event and the message template
+func (r *Rule) Validate() error {
+	switch r.Event {
+	case "issues", "issue_comment":
+	default:
+		return fmt.Errorf("unknown event %q (choose on issues, issue_comment)", r.Event)
+	}
+	if r.Message != "" {
+		if _, err := template.New("").Parse(r.Message); err != nil {
+			return fmt.Errorf("parse message: %s", err)
+		}
+	}
+	return nil
+}
+
+func (r *compiledRule) match(d *Delivery) bool {
+	if r.Event != d.Event {
+		return false
+	}
+	if len(r.Actions) > 0 && !contains(r.Actions, d.Action) {
+		return false
+	}
+	if r.Label == "" {
+		return true
+	}
+	if d.Action == "labeled" {
+		return d.Label.GetName() == r.Label
+	}
+	return hasLabel(d.Issue, r.Label)
+}
+
+// render executes the template with text of the delivery escaped, so titles never mention everyone by <!channel> nor break links
+func (r *compiledRule) render(d *Delivery) (string, error) {
+	var buf bytes.Buffer
+	if err := r.tmpl.Execute(&buf, d.escaped()); err != nil {
+		return "", err
+	}
+	if buf.Len() == 0 {
+		return "", errors.New("empty message")
+	}
+	return buf.String(), nil
+}
+
+// escaped returns a copy of the delivery whose title, label names and comment body are escaped for slack mrkdwn
+func (d *Delivery) escaped() *Delivery {
+	e := *d
+	if d.Issue != nil {
+		issue := *d.Issue
+		if issue.Title != nil {
+			issue.Title = github.String(dus.EscapeMrkdwn(issue.GetTitle()))
+		}
+		issue.Labels = make([]github.Label, 0, len(d.Issue.Labels))
+		for _, l := range d.Issue.Labels {
+			issue.Labels = append(issue.Labels, *escapedLabel(&l))
+		}
+		e.Issue = &issue
+	}
+	if d.Comment != nil {
+		comment := *d.Comment
+		if comment.Body != nil {
+			comment.Body = github.String(dus.EscapeMrkdwn(comment.GetBody()))
+		}
+		e.Comment = &comment
+	}
+	if d.Label != nil {
+		e.Label = escapedLabel(d.Label)
+	}
+	return &e
+}
+
+func escapedLabel(l *github.Label) *github.Label {
+	label := *l
+	if label.Name != nil {
+		label.Name = github.String(dus.EscapeMrkdwn(label.GetName()))
+	}
+	return &label
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasLabel(issue *github.Issue, name string) bool {
+	for _, l := range issue.Labels {
+		if l.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
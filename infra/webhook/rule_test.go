@@ -0,0 +1,48 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/google/go-github/github"
+)
+
+func Test_compiledRule_render(t *testing.T) {
+	rules, err := compileRules([]Rule{
+		{Event: "issues"},
+		{Event: "issue_comment", Message: "{{range .Issue.Labels}}{{.GetName}} {{end}}{{.Comment.GetBody}}"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Delivery{
+		Event:      "issues",
+		Action:     "labeled",
+		Repository: "sataga/issue-warehouse",
+		Issue: &github.Issue{
+			Title:   github.String("<!channel> <https://example.com|click> & more"),
+			HTMLURL: github.String("https://github.com/sataga/issue-warehouse/issues/12"),
+			Labels:  []github.Label{{Name: github.String("<!here>")}},
+		},
+		Comment: &github.IssueComment{Body: github.String("<@U0001>")},
+		Label:   &github.Label{Name: github.String("a>b")},
+	}
+	tests := []struct {
+		rule *compiledRule
+		want string
+	}{
+		{rules[0], "[sataga/issue-warehouse] &lt;!channel&gt; &lt;https://example.com|click&gt; &amp; more (issues labeled a&gt;b) https://github.com/sataga/issue-warehouse/issues/12"},
+		{rules[1], "&lt;!here&gt; &lt;@U0001&gt;"},
+	}
+	for _, tt := range tests {
+		got, err := tt.rule.render(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("render() = %q, want %q", got, tt.want)
+		}
+	}
+	if d.Issue.GetTitle() != "<!channel> <https://example.com|click> & more" || d.Label.GetName() != "a>b" {
+		t.Errorf("render() modified the delivery: %q, %q", d.Issue.GetTitle(), d.Label.GetName())
+	}
+}
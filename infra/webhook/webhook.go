@@ -0,0 +1,289 @@
+// Package webhook receives github webhook deliveries of support issues
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/github"
+	"github.com/sataga/go-github-sample/infra/slack"
+	"github.com/sataga/go-github-sample/infra/store"
+)
+
+const (
+	signatureHeader = "X-Hub-Signature-256"
+	eventHeader     = "X-GitHub-Event"
+	// maxPayloadSize is the limit of payload which github delivers
+	maxPayloadSize = 25 << 20
+)
+
+// Handler is http.Handler of github webhook
+// it updates the local store with issues, issue_comment and label deliveries and posts messages of matching rules
+// the store is still synced periodically, which fetches the whole history of issues delivered here
+type Handler struct {
+	secret       []byte
+	store        *store.Store
+	repos        []string
+	orgs         []string
+	supportLabel string
+	rules        []*compiledRule
+	notifier     slack.Notifier
+
+	// notifying counts messages being posted after their deliveries are acknowledged
+	notifying sync.WaitGroup
+}
+
+// NewHandler creates Handler, notifier can be nil when there is no rule
+// deliveries of repositories other than repos and those of orgs are ignored
+func NewHandler(secret string, st *store.Store, repos, orgs []string, supportLabel string, rules []Rule, notifier slack.Notifier) (*Handler, error) {
+	if secret == "" {
+		return nil, errors.New("need to set webhook secret")
+	}
+	if len(rules) > 0 && notifier == nil {
+		return nil, errors.New("need notifier to post messages of rules")
+	}
+	compiled, err := compileRules(rules)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{
+		secret:       []byte(secret),
+		store:        st,
+		repos:        repos,
+		orgs:         orgs,
+		supportLabel: supportLabel,
+		rules:        compiled,
+		notifier:     notifier,
+	}, nil
+}
+
+// ServeHTTP verifies signature of the delivery and handles it
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	payload, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(w, "read payload", http.StatusBadRequest)
+		return
+	}
+	if err := verifySignature(h.secret, payload, r.Header.Get(signatureHeader)); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	eventType := r.Header.Get(eventHeader)
+	switch eventType {
+	case "issues", "issue_comment", "label":
+	default:
+		// ping and other events are acknowledged and ignored
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	event, err := github.ParseWebHook(eventType, payload)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("parse payload: %s", err), http.StatusBadRequest)
+		return
+	}
+	delivery, err := h.handle(event)
+	if err != nil {
+		log.Printf("handle %s delivery: %s", eventType, err)
+		http.Error(w, "update store", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	if delivery == nil {
+		return
+	}
+	// github times out a delivery in 10 seconds, so messages are posted after it is acknowledged
+	// the context of the request is canceled on return, and the notifier times out by itself
+	h.notifying.Add(1)
+	go func() {
+		defer h.notifying.Done()
+		h.notify(context.Background(), delivery)
+	}()
+}
+
+// Wait waits for messages being posted, call it after the server is shut down
+func (h *Handler) Wait() {
+	h.notifying.Wait()
+}
+
+// tracks checks whether the repository is one of the targets
+func (h *Handler) tracks(fullName string) bool {
+	for _, repo := range h.repos {
+		if strings.EqualFold(repo, fullName) {
+			return true
+		}
+	}
+	owner := strings.SplitN(fullName, "/", 2)[0]
+	for _, org := range h.orgs {
+		if strings.EqualFold(org, owner) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifySignature checks sha256 HMAC of the payload in X-Hub-Signature-256 header
+func verifySignature(secret, payload []byte, signature string) error {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return errors.New("missing signature")
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return errors.New("malformed signature")
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// handle updates the store and returns delivery of support issue to notify, or nil
+func (h *Handler) handle(event interface{}) (*Delivery, error) {
+	repo, ok := event.(interface{ GetRepo() *github.Repository })
+	if !ok || !h.tracks(repo.GetRepo().GetFullName()) {
+		return nil, nil
+	}
+	switch e := event.(type) {
+	case *github.IssuesEvent:
+		return h.handleIssues(e)
+	case *github.IssueCommentEvent:
+		return h.handleIssueComment(e)
+	case *github.LabelEvent:
+		return nil, h.handleLabel(e)
+	}
+	return nil, nil
+}
+
+func (h *Handler) handleIssues(e *github.IssuesEvent) (*Delivery, error) {
+	issue := e.GetIssue()
+	number := issue.GetNumber()
+	if e.GetAction() == "deleted" || !hasLabel(issue, h.supportLabel) {
+		_, err := h.store.Update(e.GetRepo().GetFullName(), func(data *store.Repository) (bool, error) {
+			if _, ok := data.Issues[number]; !ok {
+				return false, nil
+			}
+			delete(data.Issues, number)
+			delete(data.Comments, number)
+			delete(data.Events, number)
+			return true, nil
+		})
+		return nil, err
+	}
+	data, err := h.store.Update(e.GetRepo().GetFullName(), func(data *store.Repository) (bool, error) {
+		data.Issues[number] = issue
+		switch e.GetAction() {
+		case "labeled", "unlabeled", "closed", "reopened", "assigned", "unassigned":
+			data.Events[number] = append(data.Events[number], &github.IssueEvent{
+				Event:     e.Action,
+				Actor:     e.Sender,
+				Label:     e.Label,
+				Assignee:  e.Assignee,
+				CreatedAt: issue.UpdatedAt,
+			})
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Delivery{
+		Event:      "issues",
+		Action:     e.GetAction(),
+		Repository: data.FullName,
+		Issue:      issue,
+		Label:      e.Label,
+		Sender:     e.Sender,
+	}, nil
+}
+
+func (h *Handler) handleIssueComment(e *github.IssueCommentEvent) (*Delivery, error) {
+	issue := e.GetIssue()
+	if !hasLabel(issue, h.supportLabel) {
+		return nil, nil
+	}
+	number := issue.GetNumber()
+	comment := e.GetComment()
+	data, err := h.store.Update(e.GetRepo().GetFullName(), func(data *store.Repository) (bool, error) {
+		comments := removeComment(data.Comments[number], comment.GetID())
+		if e.GetAction() != "deleted" {
+			comments = append(comments, comment)
+		}
+		if len(comments) == 0 {
+			delete(data.Comments, number)
+		} else {
+			data.Comments[number] = comments
+		}
+		data.Issues[number] = issue
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Delivery{
+		Event:      "issue_comment",
+		Action:     e.GetAction(),
+		Repository: data.FullName,
+		Issue:      issue,
+		Comment:    comment,
+		Sender:     e.Sender,
+	}, nil
+}
+
+func (h *Handler) handleLabel(e *github.LabelEvent) error {
+	label := e.GetLabel()
+	_, err := h.store.Update(e.GetRepo().GetFullName(), func(data *store.Repository) (bool, error) {
+		labels := make([]*github.Label, 0, len(data.Labels)+1)
+		for _, l := range data.Labels {
+			if l.GetID() != label.GetID() {
+				labels = append(labels, l)
+			}
+		}
+		if e.GetAction() != "deleted" {
+			labels = append(labels, label)
+		}
+		data.Labels = labels
+		return true, nil
+	})
+	return err
+}
+
+// notify posts messages of matching rules, failures are logged not to fail the delivery whose store is updated
+func (h *Handler) notify(ctx context.Context, d *Delivery) {
+	for _, r := range h.rules {
+		if !r.match(d) {
+			continue
+		}
+		text, err := r.render(d)
+		if err != nil {
+			log.Printf("render message of %s#%d: %s", d.Repository, d.Issue.GetNumber(), err)
+			continue
+		}
+		if err := h.notifier.Notify(ctx, &slack.Message{Text: text}); err != nil {
+			log.Printf("notify %s#%d: %s", d.Repository, d.Issue.GetNumber(), err)
+		}
+	}
+}
+
+func removeComment(comments []*github.IssueComment, id int64) []*github.IssueComment {
+	out := make([]*github.IssueComment, 0, len(comments))
+	for _, c := range comments {
+		if c.GetID() != id {
+			out = append(out, c)
+		}
+	}
+	return out
+}
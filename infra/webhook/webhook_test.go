@@ -0,0 +1,173 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/sataga/go-github-sample/infra/slack"
+	"github.com/sataga/go-github-sample/infra/store"
+)
+
+const testSecret = "It's a Secret to Everybody"
+
+type recordNotifier struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (n *recordNotifier) Notify(ctx context.Context, msg *slack.Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = append(n.messages, msg.Text)
+	return nil
+}
+
+func sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func deliver(t *testing.T, h http.Handler, event, fixture, signature string) int {
+	t.Helper()
+	payload, err := ioutil.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signature == "" {
+		signature = sign(testSecret, payload)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(payload))
+	req.Header.Set(eventHeader, event)
+	req.Header.Set(signatureHeader, signature)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	st, err := store.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &recordNotifier{}
+	rules := []Rule{
+		{Event: "issues", Actions: []string{"labeled"}, Label: "緊急度：高"},
+		{Event: "issue_comment", Actions: []string{"created"}, Message: "{{.Sender.GetLogin}} commented on {{.Issue.GetTitle}}"},
+	}
+	h, err := NewHandler(testSecret, st, []string{"sataga/issue-warehouse"}, []string{"sataga-support"}, "PF_Support", rules, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code := deliver(t, h, "issues", "issues_labeled.json", "sha256=0000"); code != http.StatusUnauthorized {
+		t.Errorf("delivery with wrong signature = %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := deliver(t, h, "issues", "issues_labeled.json", sign("wrong secret", []byte("{}"))); code != http.StatusUnauthorized {
+		t.Errorf("delivery signed with other secret = %d, want %d", code, http.StatusUnauthorized)
+	}
+	if len(n.messages) != 0 {
+		t.Fatalf("rejected deliveries notified %v", n.messages)
+	}
+
+	for _, d := range []struct {
+		event   string
+		fixture string
+	}{
+		{"ping", "ping.json"},
+		{"issues", "issues_labeled.json"},
+		// not a support issue
+		{"issues", "issues_opened_other.json"},
+		// not a target repository
+		{"issues", "issues_labeled_untracked.json"},
+		{"issue_comment", "issue_comment_created.json"},
+		{"label", "label_created.json"},
+	} {
+		if code := deliver(t, h, d.event, d.fixture, ""); code != http.StatusNoContent {
+			t.Errorf("delivery of %s = %d, want %d", d.fixture, code, http.StatusNoContent)
+		}
+		// messages are posted after deliveries are acknowledged
+		h.Wait()
+	}
+
+	wantMessages := []string{
+		"[sataga/issue-warehouse] INC0001234 コンテナが起動しない (issues labeled 緊急度：高) https://github.com/sataga/issue-warehouse/issues/12",
+		"supporter commented on INC0001234 コンテナが起動しない",
+	}
+	if !reflect.DeepEqual(n.messages, wantMessages) {
+		t.Errorf("notified messages = %q, want %q", n.messages, wantMessages)
+	}
+
+	data, err := st.Load("sataga/issue-warehouse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Issues) != 1 || data.Issues[12].GetComments() != 1 {
+		t.Errorf("stored issues = %v, want issue 12 with a comment", data.Issues)
+	}
+	if events := data.Events[12]; len(events) != 1 || events[0].GetEvent() != "labeled" || events[0].GetLabel().GetName() != "緊急度：高" {
+		t.Errorf("stored events = %v, want labeled event", events)
+	}
+	if comments := data.Comments[12]; len(comments) != 1 || comments[0].GetID() != 900000001 {
+		t.Errorf("stored comments = %v, want the delivered comment", comments)
+	}
+	if len(data.Labels) != 1 || data.Labels[0].GetName() != "keyword:Network" {
+		t.Errorf("stored labels = %v, want the created label", data.Labels)
+	}
+	if !data.Cursor.IsZero() {
+		t.Errorf("cursor = %s, want zero not to skip the first sync", data.Cursor)
+	}
+	untracked, err := st.Load("sataga/other-product")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(untracked.Issues) != 0 {
+		t.Errorf("stored issues of untracked repository = %v, want none", untracked.Issues)
+	}
+}
+
+func TestHandler_tracks(t *testing.T) {
+	h, err := NewHandler(testSecret, nil, []string{"sataga/issue-warehouse"}, []string{"sataga-support"}, "PF_Support", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for fullName, want := range map[string]bool{
+		"sataga/issue-warehouse": true,
+		"Sataga/Issue-Warehouse": true,
+		"sataga-support/product": true,
+		"sataga/other-product":   false,
+		"other/issue-warehouse":  false,
+	} {
+		if got := h.tracks(fullName); got != want {
+			t.Errorf("tracks(%s) = %v, want %v", fullName, got, want)
+		}
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	if _, err := NewHandler("", nil, nil, nil, "PF_Support", nil, nil); err == nil {
+		t.Error("NewHandler() without secret should fail")
+	}
+	if _, err := NewHandler(testSecret, nil, nil, nil, "PF_Support", []Rule{{Event: "pull_request"}}, &recordNotifier{}); err == nil {
+		t.Error("NewHandler() with unknown event should fail")
+	}
+	if _, err := NewHandler(testSecret, nil, nil, nil, "PF_Support", []Rule{{Event: "issues", Message: "{{.Issue"}}, &recordNotifier{}); err == nil {
+		t.Error("NewHandler() with broken template should fail")
+	}
+}
@@ -1,16 +1,29 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
+	"github.com/sataga/go-github-sample/config"
+	"github.com/sataga/go-github-sample/domain/period"
 	dus "github.com/sataga/go-github-sample/domain/usersupport"
+	"github.com/sataga/go-github-sample/infra/charset"
 	igh "github.com/sataga/go-github-sample/infra/github"
+	"github.com/sataga/go-github-sample/infra/publish"
 	"github.com/sataga/go-github-sample/infra/slack"
+	"github.com/sataga/go-github-sample/infra/store"
 	ius "github.com/sataga/go-github-sample/infra/usersupport"
+	"github.com/sataga/go-github-sample/infra/webhook"
 )
 
 var (
@@ -19,33 +32,83 @@ var (
 	ghMail  = flag.String("ghmail", "", "Github user email")
 	ghToken = flag.String("ghtoken", "", "GitHub Personal access token")
 
-	cnt = 0
+	configPath    = flag.String("config", "", "Path to YAML config file")
+	ownerStr      = flag.String("owner", "", "Owner of the support repository (overrides config)")
+	repoStr       = flag.String("repo", "", "Name of the support repository (overrides config)")
+	reposStr      = flag.String("repos", "", "Comma separated full names (owner/name) of support repositories (overrides config)")
+	orgsStr       = flag.String("orgs", "", "Comma separated organizations whose all repositories are aggregated (overrides config)")
+	supportLabel  = flag.String("label", "", "Base label of support issues (overrides config)")
+	formatStr     = flag.String("format", "", "Output format (json, yaml, markdown, csv). default is markdown, or csv for analysis-report")
+	storeDir      = flag.String("store", "", "Directory of local issue store, reports read synced issues from it (overrides config)")
+	businessHours = flag.Bool("business-hours", false, "Measure durations, scores and day-ago in business hours of calendar config (overrides config)")
+	encodingStr   = flag.String("encoding", charset.UTF8, "Character encoding of output (utf-8, utf-8-bom, shift_jis). utf-8-bom or shift_jis lets Excel open CSV")
+	recordDir     = flag.String("record", "", "Directory to record GitHub API responses to as fixtures")
+	replayDir     = flag.String("replay", "", "Directory of fixtures recorded by -record, GitHub API requests are answered from it without network")
+	concurrency   = flag.Int("concurrency", 4, "Number of report spans, and comments or events of issues in each span or sync of the store, fetched at once, they share rate limit of GitHub API")
+	timeout       = flag.Duration("timeout", 0, "Cancel the subcommand when it does not finish in this duration such as 10m, 0 means no timeout. serve applies it to every refresh")
 
-	loc, _          = time.LoadLocation("Asia/Tokyo")
 	now             = time.Now()
 	oneWeekBefore   = now.Add(-7 * 24 * time.Hour)
 	userSupportFlag = flag.NewFlagSet("us", flag.ExitOnError)
 	sinceStr        = userSupportFlag.String("since", oneWeekBefore.Format("2006-01-02"), "Date since listing issues from")
 	untilStr        = userSupportFlag.String("until", now.Format("2006-01-02"), "Date until listing issues from")
+	usNotify        = userSupportFlag.Bool("notify", false, "Send the result to slack")
 
 	dailyReportFlag = flag.NewFlagSet("daily-report", flag.ExitOnError)
 	dailyDayAgoInt  = dailyReportFlag.Int("day-ago", 7, "Please specify a date that has not been updated")
+	dailyNotify     = dailyReportFlag.Bool("notify", false, "Send the report to slack")
 
 	longtermReportFlag = flag.NewFlagSet("longterm-report", flag.ExitOnError)
-	longtermKindStr    = longtermReportFlag.String("kind", "monthly", "Please choose on (weekly , monthly)")
+	longtermKindStr    = longtermReportFlag.String("kind", "monthly", "Kind of spans ("+period.KindNames()+"). custom spans -since until the origin date")
+	longtermSinceStr   = longtermReportFlag.String("since", "", "Date since the span of -kind custom")
 	longtermSpanInt    = longtermReportFlag.Int("span", 4, "Please enter the span you want to get")
 	longtermOriginStr  = longtermReportFlag.String("origin", now.Format("2006-01-02"), "Get the data based on the date you entered")
+	longtermNotify     = longtermReportFlag.Bool("notify", false, "Send the report to slack")
+	longtermPublish    = longtermReportFlag.Bool("publish", false, "Commit the markdown report to publish repository of config and open a pull request")
 
 	analysisReportFlag = flag.NewFlagSet("analysys-report", flag.ExitOnError)
 	analysisSinceStr   = analysisReportFlag.String("since", oneWeekBefore.Format("2006-01-02"), "Date since listing issues from")
 	analysisUntilStr   = analysisReportFlag.String("until", now.Format("2006-01-02"), "Date until listing issues from")
 	analysisStateStr   = analysisReportFlag.String("state", "created", "Please choose on (created , closed)")
+	analysisKindStr    = analysisReportFlag.String("kind", "monthly", "Kind of spans going back from -since ("+period.KindNames()+"). custom spans -since until -until")
 	analysisSpanInt    = analysisReportFlag.Int("span", 4, "Please enter the span you want to get")
+	analysisNotify     = analysisReportFlag.Bool("notify", false, "Send the report to slack")
+
+	timelineReportFlag = flag.NewFlagSet("timeline-report", flag.ExitOnError)
+	timelineKindStr    = timelineReportFlag.String("kind", "monthly", "Kind of spans ("+period.KindNames()+"). custom spans -since until the origin date")
+	timelineSinceStr   = timelineReportFlag.String("since", "", "Date since the span of -kind custom")
+	timelineSpanInt    = timelineReportFlag.Int("span", 4, "Please enter the span you want to get")
+	timelineOriginStr  = timelineReportFlag.String("origin", now.Format("2006-01-02"), "Get the data based on the date you entered")
+	timelineNotify     = timelineReportFlag.Bool("notify", false, "Send the report to slack")
 
 	keywordReportFlag = flag.NewFlagSet("keyword-report", flag.ExitOnError)
-	keywordKindStr    = keywordReportFlag.String("kind", "monthly", "Please choose on (weekly , monthly)")
+	keywordKindStr    = keywordReportFlag.String("kind", "monthly", "Kind of spans ("+period.KindNames()+"). custom spans -since until the origin date")
+	keywordSinceStr   = keywordReportFlag.String("since", "", "Date since the span of -kind custom")
 	keywordSpanInt    = keywordReportFlag.Int("span", 4, "Please enter the span you want to get")
 	keywordUntilStr   = keywordReportFlag.String("until", now.Format("2006-01-02"), "Date until listing issue from")
+	keywordNotify     = keywordReportFlag.Bool("notify", false, "Send the report to slack")
+	keywordPublish    = keywordReportFlag.Bool("publish", false, "Commit the markdown report to publish repository of config and open a pull request")
+
+	assigneeReportFlag = flag.NewFlagSet("assignee-report", flag.ExitOnError)
+	assigneeSinceStr   = assigneeReportFlag.String("since", oneWeekBefore.Format("2006-01-02"), "Date since listing closed issues from")
+	assigneeUntilStr   = assigneeReportFlag.String("until", now.Format("2006-01-02"), "Date until listing closed issues from")
+	assigneeDayAgoInt  = assigneeReportFlag.Int("day-ago", 7, "Days without update to count an open issue as stale")
+	assigneeMaxOpenInt = assigneeReportFlag.Int("max-open", 0, "Flag assignees who have more open issues than it (overrides config)")
+	assigneeNotify     = assigneeReportFlag.Bool("notify", false, "Send the report to slack")
+
+	nudgeFlag      = flag.NewFlagSet("nudge", flag.ExitOnError)
+	nudgeDayAgoInt = nudgeFlag.Int("day-ago", 7, "Days without update to nudge an open issue")
+	nudgeDryRun    = nudgeFlag.Bool("dry-run", false, "Print comments without posting them")
+	nudgeNotify    = nudgeFlag.Bool("notify", false, "Send the result to slack")
+
+	serveFlag      = flag.NewFlagSet("serve", flag.ExitOnError)
+	serveListenStr = serveFlag.String("listen", ":9100", "Address to expose /metrics on")
+	serveInterval  = serveFlag.Duration("interval", 10*time.Minute, "Interval of syncing issues and refreshing metrics")
+	serveDayAgoInt = serveFlag.Int("day-ago", 7, "Days without update to count an open issue as stale")
+	serveWindowInt = serveFlag.Int("window", 30, "Days of closed issues to observe resolution time")
+
+	webhookFlag      = flag.NewFlagSet("webhook", flag.ExitOnError)
+	webhookListenStr = webhookFlag.String("listen", ":8080", "Address to receive github webhook deliveries on /webhook")
 )
 
 func printDefaultsAll() {
@@ -57,195 +120,526 @@ func printDefaultsAll() {
 	fmt.Println("daily-report:    Notify slack of tickets that have not been updated since the specified date")
 	dailyReportFlag.PrintDefaults()
 	fmt.Println("longterm-report:    Output user information in Markdown format based on kind")
-	dailyReportFlag.PrintDefaults()
+	longtermReportFlag.PrintDefaults()
 	fmt.Println("analysis-report:    Output user information in CSV format")
 	analysisReportFlag.PrintDefaults()
+	fmt.Println("keyword-report:    Output keyword label counts in Markdown format based on kind")
+	keywordReportFlag.PrintDefaults()
+	fmt.Println("timeline-report:    Output average dwell time per team, urgency and until escalation in Markdown format based on kind")
+	timelineReportFlag.PrintDefaults()
+	fmt.Println("assignee-report:    Output open, stale and closed issues, resolution time and escalation ratio per assignee")
+	assigneeReportFlag.PrintDefaults()
+	fmt.Println("nudge:    Comment on stale issues mentioning their assignees")
+	nudgeFlag.PrintDefaults()
+	fmt.Println("sync:    Sync issues updated since the last sync into the local store")
+	fmt.Println("serve:    Expose metrics of support issues for prometheus, refreshing them periodically")
+	serveFlag.PrintDefaults()
+	fmt.Println("webhook:    Receive github webhook deliveries into the local store and notify slack of matching rules")
+	webhookFlag.PrintDefaults()
+	fmt.Println("slacktest:    Send a test message to slack")
+}
+
+// loadConfig reads config file and applies global flags over it
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return nil, err
+	}
+	if *ownerStr != "" || *repoStr != "" {
+		cfg.Target.Repositories = nil
+		cfg.Target.Orgs = nil
+	}
+	if *ownerStr != "" {
+		cfg.Target.Owner = *ownerStr
+	}
+	if *repoStr != "" {
+		cfg.Target.Repo = *repoStr
+	}
+	if *reposStr != "" || *orgsStr != "" {
+		cfg.Target.Repositories = splitList(*reposStr)
+		cfg.Target.Orgs = splitList(*orgsStr)
+	}
+	if *supportLabel != "" {
+		cfg.Target.SupportLabel = *supportLabel
+	}
+	if *storeDir != "" {
+		cfg.Store.Dir = *storeDir
+	}
+	if *businessHours {
+		cfg.Calendar.BusinessHours = true
+	}
+	if os.Getenv("GITHUB_WEBHOOK_SECRET") != "" {
+		cfg.Webhook.Secret = os.Getenv("GITHUB_WEBHOOK_SECRET")
+	}
+	if os.Getenv("SLACK_WEBHOOK_URL") != "" {
+		cfg.Slack.WebhookURL = os.Getenv("SLACK_WEBHOOK_URL")
+	}
+	if os.Getenv("SLACK_TOKEN") != "" {
+		cfg.Slack.Token = os.Getenv("SLACK_TOKEN")
+	}
+	if os.Getenv("SLACK_CHANNEL") != "" {
+		cfg.Slack.Channel = os.Getenv("SLACK_CHANNEL")
+	}
+	return cfg, cfg.Validate()
+}
+
+// render renders the report in -format, or defaultFormat when not specified
+func render(r dus.Report, defaultFormat dus.Format) string {
+	format := defaultFormat
+	if *formatStr != "" {
+		f, err := dus.ParseFormat(*formatStr)
+		if err != nil {
+			log.Fatalf("parse format: %s", err)
+		}
+		format = f
+	}
+	out, err := dus.Render(r, format)
+	if err != nil {
+		log.Fatalf("render report: %s", err)
+	}
+	return out
+}
+
+// output writes the report to stdout in -encoding
+// slack is always sent in UTF-8, so the encoding is applied only here
+func output(out string) {
+	w, err := charset.NewWriter(os.Stdout, *encodingStr)
+	if err != nil {
+		log.Fatalf("create writer: %s", err)
+	}
+	if _, err := io.WriteString(w, out); err != nil {
+		log.Fatalf("write report: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		log.Fatalf("write report: %s", err)
+	}
+}
+
+// notify sends the report to slack
+func notify(ctx context.Context, cfg *config.Config, text string) {
+	notifyMessage(ctx, cfg, &slack.Message{Text: text})
+}
+
+// notifyMessage sends the message to slack
+func notifyMessage(ctx context.Context, cfg *config.Config, msg *slack.Message) {
+	n, err := slack.NewNotifier(cfg.Slack.WebhookURL, cfg.Slack.Token, cfg.Slack.Channel, cfg.Slack.Username)
+	if err != nil {
+		log.Fatalf("slack notifier: %s", err)
+	}
+	if err := n.Notify(ctx, msg); err != nil {
+		log.Fatalf("slack post message failed: %s", err)
+	}
+}
+
+// publishReport commits the report in markdown to the publish repository and opens a pull request
+func publishReport(ctx context.Context, ghcli igh.Client, cfg *config.Config, name string, date time.Time, r dus.Report, summary string) {
+	p, err := publish.NewPublisher(ghcli, cfg.Publish)
+	if err != nil {
+		log.Fatalf("publisher: %s", err)
+	}
+	content, err := dus.Render(r, dus.FormatMarkdown)
+	if err != nil {
+		log.Fatalf("render report: %s", err)
+	}
+	url, err := p.Publish(ctx, &publish.Report{Name: name, Date: date, Content: content, Summary: summary})
+	if err != nil {
+		log.Fatalf("publish %s: %s", name, err)
+	}
+	log.Printf("published %s: %s", name, url)
+}
+
+// newTransport creates transport of GitHub API which records or replays responses by -record and -replay
+// it returns nil for the default transport
+func newTransport() (http.RoundTripper, error) {
+	switch {
+	case *recordDir != "" && *replayDir != "":
+		return nil, errors.New("-record and -replay are exclusive")
+	case *recordDir != "":
+		return igh.NewRecordTransport(*recordDir, nil)
+	case *replayDir != "":
+		return igh.NewReplayTransport(*replayDir)
+	}
+	return nil, nil
+}
+
+// notifyContext returns context which is canceled on SIGINT or SIGTERM
+// the signal is handled only once, so the second one kills the process as usual
+func notifyContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(sig)
+		select {
+		case s := <-sig:
+			log.Printf("received %s, canceling", s)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// withTimeout returns context which is canceled after -timeout, or ctx itself when it is not set
+func withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if *timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, *timeout)
+}
+
+// listenAndServe serves handler on addr until ctx is done, then waits for in-flight requests to finish
+func listenAndServe(ctx context.Context, addr string, handler http.Handler) error {
+	srv := &http.Server{Addr: addr, Handler: handler}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown: %s", err)
+	}
+	log.Printf("stopped serving on %s", addr)
+	return nil
+}
+
+// newRepository creates repository which reads the local store when it is configured, otherwise GitHub API
+func newRepository(ghcli igh.Client, cfg *config.Config) (dus.Repository, error) {
+	if cfg.Store.Dir == "" {
+		return ius.NewUserSupportRepository(ghcli, cfg.Target.Repos(), cfg.Target.Orgs, cfg.Target.SupportLabel), nil
+	}
+	st, err := store.New(cfg.Store.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("open store: %s", err)
+	}
+	return ius.NewCachedUserSupportRepository(ghcli, st, cfg.Target.Repos(), cfg.Target.Orgs, cfg.Target.SupportLabel, *concurrency), nil
+}
+
+// newUserSupport creates UserSupport of the configured repository
+func newUserSupport(ghcli igh.Client, cfg *config.Config) dus.UserSupport {
+	uscfg, err := cfg.UserSupport()
+	if err != nil {
+		log.Fatalf("usersupport config: %s", err)
+	}
+	uscfg.Workers = *concurrency
+	repo, err := newRepository(ghcli, cfg)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return dus.NewUserSupport(repo, uscfg)
+}
+
+// splitList splits comma separated values
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// planSpans returns n spans of kind going back from the one which contains origin
+// custom kind spans since~origin and ones as long as it before them
+func planSpans(p *period.Planner, kindStr, sinceStr string, origin time.Time, n int) ([]period.Span, error) {
+	kind, err := period.ParseKind(kindStr)
+	if err != nil {
+		return nil, err
+	}
+	if kind != period.Custom {
+		return p.Spans(kind, origin, n)
+	}
+	if sinceStr == "" {
+		return nil, errors.New("need to set -since with -kind custom")
+	}
+	since, err := p.ParseDate(sinceStr)
+	if err != nil {
+		return nil, err
+	}
+	return p.CustomSpans(since, origin, n)
 }
 
 func main() {
-	jst, _ := time.LoadLocation("Asia/Tokyo")
 	flag.Parse()
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatalf("load config: %s", err)
+	}
+	planner, err := cfg.Planner()
+	if err != nil {
+		log.Fatalf("load config: %s", err)
+	}
+	if *formatStr != "" {
+		if _, err := dus.ParseFormat(*formatStr); err != nil {
+			log.Fatalf("parse format: %s", err)
+		}
+	}
 	if os.Getenv("GITHUB_TOKEN") != "" {
 		*ghToken = os.Getenv("GITHUB_TOKEN")
 	}
 	if os.Getenv("GITHUB_MAIL") != "" {
 		*ghMail = os.Getenv("GITHUB_MAIL")
 	}
-	ghcli, err := igh.NewGitHubClient(*ghURL, *ghToken, *ghUser, *ghMail)
+	transport, err := newTransport()
+	if err != nil {
+		log.Fatalf("github transport: %s", err)
+	}
+	ghcli, err := igh.NewGitHubClientWithTransport(*ghURL, *ghToken, *ghUser, *ghMail, transport)
 	if err != nil {
 		log.Fatalf("github client: %s", err)
 	}
-	subCommandArgs := os.Args[1+flag.NFlag():]
+	subCommandArgs := flag.Args()
 	if len(subCommandArgs) == 0 {
 		printDefaultsAll()
 		log.Fatalln("specify subcommand")
 	}
+	// in-flight requests are canceled on SIGINT and SIGTERM, and by -timeout
+	signalCtx, stop := notifyContext(context.Background())
+	defer stop()
+	ctx, cancel := withTimeout(signalCtx)
+	defer cancel()
 	switch subCommand := subCommandArgs[0]; subCommand {
 	case "daily-report":
 		if err := dailyReportFlag.Parse(subCommandArgs[1:]); err != nil {
 			log.Fatalf("parsing daily report flag: %s", err)
 		}
-		usrepo := ius.NewUserSupportRepository(ghcli)
-		us := dus.NewUserSupport(usrepo)
-		dairyStats, err := us.GetDailyReportStats(now, *dailyDayAgoInt)
+		us := newUserSupport(ghcli, cfg)
+		dairyStats, err := us.GetDailyReportStats(ctx, now, *dailyDayAgoInt)
 		if err != nil {
 			log.Fatalf("get user support stats: %s", err)
 		}
-		fmt.Printf("%s", dairyStats.GetDailyReportStats())
-		// channel := "times_t-sataga"
-		// username := "t-sataga"
-		// _, err = slack.PostMessage(channel, username, dairyStats.GetDailyReportStats())
-		// if err != nil {
-		// 	log.Fatalf("slack post message failed: %s", err)
-		// }
+		output(render(dairyStats, dus.FormatMarkdown))
+		if *dailyNotify {
+			notifyMessage(ctx, cfg, slack.DailyReportMessage(dairyStats))
+		}
 	case "longterm-report":
-		if err := userSupportFlag.Parse(subCommandArgs[1:]); err != nil {
+		if err := longtermReportFlag.Parse(subCommandArgs[1:]); err != nil {
 			log.Fatalf("parsing longterm report flag: %s", err)
 		}
-		usrepo := ius.NewUserSupportRepository(ghcli)
-		us := dus.NewUserSupport(usrepo)
-		var since, until time.Time
-		var err error
-		if until, err = time.ParseInLocation("2006-01-02", *longtermOriginStr, jst); err != nil {
-			log.Fatalf("could not parse: %s", *longtermOriginStr)
-		}
-		switch *longtermKindStr {
-		case "weekly":
-			since = until.AddDate(0, 0, -7)
-		case "monthly":
-			since = time.Date(until.Year(), until.Month(), 1, 0, 0, 0, 0, loc)
-			until = since.AddDate(0, +1, -1)
-		}
-		LongTermStats := &dus.LongTermStats{
-			SummaryStats: make(map[string]*dus.SummaryStats, *longtermSpanInt),
-			DetailStats:  make(map[int]*dus.DetailStats),
-		}
-		for i := 1; i <= *longtermSpanInt; i++ {
-			result, err := us.GetLongTermReportStats(since, until)
-			for key, val := range result.SummaryStats {
-				LongTermStats.SummaryStats[key] = val
-			}
-			for _, val := range result.DetailStats {
-				LongTermStats.DetailStats[cnt] = val
-				cnt++
-			}
-			if err != nil {
-				log.Fatalf("get longterm stats: %s", err)
-			}
-			switch *longtermKindStr {
-			case "weekly":
-				since = since.AddDate(0, 0, -7)
-				until = until.AddDate(0, 0, -7)
-			case "monthly":
-				since = time.Date(since.Year(), since.Month()-1, 1, 0, 0, 0, 0, loc)
-				until = since.AddDate(0, +1, -1)
-			}
+		us := newUserSupport(ghcli, cfg)
+		// reports are published to the path dated by the flag
+		origin, err := planner.ParseDate(*longtermOriginStr)
+		if err != nil {
+			log.Fatalf("could not parse: %s", err)
+		}
+		spans, err := planSpans(planner, *longtermKindStr, *longtermSinceStr, origin, *longtermSpanInt)
+		if err != nil {
+			log.Fatalf("plan spans: %s", err)
+		}
+		LongTermStats, err := dus.NewSpanFetcher(us, *concurrency).LongTermStats(ctx, spans)
+		if err != nil {
+			log.Fatalf("get longterm stats: %s", err)
+		}
+		out := render(LongTermStats, dus.FormatMarkdown)
+		output(out)
+		if *longtermNotify {
+			notify(ctx, cfg, out)
+		}
+		if *longtermPublish {
+			publishReport(ctx, ghcli, cfg, "longterm-report", origin, LongTermStats, LongTermStats.GenSummary())
 		}
-		fmt.Printf("%s", LongTermStats.GenLongTermReport())
 	case "analysis-report":
 		if err := analysisReportFlag.Parse(subCommandArgs[1:]); err != nil {
 			log.Fatalf("parsing analysis support flag: %s", err)
 		}
-		usrepo := ius.NewUserSupportRepository(ghcli)
-		us := dus.NewUserSupport(usrepo)
+		us := newUserSupport(ghcli, cfg)
 		var since, until time.Time
 		var err error
-		if since, err = time.Parse("2006-01-02", *analysisSinceStr); err != nil {
-			log.Fatalf("could not parse: %s", *analysisSinceStr)
+		if since, err = planner.ParseDate(*analysisSinceStr); err != nil {
+			log.Fatalf("could not parse: %s", err)
 		}
-		if until, err = time.Parse("2006-01-02", *analysisUntilStr); err != nil {
-			log.Fatalf("could not parse: %s", *analysisUntilStr)
+		if until, err = planner.ParseDate(*analysisUntilStr); err != nil {
+			log.Fatalf("could not parse: %s", err)
 		}
-		since = time.Date(since.Year(), since.Month(), 1, 0, 0, 0, 0, loc)
-		until = since.AddDate(0, +1, -1)
-		AnalysisStats := &dus.AnalysisStats{
-			DetailStats: make(map[int]*dus.DetailStats),
+		// spans going back from the one of since, or since~until for custom kind
+		origin := since
+		if *analysisKindStr == string(period.Custom) {
+			origin = until
 		}
-		for i := 1; i <= *analysisSpanInt; i++ {
-			result, err := us.GetAnalysisReportStats(since, until, *analysisStateStr)
-			if err != nil {
-				log.Fatalf("get user support stats: %s", err)
-			}
-			for _, val := range result.DetailStats {
-				AnalysisStats.DetailStats[cnt] = val
-				cnt++
-			}
-			since = time.Date(since.Year(), since.Month()-1, 1, 0, 0, 0, 0, loc)
-			until = since.AddDate(0, +1, -1)
+		spans, err := planSpans(planner, *analysisKindStr, *analysisSinceStr, origin, *analysisSpanInt)
+		if err != nil {
+			log.Fatalf("plan spans: %s", err)
+		}
+		AnalysisStats, err := dus.NewSpanFetcher(us, *concurrency).AnalysisStats(ctx, spans, *analysisStateStr)
+		if err != nil {
+			log.Fatalf("get user support stats: %s", err)
+		}
+		out := render(AnalysisStats, dus.FormatCSV)
+		output(out)
+		if *analysisNotify {
+			notify(ctx, cfg, out)
 		}
-		// fmt.Printf("Reporting Stats From: %s, Until: %s\n", since, until)
-		fmt.Printf("%s", AnalysisStats.GenAnalysisReport())
 	case "keyword-report":
 		if err := keywordReportFlag.Parse(subCommandArgs[1:]); err != nil {
 			log.Fatalf("parsing keyword report flag: %s", err)
 		}
-		usrepo := ius.NewUserSupportRepository(ghcli)
-		us := dus.NewUserSupport(usrepo)
+		us := newUserSupport(ghcli, cfg)
+		// reports are published to the path dated by the flag
+		origin, err := planner.ParseDate(*keywordUntilStr)
+		if err != nil {
+			log.Fatalf("could not parse: %s", err)
+		}
+		spans, err := planSpans(planner, *keywordKindStr, *keywordSinceStr, origin, *keywordSpanInt)
+		if err != nil {
+			log.Fatalf("plan spans: %s", err)
+		}
+		KeywordStats, err := dus.NewSpanFetcher(us, *concurrency).KeywordStats(ctx, spans)
+		if err != nil {
+			log.Fatalf("get keyword stats: %s", err)
+		}
+		out := render(KeywordStats, dus.FormatMarkdown)
+		output(out)
+		if *keywordNotify {
+			notify(ctx, cfg, out)
+		}
+		if *keywordPublish {
+			publishReport(ctx, ghcli, cfg, "keyword-report", origin, KeywordStats, KeywordStats.GenSummary())
+		}
+
+	case "assignee-report":
+		if err := assigneeReportFlag.Parse(subCommandArgs[1:]); err != nil {
+			log.Fatalf("parsing assignee report flag: %s", err)
+		}
+		if *assigneeMaxOpenInt > 0 {
+			cfg.Team.MaxOpenIssues = *assigneeMaxOpenInt
+		}
+		us := newUserSupport(ghcli, cfg)
 		var since, until time.Time
 		var err error
-		if until, err = time.ParseInLocation("2006-01-02", *keywordUntilStr, jst); err != nil {
+		if since, err = planner.ParseDate(*assigneeSinceStr); err != nil {
 			log.Fatalf("could not parse: %s", err)
 		}
-		switch *keywordKindStr {
-		case "weekly":
-			since = until.AddDate(0, 0, -7)
-		case "monthly":
-			since = time.Date(until.Year(), until.Month(), 1, 0, 0, 0, 0, loc)
-			until = since.AddDate(0, +1, -1)
+		if until, err = planner.ParseDate(*assigneeUntilStr); err != nil {
+			log.Fatalf("could not parse: %s", err)
 		}
-		KeywordStats := &dus.KeywordStats{
-			KeywordSummary: make(map[string]*dus.KeywordSummary),
+		// issues closed on the until date are counted as well
+		span := period.Span{Since: since, Until: until}
+		AssigneeStats, err := us.GetAssigneeReportStats(ctx, now, span.Since, span.End(), *assigneeDayAgoInt)
+		if err != nil {
+			log.Fatalf("get assignee stats: %s", err)
 		}
-		for i := 1; i <= *longtermSpanInt; i++ {
-			result, err := us.GetKeywordReportStats(since, until)
-			if err != nil {
-				log.Fatalf("get keyword stats: %s", err)
-			}
-			for key, val := range result.KeywordSummary {
-				KeywordStats.KeywordSummary[key] = val
-			}
-			if err != nil {
-				log.Fatalf("get longterm stats: %s", err)
-			}
-			switch *longtermKindStr {
-			case "weekly":
-				since = since.AddDate(0, 0, -7)
-				until = until.AddDate(0, 0, -7)
-			case "monthly":
-				since = time.Date(since.Year(), since.Month()-1, 1, 0, 0, 0, 0, loc)
-				until = since.AddDate(0, +1, -1)
+		out := render(AssigneeStats, dus.FormatMarkdown)
+		output(out)
+		if *assigneeNotify {
+			notify(ctx, cfg, out)
+		}
+	case "nudge":
+		if err := nudgeFlag.Parse(subCommandArgs[1:]); err != nil {
+			log.Fatalf("parsing nudge flag: %s", err)
+		}
+		us := newUserSupport(ghcli, cfg)
+		NudgeStats, err := us.NudgeStaleIssues(ctx, now, *nudgeDayAgoInt, *nudgeDryRun)
+		if NudgeStats == nil {
+			log.Fatalf("nudge stale issues: %s", err)
+		}
+		// issues nudged before an error are reported, so they are not left unknown
+		out := render(NudgeStats, dus.FormatMarkdown)
+		output(out)
+		if *nudgeNotify {
+			notify(ctx, cfg, out)
+		}
+		if err != nil {
+			log.Fatalf("nudge stale issues: %s", err)
+		}
+	case "sync":
+		if cfg.Store.Dir == "" {
+			log.Fatalln("need to set -store or store.dir of config")
+		}
+		st, err := store.New(cfg.Store.Dir)
+		if err != nil {
+			log.Fatalf("open store: %s", err)
+		}
+		usrepo := ius.NewCachedUserSupportRepository(ghcli, st, cfg.Target.Repos(), cfg.Target.Orgs, cfg.Target.SupportLabel, *concurrency)
+		if err := usrepo.Sync(ctx); err != nil {
+			log.Fatalf("sync store: %s", err)
+		}
+	case "serve":
+		if err := serveFlag.Parse(subCommandArgs[1:]); err != nil {
+			log.Fatalf("parsing serve flag: %s", err)
+		}
+		serve(signalCtx, ghcli, cfg)
+	case "webhook":
+		if err := webhookFlag.Parse(subCommandArgs[1:]); err != nil {
+			log.Fatalf("parsing webhook flag: %s", err)
+		}
+		if cfg.Store.Dir == "" {
+			log.Fatalln("need to set -store or store.dir of config")
+		}
+		st, err := store.New(cfg.Store.Dir)
+		if err != nil {
+			log.Fatalf("open store: %s", err)
+		}
+		var n slack.Notifier
+		if len(cfg.Webhook.Rules) > 0 {
+			if n, err = slack.NewNotifier(cfg.Slack.WebhookURL, cfg.Slack.Token, cfg.Slack.Channel, cfg.Slack.Username); err != nil {
+				log.Fatalf("slack notifier: %s", err)
 			}
 		}
-		fmt.Printf("%s", KeywordStats.GenKeywordReport())
-
-	case "slacktest":
-		channel := "times_t-sataga"
-		username := "t-sataga"
-		text := "hogehoge"
-		_, err := slack.PostMessage(channel, username, text)
+		h, err := webhook.NewHandler(cfg.Webhook.Secret, st, cfg.Target.Repos(), cfg.Target.Orgs, cfg.Target.SupportLabel, cfg.Webhook.Rules, n)
+		if err != nil {
+			log.Fatalf("webhook handler: %s", err)
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/webhook", h)
+		log.Printf("receiving webhook on %s/webhook", *webhookListenStr)
+		if err := listenAndServe(signalCtx, *webhookListenStr, mux); err != nil {
+			log.Fatalf("serve webhook: %s", err)
+		}
+		h.Wait()
+	case "timeline-report":
+		if err := timelineReportFlag.Parse(subCommandArgs[1:]); err != nil {
+			log.Fatalf("parsing timeline report flag: %s", err)
+		}
+		us := newUserSupport(ghcli, cfg)
+		origin, err := planner.ParseDate(*timelineOriginStr)
 		if err != nil {
-			log.Fatalf("slack post message failed: %s", err)
+			log.Fatalf("could not parse: %s", err)
+		}
+		spans, err := planSpans(planner, *timelineKindStr, *timelineSinceStr, origin, *timelineSpanInt)
+		if err != nil {
+			log.Fatalf("plan spans: %s", err)
+		}
+		TimelineStats, err := dus.NewSpanFetcher(us, *concurrency).TimelineStats(ctx, spans)
+		if err != nil {
+			log.Fatalf("get timeline stats: %s", err)
 		}
+		out := render(TimelineStats, dus.FormatMarkdown)
+		output(out)
+		if *timelineNotify {
+			notify(ctx, cfg, out)
+		}
+	case "slacktest":
+		notify(ctx, cfg, "hogehoge")
 	case "methodtest":
 		if err := userSupportFlag.Parse(subCommandArgs[1:]); err != nil {
 			log.Fatalf("parsing user support flag: %s", err)
 		}
-		usrepo := ius.NewUserSupportRepository(ghcli)
-		us := dus.NewUserSupport(usrepo)
+		us := newUserSupport(ghcli, cfg)
 		var since, until time.Time
 		var err error
-		if since, err = time.Parse("2006-01-02", *sinceStr); err != nil {
-			log.Fatalf("could not parse: %s", *sinceStr)
+		if since, err = planner.ParseDate(*sinceStr); err != nil {
+			log.Fatalf("could not parse: %s", err)
 		}
-		if until, err = time.Parse("2006-01-02", *untilStr); err != nil {
-			log.Fatalf("could not parse: %s", *untilStr)
+		if until, err = planner.ParseDate(*untilStr); err != nil {
+			log.Fatalf("could not parse: %s", err)
 		}
-		fmt.Printf("Reporting Stats From: %s, Until: %s\n", since, until)
-		testStats, err := us.MethodTest(since, until)
+		log.Printf("reporting stats from: %s, until: %s", since, until)
+		testStats, err := us.MethodTest(ctx, since, until)
 		if err != nil {
 			log.Fatalf("get user support stats: %s", err)
 		}
-		fmt.Printf("%v", testStats)
+		out := render(testStats, dus.FormatCSV)
+		output(out)
+		if *usNotify {
+			notify(ctx, cfg, out)
+		}
 	}
 
 }
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	dus "github.com/sataga/go-github-sample/domain/usersupport"
+	"github.com/sataga/go-github-sample/infra/fakegithub"
+)
+
+// cliEnv makes the test binary run main instead of tests, so runCLI executes the CLI as a process
+const cliEnv = "GO_GITHUB_SAMPLE_CLI"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(cliEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// cliCommand returns command which runs the CLI against GitHub API of baseURL
+func cliCommand(baseURL string, args ...string) *exec.Cmd {
+	args = append([]string{"-ghurl", baseURL, "-ghtoken", "token", "-ghmail", "sataga@example.com"}, args...)
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), cliEnv+"=1", "GITHUB_TOKEN=", "GITHUB_MAIL=", "SLACK_WEBHOOK_URL=", "SLACK_TOKEN=")
+	return cmd
+}
+
+// runCLI runs the CLI against GitHub API of baseURL and returns its stdout
+func runCLI(t *testing.T, baseURL string, args ...string) string {
+	t.Helper()
+	cmd := cliCommand(baseURL, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("go-github-sample %s: %s\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return stdout.String()
+}
+
+func newFakeGitHub(t *testing.T) *fakegithub.Server {
+	t.Helper()
+	srv, err := fakegithub.NewServerFromDir(filepath.Join("testdata", "fakegithub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv
+}
+
+func TestCLI_LongtermReport(t *testing.T) {
+	srv := newFakeGitHub(t)
+	defer srv.Close()
+
+	out := runCLI(t, srv.URL, "-format", "json", "longterm-report", "-kind", "monthly", "-span", "1", "-origin", "2020-10-15")
+	// detail_stats is written as an array, so only the summary is decoded
+	var got struct {
+		SummaryStats map[string]*dus.SummaryStats `json:"summary_stats"`
+	}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("decode output: %s\n%s", err, out)
+	}
+	if len(got.SummaryStats) != 1 {
+		t.Fatalf("summary_stats = %v, want 1 span", got.SummaryStats)
+	}
+	for span, s := range got.SummaryStats {
+		// issue 4 was created in september, and issue 2 is open
+		if s.NumCreatedIssues != 3 || s.NumClosedIssues != 3 {
+			t.Errorf("summary of %s = %d created, %d closed, want 3 created, 3 closed", span, s.NumCreatedIssues, s.NumClosedIssues)
+		}
+		if s.NumEscalationAllIssues != 1 || s.NumGenreIssues[dus.ClassGenreFailure] != 1 {
+			t.Errorf("summary of %s = %d escalation, %d failure, want 1 and 1", span, s.NumEscalationAllIssues, s.NumGenreIssues[dus.ClassGenreFailure])
+		}
+	}
+}
+
+func TestCLI_LongtermReportKinds(t *testing.T) {
+	srv := newFakeGitHub(t)
+	defer srv.Close()
+
+	// issue 1 is closed on 2020-10-03, issue 4 on 2020-10-02 and issue 3 on 2020-10-20
+	tests := []struct {
+		name string
+		args []string
+		// want is the number of closed issues per span
+		want map[string]int
+	}{
+		{
+			name: "quarterly",
+			args: []string{"-kind", "quarterly", "-span", "2", "-origin", "2020-10-15"},
+			want: map[string]int{"2020-10-01~2020-12-31": 3, "2020-07-01~2020-09-30": 0},
+		},
+		{
+			name: "weekly",
+			args: []string{"-kind", "weekly", "-span", "1", "-origin", "2020-10-15"},
+			want: map[string]int{"2020-10-12~2020-10-18": 0},
+		},
+		{
+			name: "daily counts the day",
+			args: []string{"-kind", "daily", "-span", "1", "-origin", "2020-10-03"},
+			want: map[string]int{"2020-10-03~2020-10-03": 1},
+		},
+		{
+			name: "custom",
+			args: []string{"-kind", "custom", "-span", "2", "-since", "2020-10-01", "-origin", "2020-10-10"},
+			want: map[string]int{"2020-10-01~2020-10-10": 2, "2020-09-21~2020-09-30": 0},
+		},
+		{
+			name: "custom counts the last day",
+			args: []string{"-kind", "custom", "-span", "1", "-since", "2020-10-01", "-origin", "2020-10-20"},
+			want: map[string]int{"2020-10-01~2020-10-20": 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := runCLI(t, srv.URL, append([]string{"-format", "json", "longterm-report"}, tt.args...)...)
+			var got struct {
+				SummaryStats map[string]*dus.SummaryStats `json:"summary_stats"`
+			}
+			if err := json.Unmarshal([]byte(out), &got); err != nil {
+				t.Fatalf("decode output: %s\n%s", err, out)
+			}
+			if len(got.SummaryStats) != len(tt.want) {
+				t.Fatalf("summary_stats = %v, want spans %v", got.SummaryStats, tt.want)
+			}
+			for span, closed := range tt.want {
+				s, ok := got.SummaryStats[span]
+				if !ok {
+					t.Errorf("summary_stats has no span %s", span)
+					continue
+				}
+				if s.NumClosedIssues != closed {
+					t.Errorf("summary of %s = %d closed, want %d", span, s.NumClosedIssues, closed)
+				}
+			}
+		})
+	}
+}
+
+func TestCLI_KeywordReport(t *testing.T) {
+	srv := newFakeGitHub(t)
+	defer srv.Close()
+
+	out := runCLI(t, srv.URL, "-format", "json", "keyword-report", "-kind", "monthly", "-span", "1", "-until", "2020-10-15")
+	var got dus.KeywordStats
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("decode output: %s\n%s", err, out)
+	}
+	if len(got.KeywordSummary) != 1 {
+		t.Fatalf("keyword_summary = %v, want 1 span", got.KeywordSummary)
+	}
+	for span, s := range got.KeywordSummary {
+		if s.KeywordCountAsAll["keyword:Kubernetes"] != 1 || s.KeywordCountAsEscalation["keyword:Network"] != 1 {
+			t.Errorf("keyword summary of %s = %+v, want Kubernetes 1 and escalated Network 1", span, s)
+		}
+	}
+}
+
+func TestCLI_AssigneeReport(t *testing.T) {
+	srv := newFakeGitHub(t)
+	defer srv.Close()
+
+	// issue 1 of alice is closed on 2020-10-03, and issue 4 without assignee on 2020-10-02
+	out := runCLI(t, srv.URL, "-format", "json", "assignee-report", "-since", "2020-10-01", "-until", "2020-10-03")
+	var got dus.AssigneeStats
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("decode output: %s\n%s", err, out)
+	}
+	if got.Span != "2020-10-01~2020-10-03" {
+		t.Errorf("span = %s, want 2020-10-01~2020-10-03", got.Span)
+	}
+	for login, want := range map[string]int{"alice": 1, dus.Unassigned: 1} {
+		s, ok := got.Assignees[login]
+		if !ok {
+			t.Errorf("assignees have no %s", login)
+			continue
+		}
+		if s.NumClosedIssues != want {
+			t.Errorf("closed issues of %s = %d, want %d", login, s.NumClosedIssues, want)
+		}
+	}
+}
+
+func TestCLI_Nudge(t *testing.T) {
+	srv := newFakeGitHub(t)
+	defer srv.Close()
+
+	out := runCLI(t, srv.URL, "-format", "json", "nudge", "-day-ago", "7")
+	var got dus.NudgeStats
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("decode output: %s\n%s", err, out)
+	}
+	if len(got.Nudges) != 1 || got.Nudges[0].Status != dus.NudgeStatusNudged {
+		t.Fatalf("nudges = %+v, want issue 2 nudged", got.Nudges)
+	}
+	comments := srv.Comments("sataga/issue-warehouse", 2)
+	if len(comments) != 1 || !strings.Contains(comments[0].GetBody(), "@alice") {
+		t.Errorf("comments of issue 2 = %v, want a nudge mentioning @alice", comments)
+	}
+
+	// the issue is in cooldown on the next run
+	out = runCLI(t, srv.URL, "-format", "json", "nudge", "-day-ago", "0")
+	got = dus.NudgeStats{}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("decode output: %s\n%s", err, out)
+	}
+	if len(got.Nudges) != 1 || got.Nudges[0].Status != dus.NudgeStatusCooldown {
+		t.Errorf("nudges = %+v, want issue 2 in cooldown", got.Nudges)
+	}
+}
+
+func TestCLI_RecordAndReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	srv := newFakeGitHub(t)
+
+	args := []string{"-format", "csv", "analysis-report", "-since", "2020-10-01", "-until", "2020-10-31", "-span", "2"}
+	recorded := runCLI(t, srv.URL, append([]string{"-record", dir}, args...)...)
+	srv.Close()
+	replayed := runCLI(t, "http://127.0.0.1:1", append([]string{"-replay", dir}, args...)...)
+	if replayed != recorded {
+		t.Errorf("replayed output = %s, want %s", replayed, recorded)
+	}
+	if !strings.Contains(recorded, "コンテナが起動しない") {
+		t.Errorf("output = %s, want issue 1", recorded)
+	}
+}
+
+func TestCLI_Timeout(t *testing.T) {
+	// GitHub API which never responds until the request is canceled
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	cmd := cliCommand(srv.URL, "-timeout", "500ms", "daily-report")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	started := time.Now()
+	err := cmd.Run()
+	if err == nil {
+		t.Fatal("daily-report succeeded, want timeout")
+	}
+	if elapsed := time.Since(started); elapsed > 10*time.Second {
+		t.Errorf("daily-report took %s, want to be canceled by -timeout", elapsed)
+	}
+	if !strings.Contains(stderr.String(), context.DeadlineExceeded.Error()) {
+		t.Errorf("stderr = %s, want %s", stderr.String(), context.DeadlineExceeded)
+	}
+}
+
+func TestCLI_ServeSurvivesStoreError(t *testing.T) {
+	srv := newFakeGitHub(t)
+	defer srv.Close()
+	// store can not be opened, since its dir is a regular file
+	f, err := ioutil.TempFile("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	cmd := cliCommand(srv.URL, "-store", f.Name(), "serve", "-listen", addr, "-interval", "100ms")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill()
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	// refresh errors are counted on every tick while the daemon keeps serving
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		select {
+		case err := <-exited:
+			t.Fatalf("serve exited: %v\n%s", err, stderr.String())
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("refresh errors were not counted\n%s", stderr.String())
+		}
+		if res, err := http.Get("http://" + addr + "/metrics"); err == nil {
+			b, _ := ioutil.ReadAll(res.Body)
+			res.Body.Close()
+			if strings.Contains(string(b), "usersupport_refresh_errors_total 3") {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-exited; err != nil {
+		t.Errorf("serve stopped with %v\n%s", err, stderr.String())
+	}
+}
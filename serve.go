@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sataga/go-github-sample/config"
+	dus "github.com/sataga/go-github-sample/domain/usersupport"
+	igh "github.com/sataga/go-github-sample/infra/github"
+	"github.com/sataga/go-github-sample/infra/metrics"
+	ius "github.com/sataga/go-github-sample/infra/usersupport"
+)
+
+// serve refreshes metrics every -interval and exposes them on /metrics until ctx is done
+// issues are synced into the local store before refreshing when it is configured
+func serve(ctx context.Context, ghcli igh.Client, cfg *config.Config) {
+	uscfg, err := cfg.UserSupport()
+	if err != nil {
+		log.Fatalf("usersupport config: %s", err)
+	}
+	collector := metrics.NewCollector()
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(collector, prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+
+	refresh := func() {
+		// -timeout bounds every refresh, so a hung request does not stop the following ones
+		ctx, cancel := withTimeout(ctx)
+		defer cancel()
+		// cached repository syncs only once, so it is created on every refresh
+		repo, err := newRepository(ghcli, cfg)
+		if err != nil {
+			// the store may be back on the next refresh, so the daemon keeps serving the last metrics
+			log.Println(err)
+			collector.RecordError()
+			return
+		}
+		if cached, ok := repo.(ius.CachedRepository); ok {
+			if err := cached.Sync(ctx); err != nil {
+				log.Printf("sync store: %s", err)
+				collector.RecordError()
+				return
+			}
+		}
+		now := time.Now()
+		stats, err := dus.NewUserSupport(repo, uscfg).GetMetricsStats(ctx, now, *serveDayAgoInt, *serveWindowInt)
+		if err != nil {
+			log.Printf("get metrics stats: %s", err)
+			collector.RecordError()
+			return
+		}
+		collector.Update(stats, now)
+	}
+	go func() {
+		refresh()
+		ticker := time.NewTicker(*serveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				refresh()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	log.Printf("serving metrics on %s/metrics", *serveListenStr)
+	if err := listenAndServe(ctx, *serveListenStr, mux); err != nil {
+		log.Fatalf("serve metrics: %s", err)
+	}
+}